@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/history"
 	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
 )
 
 // App Wails应用结构
@@ -79,6 +84,46 @@ func (a *App) CheckNow(ruleID string) error {
 	return a.coreAPI.CheckNow(ruleID)
 }
 
+// GetHistory 获取规则的内容历史
+// before 为RFC3339格式的时间字符串，为空时从最新的记录开始
+func (a *App) GetHistory(ruleID string, limit int, before string) ([]*history.Entry, error) {
+	var beforeTime time.Time
+	if before != "" {
+		t, err := time.Parse(time.RFC3339Nano, before)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时间格式: %w", err)
+		}
+		beforeTime = t
+	}
+	return a.coreAPI.GetHistory(ruleID, limit, beforeTime)
+}
+
+// GetDeliveries 获取通知的投递记录
+// status 为 pending、delivered、failed 之一，为空时返回所有状态
+func (a *App) GetDeliveries(status string, limit int) ([]*notification.Delivery, error) {
+	return a.coreAPI.GetDeliveries(notification.DeliveryStatus(status), limit)
+}
+
+// ResendDelivery 立即重新发送一条通知
+func (a *App) ResendDelivery(id string) error {
+	return a.coreAPI.ResendDelivery(id)
+}
+
+// ListSecrets 获取所有密钥的名称和更新时间，不返回密钥的值
+func (a *App) ListSecrets() ([]secrets.Info, error) {
+	return a.coreAPI.ListSecrets()
+}
+
+// SetSecret 新增或更新密钥
+func (a *App) SetSecret(name, value string) error {
+	return a.coreAPI.SetSecret(name, value)
+}
+
+// DeleteSecret 删除密钥
+func (a *App) DeleteSecret(name string) error {
+	return a.coreAPI.DeleteSecret(name)
+}
+
 // EventListener 实现事件监听器接口
 type EventListener struct {
 	ctx context.Context
@@ -0,0 +1,152 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zx06/apiwatch/config"
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/history"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/monitor"
+	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
+)
+
+// Options 组件装配选项
+type Options struct {
+	// ConfigPath 配置文件路径，为空时使用默认路径
+	ConfigPath string
+
+	// Notifier 初始的桌面通知器，为空时使用空通知器
+	Notifier notification.Notifier
+
+	// Manual 为true时不启动定时检查，只允许手动触发检查（用于命令行工具）
+	Manual bool
+}
+
+// Components 装配完成的核心组件
+type Components struct {
+	Config   *config.YAMLManager
+	Settings *config.Settings
+	History  history.Store
+	Monitor  *monitor.MonitorService
+	Router   *notification.Router
+	Secrets  *secrets.Store
+	Engine   *core.Engine
+}
+
+// Build 创建配置管理器、监控服务和核心引擎并完成相互连接
+//
+// 返回的引擎尚未初始化，调用方需要自行调用 Engine.Initialize。
+func Build(opts Options) (*Components, error) {
+	// 创建配置管理器
+	configMgr, err := config.NewYAMLManager(opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建配置管理器失败: %w", err)
+	}
+
+	settings, err := configMgr.LoadSettings()
+	if err != nil {
+		return nil, fmt.Errorf("加载全局设置失败: %w", err)
+	}
+
+	// 创建历史记录存储
+	historyStore, err := history.NewFileStore(settings.History.Dir, history.Retention{
+		MaxEntries: settings.History.MaxEntries,
+		MaxAge:     time.Duration(settings.History.MaxAge),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建历史记录存储失败: %w", err)
+	}
+
+	// 打开密钥存储，规则中的 ${secret:name} 在请求时从中读取
+	secretStore, err := secrets.Open(settings.Secrets.Path, settings.Secrets.KeyFile, os.Getenv(secrets.PassphraseEnv))
+	if err != nil {
+		return nil, fmt.Errorf("打开密钥存储失败: %w", err)
+	}
+
+	// 创建HTTP客户端
+	httpFetcher := fetcher.NewHTTPFetcher()
+	httpFetcher.SetSecrets(secretStore)
+	httpFetcher.SetHostLimiter(newHostLimiter(settings.HTTP))
+	httpFetcher.SetRespectRobots(settings.HTTP.RespectRobots)
+	if err := httpFetcher.SetProxy(settings.HTTP.Proxy, settings.HTTP.NoProxy); err != nil {
+		return nil, fmt.Errorf("代理设置无效: %w", err)
+	}
+
+	// 创建通知路由
+	router := notification.NewRouter(settings.Channels, settings.SMTP, opts.Notifier)
+	router.SetDispatcher(notification.NewDispatcher(settings.Notifications.Workers, settings.Notifications.QueueSize))
+
+	// 打开通知发件箱，未送达的远程通知在重启后继续重试
+	outbox, err := notification.OpenOutbox(settings.Notifications.OutboxPath, settings.Notifications.MaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("打开通知发件箱失败: %w", err)
+	}
+	router.SetOutbox(outbox)
+
+	// 手动模式（命令行工具）不重试，避免与同时运行的守护进程或桌面应用重复发送
+	if !opts.Manual {
+		router.StartRetry()
+	}
+
+	// 创建核心引擎（先声明，以便设置回调）
+	var engine *core.Engine
+
+	// 创建规则更新回调函数
+	onRuleUpdate := func(rule *models.MonitorRule) {
+		if engine != nil {
+			// 更新Engine中的规则
+			engine.UpdateRuleInMemory(rule)
+
+			// 发布规则状态变化事件
+			engine.PublishEvent(core.Event{
+				Type:      core.EventRuleStatusChanged,
+				RuleID:    rule.ID,
+				Rule:      rule,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+
+	// 创建监控服务
+	monitorSvc := monitor.NewMonitorService(httpFetcher, onRuleUpdate)
+	monitorSvc.SetManual(opts.Manual)
+	monitorSvc.SetConcurrency(settings.Monitor.Concurrency)
+	monitorSvc.SetHistory(historyStore)
+
+	// 创建核心引擎
+	engine = core.NewEngine(configMgr, monitorSvc, router, historyStore)
+	engine.SetSecrets(secretStore)
+
+	return &Components{
+		Config:   configMgr,
+		Settings: settings,
+		History:  historyStore,
+		Monitor:  monitorSvc,
+		Router:   router,
+		Secrets:  secretStore,
+		Engine:   engine,
+	}, nil
+}
+
+// newHostLimiter 根据设置创建按主机的请求限制器
+func newHostLimiter(settings config.HTTPSettings) *fetcher.HostLimiter {
+	hosts := make(map[string]fetcher.HostLimit, len(settings.Hosts))
+	for host, limit := range settings.Hosts {
+		hosts[host] = hostLimit(limit)
+	}
+	return fetcher.NewHostLimiter(hostLimit(settings.HostLimit), hosts)
+}
+
+// hostLimit 将配置中的主机请求限制转换为请求限制器使用的格式
+func hostLimit(limit models.HostLimit) fetcher.HostLimit {
+	return fetcher.HostLimit{
+		Rate:          limit.Rate,
+		Burst:         limit.Burst,
+		MaxConcurrent: limit.MaxConcurrent,
+	}
+}
@@ -0,0 +1,81 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/notification"
+)
+
+// seedOutbox 在配置目录写入一条到期待重试的Webhook通知
+func seedOutbox(t *testing.T, dir, webhookURL string) {
+	t.Helper()
+
+	now := time.Now().Add(-time.Minute)
+	entries := []map[string]any{{
+		"id":           "pending-1",
+		"rule_id":      "r1",
+		"channel":      "ops",
+		"title":        "内容变化",
+		"status":       "pending",
+		"attempts":     1,
+		"created_at":   now,
+		"updated_at":   now,
+		"next_attempt": now,
+		"config":       map[string]any{"type": "webhook", "webhook": map[string]any{"url": webhookURL}},
+		"message":      map[string]any{"rule_id": "r1", "title": "内容变化", "body": "正文", "timestamp": now},
+	}}
+	data, err := json.Marshal(entries)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "outbox.json"), data, 0600))
+}
+
+func TestBuild_OutboxRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		manual bool
+		want   int32
+	}{
+		{name: "手动模式不重试发件箱中的通知", manual: true, want: 0},
+		{name: "常驻模式重试发件箱中的通知", manual: false, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received.Add(1)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			seedOutbox(t, dir, server.URL)
+
+			components, err := Build(Options{
+				ConfigPath: filepath.Join(dir, "config.yaml"),
+				Manual:     tt.manual,
+			})
+			require.NoError(t, err)
+			require.NoError(t, components.Engine.Initialize())
+			defer components.Engine.Shutdown()
+
+			if tt.want > 0 {
+				assert.Eventually(t, func() bool {
+					return received.Load() == tt.want
+				}, 2*time.Second, 10*time.Millisecond)
+			} else {
+				time.Sleep(200 * time.Millisecond)
+				assert.Equal(t, tt.want, received.Load())
+				assert.Len(t, components.Router.Deliveries(notification.DeliveryPending, 0), 1, "待重试的通知仍可查看")
+			}
+		})
+	}
+}
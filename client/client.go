@@ -0,0 +1,365 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/history"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
+)
+
+const (
+	// requestTimeout 普通请求的超时时间（立即检查可能包含多次重试）
+	requestTimeout = 2 * time.Minute
+
+	// streamRetryInterval 事件流断开后的重连间隔
+	streamRetryInterval = 3 * time.Second
+)
+
+// Client 守护进程REST API客户端，实现core.CoreAPI接口
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+
+	// 事件订阅，每个监听器对应一个SSE连接
+	streams map[core.EventListener]context.CancelFunc
+	mu      sync.Mutex
+}
+
+// NewClient 创建API客户端
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		// 不设置整体超时，以免中断事件流；普通请求使用带超时的context
+		httpClient: &http.Client{},
+		streams:    make(map[core.EventListener]context.CancelFunc),
+	}
+}
+
+// APIError 服务端返回的错误
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Is 将HTTP状态码映射为核心层错误，便于使用errors.Is判断
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case core.ErrRuleNotFound, core.ErrDeliveryNotFound, core.ErrSecretNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case core.ErrInvalidRule, core.ErrInvalidSecret:
+		return e.StatusCode == http.StatusBadRequest
+	case core.ErrSecretsUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// GetRules 获取所有规则
+func (c *Client) GetRules() ([]*models.MonitorRule, error) {
+	var rules []*models.MonitorRule
+	if err := c.do(http.MethodGet, "/api/rules", nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRule 获取单个规则
+func (c *Client) GetRule(id string) (*models.MonitorRule, error) {
+	var rule models.MonitorRule
+	if err := c.do(http.MethodGet, "/api/rules/"+url.PathEscape(id), nil, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// AddRule 添加规则，成功后rule会被更新为服务端返回的内容（包含生成的ID）
+func (c *Client) AddRule(rule *models.MonitorRule) error {
+	return c.do(http.MethodPost, "/api/rules", rule, rule)
+}
+
+// UpdateRule 更新规则
+func (c *Client) UpdateRule(rule *models.MonitorRule) error {
+	return c.do(http.MethodPut, "/api/rules/"+url.PathEscape(rule.ID), rule, rule)
+}
+
+// DeleteRule 删除规则
+func (c *Client) DeleteRule(id string) error {
+	return c.do(http.MethodDelete, "/api/rules/"+url.PathEscape(id), nil, nil)
+}
+
+// StartMonitoring 启动监控
+func (c *Client) StartMonitoring(ruleID string) error {
+	return c.do(http.MethodPost, "/api/rules/"+url.PathEscape(ruleID)+"/start", nil, nil)
+}
+
+// StopMonitoring 停止监控
+func (c *Client) StopMonitoring(ruleID string) error {
+	return c.do(http.MethodPost, "/api/rules/"+url.PathEscape(ruleID)+"/stop", nil, nil)
+}
+
+// StopAllMonitoring 停止所有监控
+func (c *Client) StopAllMonitoring() error {
+	return c.do(http.MethodPost, "/api/monitoring/stop-all", nil, nil)
+}
+
+// CheckNow 立即检查
+func (c *Client) CheckNow(ruleID string) error {
+	return c.do(http.MethodPost, "/api/rules/"+url.PathEscape(ruleID)+"/check", nil, nil)
+}
+
+// GetHistory 获取规则的内容历史
+func (c *Client) GetHistory(ruleID string, limit int, before time.Time) ([]*history.Entry, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if !before.IsZero() {
+		query.Set("before", before.Format(time.RFC3339Nano))
+	}
+
+	path := "/api/rules/" + url.PathEscape(ruleID) + "/history"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var entries []*history.Entry
+	if err := c.do(http.MethodGet, path, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetDeliveries 获取通知的投递记录
+func (c *Client) GetDeliveries(status notification.DeliveryStatus, limit int) ([]*notification.Delivery, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", string(status))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	path := "/api/deliveries"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var deliveries []*notification.Delivery
+	if err := c.do(http.MethodGet, path, nil, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ResendDelivery 立即重新发送一条通知
+func (c *Client) ResendDelivery(id string) error {
+	return c.do(http.MethodPost, "/api/deliveries/"+url.PathEscape(id)+"/resend", nil, nil)
+}
+
+// ListSecrets 获取所有密钥的名称和更新时间
+func (c *Client) ListSecrets() ([]secrets.Info, error) {
+	var infos []secrets.Info
+	if err := c.do(http.MethodGet, "/api/secrets", nil, &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// SetSecret 新增或更新密钥
+func (c *Client) SetSecret(name, value string) error {
+	body := map[string]string{"value": value}
+	return c.do(http.MethodPut, "/api/secrets/"+url.PathEscape(name), body, nil)
+}
+
+// DeleteSecret 删除密钥
+func (c *Client) DeleteSecret(name string) error {
+	return c.do(http.MethodDelete, "/api/secrets/"+url.PathEscape(name), nil, nil)
+}
+
+// Subscribe 订阅事件，为监听器建立SSE连接并在断开后自动重连
+func (c *Client) Subscribe(listener core.EventListener) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.mu.Lock()
+	if old, exists := c.streams[listener]; exists {
+		old()
+	}
+	c.streams[listener] = cancel
+	c.mu.Unlock()
+
+	go func() {
+		for {
+			if err := c.streamEvents(ctx, listener); err != nil && ctx.Err() == nil {
+				slog.Warn("事件流已断开，稍后重连", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(streamRetryInterval):
+			}
+		}
+	}()
+}
+
+// Unsubscribe 取消订阅
+func (c *Client) Unsubscribe(listener core.EventListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cancel, exists := c.streams[listener]; exists {
+		cancel()
+		delete(c.streams, listener)
+	}
+}
+
+// Initialize 检查守护进程是否可用
+func (c *Client) Initialize() error {
+	if err := c.do(http.MethodGet, "/api/health", nil, nil); err != nil {
+		return fmt.Errorf("连接守护进程失败: %w", err)
+	}
+	return nil
+}
+
+// Shutdown 关闭所有事件流（不会关闭守护进程）
+func (c *Client) Shutdown() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for listener, cancel := range c.streams {
+		cancel()
+		delete(c.streams, listener)
+	}
+	return nil
+}
+
+// do 发送请求并解析JSON响应
+func (c *Client) do(method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := c.newRequest(ctx, method, path, bodyReader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求守护进程失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeAPIError(resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// newRequest 创建带认证信息的请求
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// streamEvents 读取SSE事件流并分发给监听器，直到连接断开或ctx取消
+func (c *Client) streamEvents(ctx context.Context, listener core.EventListener) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接事件流失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeAPIError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var event core.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			slog.Warn("解析事件失败", "error", err)
+			continue
+		}
+		listener.OnEvent(event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("事件流已关闭")
+}
+
+// decodeAPIError 解析错误响应
+func decodeAPIError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+		if body.Error == "" {
+			body.Error = resp.Status
+		}
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+}
@@ -0,0 +1,287 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/bootstrap"
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/server"
+)
+
+// newTestClient 启动基于真实引擎的REST服务并返回客户端
+func newTestClient(t *testing.T, token string) *Client {
+	t.Helper()
+
+	components, err := bootstrap.Build(bootstrap.Options{
+		ConfigPath: filepath.Join(t.TempDir(), "config.yaml"),
+		Manual:     true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, components.Engine.Initialize())
+
+	ts := httptest.NewServer(server.NewServer(components.Engine, token))
+	t.Cleanup(ts.Close)
+
+	c := NewClient(ts.URL+"/", token)
+	t.Cleanup(func() { c.Shutdown() })
+	return c
+}
+
+func newTestRule() *models.MonitorRule {
+	return &models.MonitorRule{
+		Name:          "测试规则",
+		URL:           "https://example.com",
+		Interval:      models.Duration(5 * time.Minute),
+		ExtractorType: models.ExtractorCSS,
+		ExtractorExpr: "title",
+	}
+}
+
+func TestClient_RuleCRUD(t *testing.T) {
+	c := newTestClient(t, "")
+	require.NoError(t, c.Initialize())
+
+	rule := newTestRule()
+	require.NoError(t, c.AddRule(rule))
+	require.NotEmpty(t, rule.ID, "添加后应返回生成的ID")
+	assert.Equal(t, models.StatusIdle, rule.Status)
+
+	rules, err := c.GetRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, rule.ID, rules[0].ID)
+
+	rule.Name = "新名称"
+	require.NoError(t, c.UpdateRule(rule))
+
+	got, err := c.GetRule(rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "新名称", got.Name)
+
+	require.NoError(t, c.DeleteRule(rule.ID))
+	_, err = c.GetRule(rule.ID)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, core.ErrRuleNotFound))
+}
+
+func TestClient_Errors(t *testing.T) {
+	c := newTestClient(t, "")
+
+	t.Run("规则验证失败", func(t *testing.T) {
+		rule := newTestRule()
+		rule.Name = ""
+		err := c.AddRule(rule)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, core.ErrInvalidRule))
+		assert.Contains(t, err.Error(), "规则名称不能为空")
+	})
+
+	t.Run("引用未定义的通知渠道", func(t *testing.T) {
+		rule := newTestRule()
+		rule.Channels = []string{"missing"}
+		err := c.AddRule(rule)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, core.ErrInvalidRule))
+		assert.Contains(t, err.Error(), "未定义的通知渠道")
+	})
+
+	t.Run("无效的消息模板", func(t *testing.T) {
+		rule := newTestRule()
+		rule.Template = &models.MessageTemplate{Body: "{{.Rule.Missing}}"}
+		err := c.AddRule(rule)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, core.ErrInvalidRule))
+		assert.Contains(t, err.Error(), "消息模板无效")
+	})
+
+	t.Run("重新发送不存在的通知", func(t *testing.T) {
+		deliveries, err := c.GetDeliveries("", 0)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+
+		err = c.ResendDelivery("missing")
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, core.ErrDeliveryNotFound))
+	})
+
+	t.Run("密钥管理", func(t *testing.T) {
+		require.NoError(t, c.SetSecret("api-token", "s3cr3t"))
+		infos, err := c.ListSecrets()
+		require.NoError(t, err)
+		require.Len(t, infos, 1)
+		assert.Equal(t, "api-token", infos[0].Name)
+
+		assert.True(t, errors.Is(c.SetSecret("bad name", "v"), core.ErrInvalidSecret))
+		require.NoError(t, c.DeleteSecret("api-token"))
+		assert.True(t, errors.Is(c.DeleteSecret("api-token"), core.ErrSecretNotFound))
+	})
+
+	t.Run("未启动时立即检查", func(t *testing.T) {
+		rule := newTestRule()
+		require.NoError(t, c.AddRule(rule))
+
+		err := c.CheckNow(rule.ID)
+		require.Error(t, err)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, 409, apiErr.StatusCode)
+	})
+}
+
+func TestClient_Token(t *testing.T) {
+	c := newTestClient(t, "secret")
+	require.NoError(t, c.Initialize())
+
+	c.token = "wrong"
+	err := c.Initialize()
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 401, apiErr.StatusCode)
+}
+
+// recordingListener 记录收到的事件
+type recordingListener struct {
+	events chan core.Event
+}
+
+func (l *recordingListener) OnEvent(event core.Event) {
+	l.events <- event
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	c := newTestClient(t, "")
+
+	listener := &recordingListener{events: make(chan core.Event, 16)}
+	c.Subscribe(listener)
+	defer c.Unsubscribe(listener)
+
+	// 事件流建立是异步的，重复添加规则直到收到事件
+	var event core.Event
+	require.Eventually(t, func() bool {
+		require.NoError(t, c.AddRule(newTestRule()))
+		select {
+		case event = <-listener.events:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 3*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, core.EventRuleAdded, event.Type)
+	require.NotNil(t, event.Rule)
+	assert.Equal(t, "测试规则", event.Rule.Name)
+}
+
+func TestClient_OutcomeEvents(t *testing.T) {
+	var price atomic.Int32
+	price.Store(100)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if price.Load() < 0 {
+			fmt.Fprint(w, "not json")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"price": %d}`, price.Load())
+	}))
+	defer target.Close()
+
+	c := newTestClient(t, "")
+
+	listener := &recordingListener{events: make(chan core.Event, 64)}
+	c.Subscribe(listener)
+	defer c.Unsubscribe(listener)
+
+	rule := newTestRule()
+	rule.URL = target.URL
+	rule.ExtractorType = models.ExtractorJSON
+	rule.ExtractorExpr = "@this"
+	rule.Enabled = true
+	require.NoError(t, c.AddRule(rule))
+
+	// 等待事件流建立
+	require.Eventually(t, func() bool {
+		require.NoError(t, c.UpdateRule(rule))
+		select {
+		case <-listener.events:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 3*time.Second, 10*time.Millisecond, "应收到规则事件")
+
+	// waitFor 等待指定类型的事件
+	waitFor := func(eventType core.EventType) core.Event {
+		t.Helper()
+		timeout := time.After(3 * time.Second)
+		for {
+			select {
+			case event := <-listener.events:
+				if event.Type == eventType {
+					return event
+				}
+			case <-timeout:
+				t.Fatalf("未收到 %s 事件", eventType)
+			}
+		}
+	}
+
+	require.NoError(t, c.CheckNow(rule.ID))
+	price.Store(120)
+	require.NoError(t, c.CheckNow(rule.ID))
+
+	event := waitFor(core.EventContentChanged)
+	assert.Equal(t, rule.ID, event.RuleID)
+	data, ok := event.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "changed", data["kind"])
+	assert.Equal(t, `{"price": 100}`, data["old_content"])
+	assert.Equal(t, `{"price": 120}`, data["new_content"])
+	require.Contains(t, data, "diff")
+	assert.Contains(t, data["diff"].(map[string]interface{})["unified"], "+{\"price\": 120}")
+
+	price.Store(-1)
+	require.Error(t, c.CheckNow(rule.ID))
+
+	event = waitFor(core.EventMonitorError)
+	data, ok = event.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "error", data["kind"])
+	assert.Equal(t, "extract", data["stage"])
+	assert.NotEmpty(t, data["error"])
+	assert.EqualValues(t, 1, data["failures"])
+
+	require.Error(t, c.CheckNow(rule.ID))
+	event = waitFor(core.EventMonitorError)
+	assert.EqualValues(t, 2, event.Data.(map[string]interface{})["failures"])
+	got, err := c.GetRule(rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.ConsecutiveFailures)
+	assert.NotEmpty(t, got.FailingSince)
+
+	price.Store(130)
+	require.NoError(t, c.CheckNow(rule.ID))
+
+	event = waitFor(core.EventMonitorRecovered)
+	data, ok = event.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.EqualValues(t, 2, data["failures"])
+	assert.NotEmpty(t, data["failing_since"])
+	assert.Contains(t, data["last_error"], "内容提取失败")
+	got, err = c.GetRule(rule.ID)
+	require.NoError(t, err)
+	assert.Zero(t, got.ConsecutiveFailures)
+	assert.Empty(t, got.FailingSince)
+}
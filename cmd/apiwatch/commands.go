@@ -0,0 +1,470 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/notification"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	register(&command{
+		name:    "list",
+		usage:   "list",
+		summary: "列出所有规则",
+		run:     runList,
+	})
+	register(&command{
+		name:    "show",
+		usage:   "show <规则ID>",
+		summary: "显示规则详情",
+		run:     runShow,
+	})
+	register(&command{
+		name:    "add",
+		usage:   "add -f <rule.yaml|->",
+		summary: "从YAML/JSON文件添加规则",
+		run:     runAdd,
+	})
+	register(&command{
+		name:    "update",
+		usage:   "update [规则ID] -f <rule.yaml|->",
+		summary: "从YAML/JSON文件更新规则",
+		run:     runUpdate,
+	})
+	register(&command{
+		name:    "delete",
+		usage:   "delete <规则ID>...",
+		summary: "删除规则",
+		run:     runDelete,
+	})
+	register(&command{
+		name:    "start",
+		usage:   "start <规则ID>...",
+		summary: "启动监控",
+		run:     runStart,
+	})
+	register(&command{
+		name:    "stop",
+		usage:   "stop [--all] <规则ID>...",
+		summary: "停止监控",
+		run:     runStop,
+	})
+	register(&command{
+		name:    "check-now",
+		usage:   "check-now <规则ID>",
+		summary: "立即执行一次检查",
+		run:     runCheckNow,
+	})
+	register(&command{
+		name:    "history",
+		usage:   "history <规则ID> [--limit N] [--before RFC3339时间]",
+		summary: "显示规则的内容变化历史",
+		run:     runHistory,
+	})
+	register(&command{
+		name:    "deliveries",
+		usage:   "deliveries [--status pending|delivered|failed] [--limit N]",
+		summary: "显示远程通知的投递状态",
+		run:     runDeliveries,
+	})
+	register(&command{
+		name:    "resend",
+		usage:   "resend <投递ID>...",
+		summary: "立即重新发送通知",
+		run:     runResend,
+	})
+	register(&command{
+		name:    "secrets",
+		usage:   "secrets",
+		summary: "列出密钥（不显示密钥的值）",
+		run:     runSecrets,
+	})
+	register(&command{
+		name:    "secret-set",
+		usage:   "secret-set <名称>（从标准输入读取密钥的值）",
+		summary: "新增或更新密钥",
+		run:     runSecretSet,
+	})
+	register(&command{
+		name:    "secret-delete",
+		usage:   "secret-delete <名称>...",
+		summary: "删除密钥",
+		run:     runSecretDelete,
+	})
+	register(&command{
+		name:    "tail-events",
+		usage:   "tail-events [--rule <规则ID>] [--type <事件类型>]",
+		summary: "持续输出实时事件（本地模式下会在前台运行监控）",
+		run:     runTailEvents,
+	})
+}
+
+// runList 列出所有规则
+func runList(g *globalOptions, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		rules, err := api.GetRules()
+		if err != nil {
+			return err
+		}
+		return printRules(os.Stdout, g.output, rules)
+	})
+}
+
+// runShow 显示规则详情
+func runShow(g *globalOptions, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		rule, err := api.GetRule(args[0])
+		if err != nil {
+			return err
+		}
+		return printRule(os.Stdout, g.output, rule)
+	})
+}
+
+// runAdd 添加规则
+func runAdd(g *globalOptions, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	file := fs.String("f", "", "规则文件（YAML或JSON），- 表示标准输入")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if *file == "" || len(positional) > 0 {
+		return errUsage
+	}
+
+	rule, err := readRuleFile(*file)
+	if err != nil {
+		return err
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		if err := api.AddRule(rule); err != nil {
+			return err
+		}
+		return printRule(os.Stdout, g.output, rule)
+	})
+}
+
+// runUpdate 更新规则
+func runUpdate(g *globalOptions, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	file := fs.String("f", "", "规则文件（YAML或JSON），- 表示标准输入")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if *file == "" || len(positional) > 1 {
+		return errUsage
+	}
+
+	rule, err := readRuleFile(*file)
+	if err != nil {
+		return err
+	}
+
+	// 命令行中的ID优先
+	if len(positional) == 1 {
+		if rule.ID != "" && rule.ID != positional[0] {
+			return fmt.Errorf("规则文件中的ID %q 与参数 %q 不一致", rule.ID, positional[0])
+		}
+		rule.ID = positional[0]
+	}
+	if rule.ID == "" {
+		return fmt.Errorf("%w: 缺少规则ID", errUsage)
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		if err := api.UpdateRule(rule); err != nil {
+			return err
+		}
+		return printRule(os.Stdout, g.output, rule)
+	})
+}
+
+// runDelete 删除规则
+func runDelete(g *globalOptions, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		for _, id := range args {
+			if err := api.DeleteRule(id); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已删除规则 %s\n", id)
+		}
+		return nil
+	})
+}
+
+// runStart 启动监控
+func runStart(g *globalOptions, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		for _, id := range args {
+			if err := api.StartMonitoring(id); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已启动监控 %s\n", id)
+		}
+		return nil
+	})
+}
+
+// runStop 停止监控
+func runStop(g *globalOptions, args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	all := fs.Bool("all", false, "停止所有监控")
+	ids, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if *all == (len(ids) > 0) {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		if *all {
+			if err := api.StopAllMonitoring(); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "已停止所有监控")
+			return nil
+		}
+
+		for _, id := range ids {
+			if err := api.StopMonitoring(id); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已停止监控 %s\n", id)
+		}
+		return nil
+	})
+}
+
+// runCheckNow 立即检查
+func runCheckNow(g *globalOptions, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		checkErr := api.CheckNow(args[0])
+
+		// 检查失败时规则中也记录了错误信息，仍然输出规则的最新状态
+		rule, err := api.GetRule(args[0])
+		if err != nil {
+			return err
+		}
+		if err := printRule(os.Stdout, g.output, rule); err != nil {
+			return err
+		}
+		return checkErr
+	})
+}
+
+// runHistory 显示内容变化历史
+func runHistory(g *globalOptions, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "返回的记录数")
+	before := fs.String("before", "", "只显示早于该时间的记录（RFC3339格式）")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+
+	var beforeTime time.Time
+	if *before != "" {
+		beforeTime, err = time.Parse(time.RFC3339Nano, *before)
+		if err != nil {
+			return fmt.Errorf("%w: 无效的时间格式 %q", errUsage, *before)
+		}
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		entries, err := api.GetHistory(positional[0], *limit, beforeTime)
+		if err != nil {
+			return err
+		}
+		return printHistory(os.Stdout, g.output, entries)
+	})
+}
+
+// runDeliveries 显示通知的投递状态
+func runDeliveries(g *globalOptions, args []string) error {
+	fs := flag.NewFlagSet("deliveries", flag.ContinueOnError)
+	status := fs.String("status", "", "只显示该状态的记录（pending、delivered、failed）")
+	limit := fs.Int("limit", 20, "返回的记录数")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errUsage
+	}
+	if s := notification.DeliveryStatus(*status); s != "" && !s.Valid() {
+		return fmt.Errorf("%w: 无效的投递状态 %q", errUsage, *status)
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		deliveries, err := api.GetDeliveries(notification.DeliveryStatus(*status), *limit)
+		if err != nil {
+			return err
+		}
+		return printDeliveries(os.Stdout, g.output, deliveries)
+	})
+}
+
+// runResend 立即重新发送通知
+func runResend(g *globalOptions, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		for _, id := range args {
+			if err := api.ResendDelivery(id); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已重新发送通知 %s\n", id)
+		}
+		return nil
+	})
+}
+
+// runSecrets 列出密钥
+func runSecrets(g *globalOptions, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		infos, err := api.ListSecrets()
+		if err != nil {
+			return err
+		}
+		return printSecrets(os.Stdout, g.output, infos)
+	})
+}
+
+// runSecretSet 新增或更新密钥，密钥的值从标准输入读取，避免出现在命令行历史中
+func runSecretSet(g *globalOptions, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("读取密钥失败: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return errors.New("密钥的值不能为空")
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		if err := api.SetSecret(args[0], value); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "已保存密钥 %s\n", args[0])
+		return nil
+	})
+}
+
+// runSecretDelete 删除密钥
+func runSecretDelete(g *globalOptions, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	return withAPI(g, func(api core.CoreAPI) error {
+		for _, name := range args {
+			if err := api.DeleteSecret(name); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "已删除密钥 %s\n", name)
+		}
+		return nil
+	})
+}
+
+// runTailEvents 持续输出实时事件
+func runTailEvents(g *globalOptions, args []string) error {
+	fs := flag.NewFlagSet("tail-events", flag.ContinueOnError)
+	var ruleIDs, types stringList
+	fs.Var(&ruleIDs, "rule", "只输出指定规则的事件（可重复或逗号分隔）")
+	fs.Var(&types, "type", "只输出指定类型的事件（可重复或逗号分隔）")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errUsage
+	}
+
+	// 本地模式下需要在前台运行监控才能产生事件
+	api, closeFn, err := openAPI(g, false)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	printer := newEventPrinter(os.Stdout, g.output, ruleIDs, types)
+	api.Subscribe(printer)
+	defer api.Unsubscribe(printer)
+
+	ctx, stop := signal.NotifyContext(printer.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	return printer.err()
+}
+
+// readRuleFile 读取规则文件，支持YAML和JSON格式
+func readRuleFile(path string) (*models.MonitorRule, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取规则文件失败: %w", err)
+	}
+
+	var rule models.MonitorRule
+	if err := yaml.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("解析规则文件失败: %w", err)
+	}
+	return &rule, nil
+}
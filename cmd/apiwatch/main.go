@@ -0,0 +1,203 @@
+// apiwatch 命令行工具，用于管理监控规则
+//
+// 默认直接读写本地配置文件；指定 --server 时通过守护进程的REST API操作。
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zx06/apiwatch/bootstrap"
+	"github.com/zx06/apiwatch/client"
+	"github.com/zx06/apiwatch/core"
+)
+
+// globalOptions 全局选项
+type globalOptions struct {
+	server     string
+	token      string
+	configPath string
+	output     string
+	verbose    bool
+}
+
+// command 子命令
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(g *globalOptions, args []string) error
+}
+
+// errUsage 参数错误
+var errUsage = errors.New("参数错误")
+
+var commands = map[string]*command{}
+
+// register 注册子命令
+func register(cmd *command) {
+	commands[cmd.name] = cmd
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 解析全局选项并执行子命令，返回进程退出码
+func run(args []string) int {
+	g := &globalOptions{}
+	fs := flag.NewFlagSet("apiwatch", flag.ContinueOnError)
+	fs.StringVar(&g.server, "server", os.Getenv("APIWATCH_SERVER"), "守护进程地址，例如 http://127.0.0.1:8787（默认读取环境变量 APIWATCH_SERVER）")
+	fs.StringVar(&g.token, "token", os.Getenv("APIWATCH_TOKEN"), "API访问令牌（默认读取环境变量 APIWATCH_TOKEN）")
+	fs.StringVar(&g.configPath, "config", "", "本地配置文件路径（默认 ~/.url-monitor/config.yaml）")
+	fs.StringVar(&g.output, "o", "table", "输出格式：table 或 json")
+	fs.BoolVar(&g.verbose, "v", false, "输出调试日志")
+	fs.Usage = func() { printUsage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if g.output != "table" && g.output != "json" {
+		fmt.Fprintf(os.Stderr, "不支持的输出格式: %s\n", g.output)
+		return 2
+	}
+
+	// 命令行工具的日志输出到stderr，避免干扰命令输出
+	level := slog.LevelWarn
+	if g.verbose {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	if fs.NArg() == 0 {
+		printUsage(fs)
+		return 2
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", fs.Arg(0))
+		printUsage(fs)
+		return 2
+	}
+
+	if err := cmd.run(g, fs.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "用法: apiwatch %s\n", cmd.usage)
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// printUsage 打印帮助信息
+func printUsage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "用法: apiwatch [全局选项] <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "全局选项:")
+	fs.PrintDefaults()
+}
+
+// openAPI 打开核心API
+//
+// 指定了守护进程地址时返回REST客户端；否则基于本地配置文件装配引擎。
+// 本地模式下 manual 为true时不启动定时检查。
+// 返回的关闭函数会在本地模式下保存配置。
+func openAPI(g *globalOptions, manual bool) (core.CoreAPI, func() error, error) {
+	if g.server != "" {
+		c := client.NewClient(g.server, g.token)
+		if err := c.Initialize(); err != nil {
+			return nil, nil, err
+		}
+		return c, c.Shutdown, nil
+	}
+
+	components, err := bootstrap.Build(bootstrap.Options{
+		ConfigPath: g.configPath,
+		Manual:     manual,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	engine := components.Engine
+	if err := engine.Initialize(); err != nil {
+		return nil, nil, err
+	}
+	return engine, engine.Shutdown, nil
+}
+
+// withAPI 打开核心API并执行操作，结束后关闭
+func withAPI(g *globalOptions, fn func(api core.CoreAPI) error) (err error) {
+	api, closeFn, err := openAPI(g, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeFn(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return fn(api)
+}
+
+// parseInterspersed 解析允许与位置参数交错的选项，返回位置参数
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// stringList 可重复的字符串选项，也支持逗号分隔
+type stringList []string
+
+// String 实现flag.Value接口
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set 实现flag.Value接口
+func (l *stringList) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
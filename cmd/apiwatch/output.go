@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/history"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
+)
+
+// printRules 输出规则列表
+func printRules(w io.Writer, format string, rules []*models.MonitorRule) error {
+	if format == "json" {
+		return printJSON(w, rules)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tENABLED\tSCHEDULE\tLAST CHECKED\tNEXT RUN")
+	for _, rule := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\t%s\n",
+			rule.ID,
+			rule.Name,
+			rule.Status,
+			rule.Enabled,
+			scheduleText(rule),
+			orDash(rule.LastChecked),
+			orDash(rule.NextRun),
+		)
+	}
+	return tw.Flush()
+}
+
+// printRule 输出单个规则详情
+func printRule(w io.Writer, format string, rule *models.MonitorRule) error {
+	if format == "json" {
+		return printJSON(w, rule)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", rule.ID)
+	fmt.Fprintf(tw, "Name:\t%s\n", rule.Name)
+	if rule.Description != "" {
+		fmt.Fprintf(tw, "Description:\t%s\n", rule.Description)
+	}
+	fmt.Fprintf(tw, "URL:\t%s %s\n", rule.Method, rule.URL)
+
+	if len(rule.Headers) > 0 {
+		keys := make([]string, 0, len(rule.Headers))
+		for k := range rule.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(tw, "Headers:\t%s\n", strings.Join(keys, ", "))
+	}
+
+	if rule.Retry != nil {
+		fmt.Fprintf(tw, "Retry:\t%s\n", retryText(rule.Retry))
+	}
+	if rule.Auth != nil {
+		fmt.Fprintf(tw, "Auth:\t%s\n", authText(rule.Auth))
+	}
+	if rule.TLS != nil {
+		fmt.Fprintf(tw, "TLS:\t%s\n", tlsText(rule.TLS))
+	}
+	if rule.Proxy != "" {
+		fmt.Fprintf(tw, "Proxy:\t%s\n", proxyText(rule.Proxy))
+	}
+
+	if rule.Schedule != "" {
+		fmt.Fprintf(tw, "Schedule:\t%s\n", scheduleText(rule))
+	} else {
+		fmt.Fprintf(tw, "Interval:\t%s\n", time.Duration(rule.Interval))
+	}
+	fmt.Fprintf(tw, "Extractor:\t%s %s\n", rule.ExtractorType, rule.ExtractorExpr)
+	if rule.ExtractorType == models.ExtractorCert {
+		fmt.Fprintf(tw, "Cert Alert:\t< %d days\n", rule.CertAlertThreshold())
+	}
+	fmt.Fprintf(tw, "Enabled:\t%t\n", rule.Enabled)
+	fmt.Fprintf(tw, "Notify:\t%t\n", rule.NotifyEnabled)
+	if len(rule.Channels) > 0 {
+		fmt.Fprintf(tw, "Channels:\t%s\n", strings.Join(rule.Channels, ", "))
+	}
+	if len(rule.ErrorChannels) > 0 {
+		fmt.Fprintf(tw, "Error Channels:\t%s\n", strings.Join(rule.ErrorChannels, ", "))
+	}
+	if rule.RateLimit != nil {
+		fmt.Fprintf(tw, "Rate Limit:\t%d / %s\n", rule.RateLimit.Max, time.Duration(rule.RateLimit.Window))
+	}
+	if rule.Alert != nil {
+		fmt.Fprintf(tw, "Alert:\tafter %d failures, recovery %t\n", rule.Alert.Threshold(), rule.Alert.NotifyRecovery)
+	}
+	for _, webhook := range rule.Webhooks {
+		fmt.Fprintf(tw, "Webhook:\t%s\n", webhook.URL)
+	}
+	for _, chat := range rule.Chats {
+		fmt.Fprintf(tw, "Chat:\t%s\n", chat.Platform)
+	}
+	if len(rule.EmailTo) > 0 {
+		fmt.Fprintf(tw, "Email To:\t%s\n", strings.Join(rule.EmailTo, ", "))
+	}
+	fmt.Fprintf(tw, "Status:\t%s\n", rule.Status)
+	fmt.Fprintf(tw, "Last Checked:\t%s\n", orDash(rule.LastChecked))
+	if rule.NextRun != "" {
+		fmt.Fprintf(tw, "Next Run:\t%s\n", rule.NextRun)
+	}
+	if rule.ErrorMessage != "" {
+		fmt.Fprintf(tw, "Error:\t%s\n", rule.ErrorMessage)
+	}
+	if rule.ConsecutiveFailures > 0 {
+		fmt.Fprintf(tw, "Failures:\t%d since %s\n", rule.ConsecutiveFailures, orDash(rule.FailingSince))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// 内容可能包含多行，放在表格之后输出
+	if rule.LastContent != "" {
+		fmt.Fprintf(w, "Last Content:\n%s\n", rule.LastContent)
+	}
+	return nil
+}
+
+// scheduleText 返回规则的检查计划：cron表达式（附带时区）或检查间隔
+func scheduleText(rule *models.MonitorRule) string {
+	if rule.Schedule == "" {
+		return time.Duration(rule.Interval).String()
+	}
+	if rule.TimeZone != "" {
+		return rule.Schedule + " (" + rule.TimeZone + ")"
+	}
+	return rule.Schedule
+}
+
+// retryText 返回重试策略中已配置的项，未配置的项使用默认值
+func retryText(p *models.RetryPolicy) string {
+	var parts []string
+	if p.Timeout > 0 {
+		parts = append(parts, "timeout "+time.Duration(p.Timeout).String())
+	}
+	if p.MaxAttempts > 0 {
+		parts = append(parts, fmt.Sprintf("%d attempts", p.MaxAttempts))
+	}
+	if p.BackoffBase > 0 || p.BackoffMax > 0 {
+		parts = append(parts, fmt.Sprintf("backoff %s-%s", time.Duration(p.BackoffBase), time.Duration(p.BackoffMax)))
+	}
+	if p.Jitter > 0 {
+		parts = append(parts, fmt.Sprintf("jitter %.0f%%", p.Jitter*100))
+	}
+	if len(p.RetryStatus) > 0 {
+		codes := make([]string, len(p.RetryStatus))
+		for i, code := range p.RetryStatus {
+			codes[i] = strconv.Itoa(code)
+		}
+		parts = append(parts, "status "+strings.Join(codes, ","))
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// proxyText 返回代理地址，隐藏其中的密码
+func proxyText(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return proxy
+	}
+	return u.Redacted()
+}
+
+// authText 返回认证方式的说明，不包含密码、令牌等敏感信息
+func authText(c *models.AuthConfig) string {
+	switch c.Type {
+	case models.AuthBasic:
+		return "basic (" + c.Username + ")"
+	case models.AuthAPIKey:
+		in := c.In
+		if in == "" {
+			in = models.APIKeyInHeader
+		}
+		return "api_key (" + in + " " + c.Name + ")"
+	case models.AuthOAuth2:
+		return "oauth2 (" + c.TokenURL + ")"
+	default:
+		return string(c.Type)
+	}
+}
+
+// tlsText 返回TLS设置中已配置的项
+func tlsText(c *models.TLSConfig) string {
+	var parts []string
+	if c.CAFile != "" {
+		parts = append(parts, "ca "+c.CAFile)
+	}
+	if c.CertFile != "" {
+		parts = append(parts, "client cert "+c.CertFile)
+	}
+	if c.ServerName != "" {
+		parts = append(parts, "server name "+c.ServerName)
+	}
+	if c.MinVersion != "" {
+		parts = append(parts, "min TLS "+c.MinVersion)
+	}
+	if len(c.Pins) > 0 {
+		parts = append(parts, fmt.Sprintf("%d pins", len(c.Pins)))
+	}
+	if c.InsecureSkipVerify {
+		parts = append(parts, "insecure skip verify")
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// historyContentWidth 表格中内容列的最大宽度（字符数）
+const historyContentWidth = 60
+
+// printHistory 输出历史记录
+func printHistory(w io.Writer, format string, entries []*history.Entry) error {
+	if format == "json" {
+		return printJSON(w, entries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECKED AT\tHTTP\tDURATION\tCONTENT")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n",
+			entry.CheckedAt.Local().Format(time.RFC3339),
+			entry.StatusCode,
+			time.Duration(entry.DurationMs)*time.Millisecond,
+			truncateLine(entry.Content, historyContentWidth),
+		)
+	}
+	return tw.Flush()
+}
+
+// printDeliveries 输出通知投递记录
+func printDeliveries(w io.Writer, format string, deliveries []*notification.Delivery) error {
+	if format == "json" {
+		return printJSON(w, deliveries)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED AT\tCHANNEL\tSTATUS\tATTEMPTS\tNEXT ATTEMPT\tTITLE\tERROR")
+	for _, d := range deliveries {
+		next := "-"
+		if d.Status == notification.DeliveryPending && !d.NextAttempt.IsZero() {
+			next = d.NextAttempt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			d.ID,
+			d.CreatedAt.Local().Format(time.RFC3339),
+			d.Channel,
+			d.Status,
+			d.Attempts,
+			next,
+			truncateLine(d.Title, historyContentWidth),
+			orDash(truncateLine(d.LastError, historyContentWidth)),
+		)
+	}
+	return tw.Flush()
+}
+
+// printSecrets 输出密钥的名称和更新时间
+func printSecrets(w io.Writer, format string, infos []secrets.Info) error {
+	if format == "json" {
+		return printJSON(w, infos)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tUPDATED AT")
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\n", info.Name, info.UpdatedAt.Local().Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// truncateLine 将内容压缩为单行并截断到指定字符数
+func truncateLine(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width-3]) + "..."
+	}
+	return s
+}
+
+// printJSON 以缩进格式输出JSON
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// orDash 空字符串显示为 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// eventPrinter 事件输出器，实现core.EventListener接口
+type eventPrinter struct {
+	w       io.Writer
+	format  string
+	ruleIDs map[string]bool
+	types   map[core.EventType]bool
+
+	// ctx 在写入失败时被取消（例如管道已关闭）
+	ctx      context.Context
+	cancel   context.CancelFunc
+	writeErr error
+	mu       sync.Mutex
+}
+
+// newEventPrinter 创建事件输出器
+func newEventPrinter(w io.Writer, format string, ruleIDs, types []string) *eventPrinter {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &eventPrinter{
+		w:      w,
+		format: format,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if len(ruleIDs) > 0 {
+		p.ruleIDs = make(map[string]bool, len(ruleIDs))
+		for _, id := range ruleIDs {
+			p.ruleIDs[id] = true
+		}
+	}
+	if len(types) > 0 {
+		p.types = make(map[core.EventType]bool, len(types))
+		for _, t := range types {
+			p.types[core.EventType(t)] = true
+		}
+	}
+	return p
+}
+
+// OnEvent 实现EventListener接口
+func (p *eventPrinter) OnEvent(event core.Event) {
+	if p.ruleIDs != nil && !p.ruleIDs[event.RuleID] {
+		return
+	}
+	if p.types != nil && !p.types[event.Type] {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.writeErr != nil {
+		return
+	}
+
+	var err error
+	if p.format == "json" {
+		err = json.NewEncoder(p.w).Encode(event)
+	} else {
+		_, err = fmt.Fprintln(p.w, formatEvent(event))
+	}
+
+	if err != nil {
+		p.writeErr = err
+		p.cancel()
+	}
+}
+
+// err 返回写入错误
+func (p *eventPrinter) err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.writeErr
+}
+
+// formatEvent 将事件格式化为单行文本
+func formatEvent(event core.Event) string {
+	parts := []string{
+		event.Timestamp.Local().Format(time.RFC3339),
+		string(event.Type),
+		orDash(event.RuleID),
+	}
+
+	if event.Rule != nil {
+		parts = append(parts, fmt.Sprintf("%q", event.Rule.Name), string(event.Rule.Status))
+		if event.Rule.ErrorMessage != "" {
+			parts = append(parts, event.Rule.ErrorMessage)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,94 @@
+// apiwatchd 无界面守护进程，通过REST API提供监控能力
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zx06/apiwatch/bootstrap"
+	"github.com/zx06/apiwatch/logger"
+	"github.com/zx06/apiwatch/server"
+)
+
+// shutdownTimeout 优雅关闭的最长等待时间
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8787", "REST API监听地址")
+	configPath := flag.String("config", "", "配置文件路径（默认 ~/.url-monitor/config.yaml）")
+	token := flag.String("token", os.Getenv("APIWATCH_TOKEN"), "API访问令牌（默认读取环境变量 APIWATCH_TOKEN）")
+	flag.Parse()
+
+	// 初始化日志系统
+	if err := logger.Setup(logger.DefaultConfig()); err != nil {
+		panic("初始化日志系统失败: " + err.Error())
+	}
+
+	slog.Info("API Watch 守护进程启动中...")
+
+	components, err := bootstrap.Build(bootstrap.Options{ConfigPath: *configPath})
+	if err != nil {
+		slog.Error("创建核心组件失败", "error", err)
+		os.Exit(1)
+	}
+	engine := components.Engine
+
+	if err := engine.Initialize(); err != nil {
+		slog.Error("初始化引擎失败", "error", err)
+		os.Exit(1)
+	}
+
+	if *token == "" {
+		slog.Warn("未设置API访问令牌，任何能访问监听地址的客户端都可以管理规则")
+	}
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.NewServer(engine, *token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// 监听退出信号
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("REST API已启动", "addr", *addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("收到退出信号，正在关闭...")
+	case err := <-errCh:
+		slog.Error("REST API运行失败", "error", err)
+		exitCode = 1
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("关闭REST API失败", "error", err)
+	}
+
+	if err := engine.Shutdown(); err != nil {
+		slog.Error("关闭引擎失败", "error", err)
+		exitCode = 1
+	}
+
+	slog.Info("API Watch 守护进程已退出")
+	os.Exit(exitCode)
+}
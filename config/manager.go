@@ -33,8 +33,9 @@ type Manager interface {
 
 // Config 配置文件结构
 type Config struct {
-	Version string                `yaml:"version"`
-	Rules   []*models.MonitorRule `yaml:"rules"`
+	Version  string `yaml:"version"`
+	Settings `yaml:",inline"`
+	Rules    []*models.MonitorRule `yaml:"rules"`
 }
 
 // YAMLManager YAML配置管理器
@@ -70,9 +71,37 @@ func (m *YAMLManager) Load() ([]*models.MonitorRule, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// 如果配置文件不存在，返回空列表
+	config, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Rules, nil
+}
+
+// LoadSettings 加载全局设置，未配置的项使用默认值
+func (m *YAMLManager) LoadSettings() (*Settings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	config, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := config.Settings
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	settings.applyDefaults(filepath.Dir(m.configPath))
+	return &settings, nil
+}
+
+// read 读取配置文件（调用方需持有锁）
+func (m *YAMLManager) read() (*Config, error) {
+	// 如果配置文件不存在，返回空配置
 	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
-		return []*models.MonitorRule{}, nil
+		return &Config{Rules: []*models.MonitorRule{}}, nil
 	}
 
 	data, err := os.ReadFile(m.configPath)
@@ -84,21 +113,26 @@ func (m *YAMLManager) Load() ([]*models.MonitorRule, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
+	if config.Rules == nil {
+		config.Rules = []*models.MonitorRule{}
+	}
 
-	return config.Rules, nil
+	return &config, nil
 }
 
-// Save 保存所有规则
+// Save 保存所有规则，配置文件中的全局设置保持不变
 func (m *YAMLManager) Save(rules []*models.MonitorRule) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	config := Config{
-		Version: "1.0",
-		Rules:   rules,
+	config, err := m.read()
+	if err != nil {
+		return err
 	}
+	config.Version = "1.0"
+	config.Rules = rules
 
-	data, err := yaml.Marshal(&config)
+	data, err := yaml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/monitor"
+	"github.com/zx06/apiwatch/notification"
+)
+
+// Settings 全局设置（配置文件中规则以外的部分）
+type Settings struct {
+	History HistorySettings `yaml:"history,omitempty"`
+
+	// SMTP 邮件通知使用的SMTP服务器，未配置时不发送邮件通知
+	SMTP *models.SMTPConfig `yaml:"smtp,omitempty"`
+
+	// Channels 命名通知渠道，规则通过名称引用
+	Channels map[string]models.ChannelConfig `yaml:"channels,omitempty"`
+
+	Notifications NotificationSettings `yaml:"notifications,omitempty"`
+
+	Monitor MonitorSettings `yaml:"monitor,omitempty"`
+
+	HTTP HTTPSettings `yaml:"http,omitempty"`
+
+	Secrets SecretsSettings `yaml:"secrets,omitempty"`
+}
+
+// SecretsSettings 密钥存储设置
+type SecretsSettings struct {
+	// Path 加密的密钥存储文件，默认为配置文件所在目录下的 secrets.json
+	Path string `yaml:"path,omitempty"`
+
+	// KeyFile 未设置密码（环境变量 APIWATCH_SECRETS_PASSPHRASE）时使用的密钥文件，
+	// 默认为配置文件所在目录下的 secrets.key
+	KeyFile string `yaml:"key_file,omitempty"`
+}
+
+// HTTPSettings 检查请求设置
+type HTTPSettings struct {
+	// HostLimit 对每个主机的默认请求限制，同一主机的所有规则共享
+	HostLimit models.HostLimit `yaml:"host_limit,omitempty"`
+
+	// Hosts 按主机名（不含端口）单独设置的请求限制，代替默认限制
+	Hosts map[string]models.HostLimit `yaml:"hosts,omitempty"`
+
+	// RespectRobots 为true时使用CSS提取器的规则遵守目标站点的robots.txt
+	RespectRobots bool `yaml:"respect_robots,omitempty"`
+
+	// Proxy 默认代理地址，为空时按环境变量 HTTP_PROXY、HTTPS_PROXY 和 NO_PROXY 选择代理，
+	// 为 direct 时不使用代理；规则可以单独设置代理
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// NoProxy 不使用默认代理的主机，格式同 NO_PROXY，仅在设置了 Proxy 时使用
+	NoProxy []string `yaml:"no_proxy,omitempty"`
+}
+
+// MonitorSettings 监控检查设置
+type MonitorSettings struct {
+	// Concurrency 同时执行检查的最大数量
+	Concurrency int `yaml:"concurrency,omitempty"`
+}
+
+// NotificationSettings 通知发送设置
+type NotificationSettings struct {
+	// DesktopMode 桌面通知方式：toast（默认）、dialog 或 both
+	DesktopMode models.DesktopMode `yaml:"desktop_mode,omitempty"`
+
+	// Workers 并发发送远程通知的数量，桌面通知另有一个worker
+	Workers int `yaml:"workers,omitempty"`
+
+	// QueueSize 待发送通知的队列长度，队列已满时丢弃新的桌面通知，远程通知留在发件箱中稍后重试
+	QueueSize int `yaml:"queue_size,omitempty"`
+
+	// OutboxPath 发件箱文件，保存未送达的远程通知，默认为配置文件所在目录下的 outbox.json
+	OutboxPath string `yaml:"outbox_path,omitempty"`
+
+	// MaxAttempts 每条通知最多尝试发送的次数，之后标记为失败
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+}
+
+// HistorySettings 内容变化历史设置
+type HistorySettings struct {
+	// Dir 历史记录目录，默认为配置文件所在目录下的 history
+	Dir string `yaml:"dir,omitempty"`
+
+	// MaxEntries 每个规则最多保留的记录数
+	MaxEntries int `yaml:"max_entries,omitempty"`
+
+	// MaxAge 记录最长保留时间
+	MaxAge models.Duration `yaml:"max_age,omitempty"`
+}
+
+const (
+	// DefaultHistoryMaxEntries 默认每个规则保留的历史记录数
+	DefaultHistoryMaxEntries = 1000
+
+	// DefaultHistoryMaxAge 默认历史记录保留时间
+	DefaultHistoryMaxAge = 90 * 24 * time.Hour
+
+	// DefaultHostMaxConcurrent 默认对同一主机同时进行的请求数上限
+	DefaultHostMaxConcurrent = 4
+)
+
+// applyDefaults 为未配置的项填充默认值
+func (s *Settings) applyDefaults(configDir string) {
+	if s.History.Dir == "" {
+		s.History.Dir = filepath.Join(configDir, "history")
+	}
+	if s.History.MaxEntries <= 0 {
+		s.History.MaxEntries = DefaultHistoryMaxEntries
+	}
+	if s.History.MaxAge <= 0 {
+		s.History.MaxAge = models.Duration(DefaultHistoryMaxAge)
+	}
+	if s.Notifications.DesktopMode == "" {
+		s.Notifications.DesktopMode = models.DesktopToast
+	}
+	if s.Notifications.Workers <= 0 {
+		s.Notifications.Workers = notification.DefaultDispatchWorkers
+	}
+	if s.Notifications.QueueSize <= 0 {
+		s.Notifications.QueueSize = notification.DefaultDispatchQueueSize
+	}
+	if s.Notifications.OutboxPath == "" {
+		s.Notifications.OutboxPath = filepath.Join(configDir, "outbox.json")
+	}
+	if s.Notifications.MaxAttempts <= 0 {
+		s.Notifications.MaxAttempts = notification.DefaultMaxAttempts
+	}
+	if s.Monitor.Concurrency <= 0 {
+		s.Monitor.Concurrency = monitor.DefaultConcurrency
+	}
+	if s.HTTP.HostLimit.MaxConcurrent <= 0 {
+		s.HTTP.HostLimit.MaxConcurrent = DefaultHostMaxConcurrent
+	}
+	if s.Secrets.Path == "" {
+		s.Secrets.Path = filepath.Join(configDir, "secrets.json")
+	}
+	if s.Secrets.KeyFile == "" {
+		s.Secrets.KeyFile = filepath.Join(configDir, "secrets.key")
+	}
+}
+
+// validate 验证设置
+func (s *Settings) validate() error {
+	if s.SMTP != nil {
+		if err := s.SMTP.Validate(); err != nil {
+			return fmt.Errorf("SMTP配置无效: %w", err)
+		}
+	}
+
+	if err := s.Notifications.DesktopMode.Validate(); err != nil {
+		return err
+	}
+	if s.Notifications.Workers < 0 || s.Notifications.QueueSize < 0 {
+		return errors.New("通知并发数和队列长度不能为负数")
+	}
+
+	if err := s.HTTP.HostLimit.Validate(); err != nil {
+		return err
+	}
+	if err := models.ValidateProxy(s.HTTP.Proxy); err != nil {
+		return err
+	}
+	for host, limit := range s.HTTP.Hosts {
+		if host == "" {
+			return errors.New("主机名不能为空")
+		}
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("主机 %s 的请求限制无效: %w", host, err)
+		}
+	}
+
+	for name, channel := range s.Channels {
+		if name == "" {
+			return errors.New("通知渠道名称不能为空")
+		}
+		if name == models.DesktopChannel && channel.Type != models.ChannelDesktop {
+			return fmt.Errorf("通知渠道 %s 为内置的桌面通知渠道，不能用于其他类型", name)
+		}
+		if err := channel.Validate(); err != nil {
+			return fmt.Errorf("通知渠道 %s 配置无效: %w", name, err)
+		}
+		if err := notification.ValidateTemplate(channel.Template, nil); err != nil {
+			return fmt.Errorf("通知渠道 %s 的消息模板无效: %w", name, err)
+		}
+		if channel.Type == models.ChannelEmail && s.SMTP == nil {
+			return fmt.Errorf("通知渠道 %s 需要配置SMTP服务器", name)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 package core
 
 import (
+	"time"
+
+	"github.com/zx06/apiwatch/history"
 	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
 )
 
 // CoreAPI 核心API接口，UI层通过此接口与核心层交互
@@ -19,6 +24,20 @@ type CoreAPI interface {
 	StopAllMonitoring() error
 	CheckNow(ruleID string) error
 
+	// 历史记录
+	// limit <= 0 时使用默认数量；before 不为零值时只返回早于该时间的记录
+	GetHistory(ruleID string, limit int, before time.Time) ([]*history.Entry, error)
+
+	// 通知投递
+	// status 为空时返回所有状态；limit <= 0 时使用默认数量
+	GetDeliveries(status notification.DeliveryStatus, limit int) ([]*notification.Delivery, error)
+	ResendDelivery(id string) error
+
+	// 密钥管理，规则中通过 ${secret:name} 引用；不会返回密钥的值
+	ListSecrets() ([]secrets.Info, error)
+	SetSecret(name, value string) error
+	DeleteSecret(name string) error
+
 	// 事件订阅
 	Subscribe(listener EventListener)
 	Unsubscribe(listener EventListener)
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -8,34 +10,59 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/zx06/apiwatch/config"
+	"github.com/zx06/apiwatch/history"
 	"github.com/zx06/apiwatch/models"
 	"github.com/zx06/apiwatch/monitor"
 	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
 )
 
+// shutdownTimeout 关闭引擎时等待正在进行的检查和通知发送结束的最长时间
+const shutdownTimeout = 10 * time.Second
+
 // Engine Monitor引擎，实现CoreAPI接口
 type Engine struct {
 	configMgr  config.Manager
 	monitorSvc monitor.Service
 	eventBus   EventBus
-	notifier   notification.Notifier
+	router     *notification.Router
+	history    history.Store
+	secrets    *secrets.Store
 
 	rules []*models.MonitorRule
 	mu    sync.RWMutex
+
+	// 检查结果处理循环
+	outcomeOnce  sync.Once
+	stopOnce     sync.Once
+	stopOutcomes chan struct{}
+	outcomesDone chan struct{}
 }
 
 // NewEngine 创建Monitor引擎
 func NewEngine(
 	configMgr config.Manager,
 	monitorSvc monitor.Service,
-	notifier notification.Notifier,
+	router *notification.Router,
+	historyStore history.Store,
 ) *Engine {
+	if router == nil {
+		router = notification.NewRouter(nil, nil, nil)
+	}
+	if historyStore == nil {
+		historyStore = history.NewNoOpStore()
+	}
+
 	return &Engine{
 		configMgr:  configMgr,
 		monitorSvc: monitorSvc,
 		eventBus:   NewEventBus(),
-		notifier:   notifier,
+		router:     router,
+		history:    historyStore,
 		rules:      make([]*models.MonitorRule, 0),
+
+		stopOutcomes: make(chan struct{}),
+		outcomesDone: make(chan struct{}),
 	}
 }
 
@@ -53,6 +80,11 @@ func (e *Engine) Initialize() error {
 	e.rules = rules
 	e.mu.Unlock()
 
+	// 开始处理检查结果
+	e.outcomeOnce.Do(func() {
+		go e.processOutcomes()
+	})
+
 	// 启动已启用的监控任务
 	for _, rule := range rules {
 		if rule.Enabled {
@@ -73,8 +105,22 @@ func (e *Engine) Initialize() error {
 func (e *Engine) Shutdown() error {
 	slog.Info("关闭Monitor引擎")
 
-	// 停止所有监控任务
-	e.monitorSvc.StopAll()
+	// 停止所有监控任务，取消正在进行的检查并在限定时间内等待其结束
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.monitorSvc.Shutdown(ctx); err != nil {
+		slog.Warn("停止监控任务未完成", "error", err)
+	}
+
+	// 处理完已产生的检查结果后停止处理循环
+	if err := e.stopOutcomeLoop(ctx); err != nil {
+		slog.Warn("处理检查结果未完成", "error", err)
+	}
+
+	// 在剩余时间内等待已排队的通知发送完成
+	if err := e.router.Close(ctx); err != nil {
+		slog.Warn("发送通知未完成", "error", err)
+	}
 
 	// 保存配置
 	e.mu.RLock()
@@ -112,14 +158,17 @@ func (e *Engine) GetRule(id string) (*models.MonitorRule, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("规则不存在: %s", id)
+	return nil, fmt.Errorf("%w: %s", ErrRuleNotFound, id)
 }
 
 // AddRule 添加规则
 func (e *Engine) AddRule(rule *models.MonitorRule) error {
 	// 验证规则
 	if err := rule.Validate(); err != nil {
-		return fmt.Errorf("规则验证失败: %w", err)
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	if err := e.router.ValidateRule(rule); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
 	}
 
 	// 生成ID
@@ -171,9 +220,41 @@ func (e *Engine) AddRule(rule *models.MonitorRule) error {
 
 // UpdateRule 更新规则
 func (e *Engine) UpdateRule(rule *models.MonitorRule) error {
+	if err := e.saveRule(rule); err != nil {
+		return err
+	}
+
+	// 更新监控任务
+	if rule.Enabled {
+		if e.monitorSvc.IsTaskRunning(rule.ID) {
+			if err := e.monitorSvc.UpdateTask(rule); err != nil {
+				slog.Error("更新监控任务失败", "rule_id", rule.ID, "error", err)
+			}
+		} else {
+			if err := e.monitorSvc.StartTask(rule); err != nil {
+				slog.Error("启动监控任务失败", "rule_id", rule.ID, "error", err)
+			}
+		}
+	} else {
+		if e.monitorSvc.IsTaskRunning(rule.ID) {
+			if err := e.monitorSvc.StopTask(rule.ID); err != nil {
+				slog.Error("停止监控任务失败", "rule_id", rule.ID, "error", err)
+			}
+		}
+	}
+
+	e.ruleUpdated(rule)
+	return nil
+}
+
+// saveRule 验证规则并替换内存和配置文件中的同ID规则，不改变监控任务
+func (e *Engine) saveRule(rule *models.MonitorRule) error {
 	// 验证规则
 	if err := rule.Validate(); err != nil {
-		return fmt.Errorf("规则验证失败: %w", err)
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	if err := e.router.ValidateRule(rule); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
 	}
 
 	e.mu.Lock()
@@ -188,34 +269,18 @@ func (e *Engine) UpdateRule(rule *models.MonitorRule) error {
 	e.mu.Unlock()
 
 	if !found {
-		return fmt.Errorf("规则不存在: %s", rule.ID)
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, rule.ID)
 	}
 
 	// 保存到配置
 	if err := e.configMgr.UpdateRule(rule); err != nil {
 		return fmt.Errorf("保存规则失败: %w", err)
 	}
+	return nil
+}
 
-	// 更新监控任务
-	if rule.Enabled {
-		if e.monitorSvc.IsTaskRunning(rule.ID) {
-			if err := e.monitorSvc.UpdateTask(rule); err != nil {
-				slog.Error("更新监控任务失败", "rule_id", rule.ID, "error", err)
-			}
-		} else {
-			if err := e.monitorSvc.StartTask(rule); err != nil {
-				slog.Error("启动监控任务失败", "rule_id", rule.ID, "error", err)
-			}
-		}
-	} else {
-		if e.monitorSvc.IsTaskRunning(rule.ID) {
-			if err := e.monitorSvc.StopTask(rule.ID); err != nil {
-				slog.Error("停止监控任务失败", "rule_id", rule.ID, "error", err)
-			}
-		}
-	}
-
-	// 发布事件
+// ruleUpdated 发布规则更新事件
+func (e *Engine) ruleUpdated(rule *models.MonitorRule) {
 	e.eventBus.Publish(Event{
 		Type:      EventRuleUpdated,
 		RuleID:    rule.ID,
@@ -224,7 +289,6 @@ func (e *Engine) UpdateRule(rule *models.MonitorRule) error {
 	})
 
 	slog.Info("规则已更新", "rule_id", rule.ID, "rule_name", rule.Name)
-	return nil
 }
 
 // DeleteRule 删除规则
@@ -241,7 +305,7 @@ func (e *Engine) DeleteRule(id string) error {
 	e.mu.Unlock()
 
 	if !found {
-		return fmt.Errorf("规则不存在: %s", id)
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, id)
 	}
 
 	// 停止监控任务
@@ -256,6 +320,11 @@ func (e *Engine) DeleteRule(id string) error {
 		return fmt.Errorf("删除规则失败: %w", err)
 	}
 
+	// 删除历史记录
+	if err := e.history.DeleteRule(id); err != nil {
+		slog.Error("删除历史记录失败", "rule_id", id, "error", err)
+	}
+
 	// 发布事件
 	e.eventBus.Publish(Event{
 		Type:      EventRuleDeleted,
@@ -274,27 +343,38 @@ func (e *Engine) StartMonitoring(ruleID string) error {
 		return err
 	}
 
-	if err := e.monitorSvc.StartTask(rule); err != nil {
+	// 先保存启用状态再启动任务；启动后再更新规则会取消任务正在进行的首次检查并重新请求
+	enabled := *rule
+	enabled.Enabled = true
+	if err := e.saveRule(&enabled); err != nil {
+		return err
+	}
+
+	if err := e.monitorSvc.StartTask(&enabled); err != nil {
+		// 恢复原来的规则
+		if saveErr := e.saveRule(rule); saveErr != nil {
+			slog.Error("恢复规则失败", "rule_id", rule.ID, "error", saveErr)
+		}
 		return fmt.Errorf("启动监控失败: %w", err)
 	}
 
-	// 更新规则状态
-	rule.Enabled = true
-	return e.UpdateRule(rule)
+	e.ruleUpdated(&enabled)
+	return nil
 }
 
 // StopMonitoring 停止监控
 func (e *Engine) StopMonitoring(ruleID string) error {
-	if err := e.monitorSvc.StopTask(ruleID); err != nil {
-		return fmt.Errorf("停止监控失败: %w", err)
-	}
-
-	// 更新规则状态
+	// 先查找规则，规则不存在时返回 ErrRuleNotFound 而不是任务不存在
 	rule, err := e.GetRule(ruleID)
 	if err != nil {
 		return err
 	}
 
+	if err := e.monitorSvc.StopTask(ruleID); err != nil {
+		return fmt.Errorf("停止监控失败: %w", err)
+	}
+
+	// 更新规则状态
 	rule.Enabled = false
 	rule.Status = models.StatusPaused
 	return e.UpdateRule(rule)
@@ -317,9 +397,88 @@ func (e *Engine) StopAllMonitoring() error {
 
 // CheckNow 立即检查
 func (e *Engine) CheckNow(ruleID string) error {
+	if _, err := e.GetRule(ruleID); err != nil {
+		return err
+	}
 	return e.monitorSvc.RunTaskOnce(ruleID)
 }
 
+// GetHistory 获取规则的内容历史
+func (e *Engine) GetHistory(ruleID string, limit int, before time.Time) ([]*history.Entry, error) {
+	if _, err := e.GetRule(ruleID); err != nil {
+		return nil, err
+	}
+
+	entries, err := e.history.List(ruleID, limit, before)
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %w", err)
+	}
+	return entries, nil
+}
+
+// GetDeliveries 获取发往远程渠道的通知的投递记录
+func (e *Engine) GetDeliveries(status notification.DeliveryStatus, limit int) ([]*notification.Delivery, error) {
+	if status != "" && !status.Valid() {
+		return nil, fmt.Errorf("无效的投递状态: %s", status)
+	}
+	return e.router.Deliveries(status, limit), nil
+}
+
+// ResendDelivery 立即重新发送一条通知
+func (e *Engine) ResendDelivery(id string) error {
+	if err := e.router.Resend(id); err != nil {
+		if errors.Is(err, notification.ErrDeliveryNotFound) {
+			return fmt.Errorf("%w: %s", ErrDeliveryNotFound, id)
+		}
+		return fmt.Errorf("重新发送通知失败: %w", err)
+	}
+	slog.Info("已重新发送通知", "id", id)
+	return nil
+}
+
+// SetSecrets 设置密钥存储，需要在初始化之前调用
+func (e *Engine) SetSecrets(store *secrets.Store) {
+	e.secrets = store
+}
+
+// ListSecrets 获取所有密钥的名称和更新时间
+func (e *Engine) ListSecrets() ([]secrets.Info, error) {
+	if e.secrets == nil {
+		return nil, ErrSecretsUnavailable
+	}
+	return e.secrets.List(), nil
+}
+
+// SetSecret 新增或更新密钥
+func (e *Engine) SetSecret(name, value string) error {
+	if e.secrets == nil {
+		return ErrSecretsUnavailable
+	}
+	if err := e.secrets.Set(name, value); err != nil {
+		if errors.Is(err, secrets.ErrInvalidName) {
+			return fmt.Errorf("%w: %q", ErrInvalidSecret, name)
+		}
+		return fmt.Errorf("保存密钥失败: %w", err)
+	}
+	slog.Info("已保存密钥", "name", name)
+	return nil
+}
+
+// DeleteSecret 删除密钥
+func (e *Engine) DeleteSecret(name string) error {
+	if e.secrets == nil {
+		return ErrSecretsUnavailable
+	}
+	if err := e.secrets.Delete(name); err != nil {
+		if errors.Is(err, secrets.ErrSecretNotFound) {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+		}
+		return fmt.Errorf("删除密钥失败: %w", err)
+	}
+	slog.Info("已删除密钥", "name", name)
+	return nil
+}
+
 // Subscribe 订阅事件
 func (e *Engine) Subscribe(listener EventListener) {
 	e.eventBus.Subscribe(listener)
@@ -354,6 +513,82 @@ func (e *Engine) UpdateRuleInMemory(rule *models.MonitorRule) {
 	}
 }
 
+// processOutcomes 处理监控任务发送的检查结果，直到引擎关闭
+func (e *Engine) processOutcomes() {
+	defer close(e.outcomesDone)
+
+	outcomes := e.monitorSvc.Outcomes()
+	for {
+		select {
+		case outcome := <-outcomes:
+			e.handleOutcome(outcome)
+		case <-e.stopOutcomes:
+			// 处理剩余的结果
+			for {
+				select {
+				case outcome := <-outcomes:
+					e.handleOutcome(outcome)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// stopOutcomeLoop 停止检查结果处理循环并等待其退出，ctx 结束时不再等待并返回错误
+func (e *Engine) stopOutcomeLoop(ctx context.Context) error {
+	// 处理循环未启动时直接标记为已结束
+	e.outcomeOnce.Do(func() {
+		close(e.outcomesDone)
+	})
+	e.stopOnce.Do(func() {
+		close(e.stopOutcomes)
+	})
+
+	select {
+	case <-e.outcomesDone:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// handleOutcome 将检查结果转换为内容变化、监控错误或恢复事件
+//
+// 从连续失败中恢复的检查先发布恢复事件，内容同时发生变化时再发布内容变化事件。
+func (e *Engine) handleOutcome(outcome monitor.Outcome) {
+	var eventTypes []EventType
+	switch outcome.Kind {
+	case monitor.OutcomeError:
+		eventTypes = append(eventTypes, EventMonitorError)
+	default:
+		if outcome.Failures > 0 {
+			eventTypes = append(eventTypes, EventMonitorRecovered)
+		}
+		if outcome.Kind == monitor.OutcomeChanged {
+			eventTypes = append(eventTypes, EventContentChanged)
+		}
+	}
+
+	// 规则可能已被删除
+	rule, _ := e.GetRule(outcome.RuleID)
+
+	for _, eventType := range eventTypes {
+		e.eventBus.Publish(Event{
+			Type:      eventType,
+			RuleID:    outcome.RuleID,
+			Rule:      rule,
+			Timestamp: outcome.CheckedAt,
+			Data:      outcome,
+		})
+
+		if rule != nil && rule.NotifyEnabled {
+			e.notify(rule, eventType, &outcome)
+		}
+	}
+}
+
 // handleRuleUpdate 处理规则更新（由监控任务回调）
 func (e *Engine) handleRuleUpdate(rule *models.MonitorRule) {
 	e.mu.Lock()
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/config"
+	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/monitor"
+	"github.com/zx06/apiwatch/notification"
+)
+
+// recordingBus 同步记录发布的事件
+type recordingBus struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (b *recordingBus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+}
+
+func (b *recordingBus) Subscribe(listener EventListener)   {}
+func (b *recordingBus) Unsubscribe(listener EventListener) {}
+
+func (b *recordingBus) types() []EventType {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	types := make([]EventType, len(b.events))
+	for i, event := range b.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// newTestEngine 创建只用于处理检查结果的引擎，事件记录到返回的事件总线
+func newTestEngine(rules ...*models.MonitorRule) (*Engine, *recordingBus) {
+	bus := &recordingBus{}
+	engine := NewEngine(nil, nil, nil, nil)
+	engine.eventBus = bus
+	engine.rules = rules
+	return engine, bus
+}
+
+func TestEngine_HandleOutcome(t *testing.T) {
+	rule := &models.MonitorRule{ID: "rule-1", Name: "测试规则"}
+
+	tests := []struct {
+		name    string
+		outcome monitor.Outcome
+		want    []EventType
+	}{
+		{
+			name:    "内容未变化不发布事件",
+			outcome: monitor.Outcome{Kind: monitor.OutcomeUnchanged},
+			want:    []EventType{},
+		},
+		{
+			name:    "内容变化",
+			outcome: monitor.Outcome{Kind: monitor.OutcomeChanged},
+			want:    []EventType{EventContentChanged},
+		},
+		{
+			name:    "检查失败",
+			outcome: monitor.Outcome{Kind: monitor.OutcomeError, Failures: 1},
+			want:    []EventType{EventMonitorError},
+		},
+		{
+			name:    "连续失败后恢复",
+			outcome: monitor.Outcome{Kind: monitor.OutcomeUnchanged, Failures: 2},
+			want:    []EventType{EventMonitorRecovered},
+		},
+		{
+			name:    "恢复时内容同时变化",
+			outcome: monitor.Outcome{Kind: monitor.OutcomeChanged, Failures: 2},
+			want:    []EventType{EventMonitorRecovered, EventContentChanged},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, bus := newTestEngine(rule)
+			tt.outcome.RuleID = rule.ID
+			tt.outcome.CheckedAt = time.Now()
+
+			engine.handleOutcome(tt.outcome)
+			assert.Equal(t, tt.want, bus.types())
+		})
+	}
+
+	t.Run("事件包含规则和检查结果", func(t *testing.T) {
+		engine, bus := newTestEngine(rule)
+		checkedAt := time.Now()
+		outcome := monitor.Outcome{RuleID: rule.ID, Kind: monitor.OutcomeChanged, CheckedAt: checkedAt}
+
+		engine.handleOutcome(outcome)
+		require.Len(t, bus.events, 1)
+		event := bus.events[0]
+		assert.Equal(t, rule.ID, event.RuleID)
+		assert.Equal(t, rule, event.Rule)
+		assert.Equal(t, checkedAt, event.Timestamp)
+		assert.Equal(t, outcome, event.Data)
+	})
+
+	t.Run("规则已删除时仍发布事件", func(t *testing.T) {
+		engine, bus := newTestEngine()
+
+		engine.handleOutcome(monitor.Outcome{RuleID: "deleted", Kind: monitor.OutcomeError, Failures: 1})
+		require.Len(t, bus.events, 1)
+		assert.Nil(t, bus.events[0].Rule)
+	})
+}
+
+// blockingFetcher 请求阻塞到 release 关闭，记录请求次数和被取消的次数
+type blockingFetcher struct {
+	calls    atomic.Int32
+	canceled atomic.Int32
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func (f *blockingFetcher) Fetch(ctx context.Context, req *fetcher.Request) (*fetcher.Response, error) {
+	f.calls.Add(1)
+	f.started <- struct{}{}
+	select {
+	case <-f.release:
+		return &fetcher.Response{Body: []byte(`{"v":1}`), ContentType: "application/json", StatusCode: 200}, nil
+	case <-ctx.Done():
+		f.canceled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+func TestEngine_StartMonitoring(t *testing.T) {
+	rule := &models.MonitorRule{
+		ID:            "rule-1",
+		Name:          "测试规则",
+		URL:           "https://example.com",
+		Interval:      models.Duration(time.Hour),
+		ExtractorType: models.ExtractorJSON,
+		ExtractorExpr: "v",
+	}
+	configMgr, err := config.NewYAMLManager(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, configMgr.Save([]*models.MonitorRule{rule}))
+
+	f := &blockingFetcher{started: make(chan struct{}, 4), release: make(chan struct{})}
+	monitorSvc := monitor.NewMonitorService(f, nil)
+	engine := NewEngine(configMgr, monitorSvc, notification.NewRouter(nil, nil, nil), nil)
+	bus := &recordingBus{}
+	engine.eventBus = bus
+	engine.rules = []*models.MonitorRule{rule}
+	t.Cleanup(func() {
+		close(f.release)
+		_ = monitorSvc.Shutdown(context.Background())
+	})
+
+	require.NoError(t, engine.StartMonitoring(rule.ID))
+
+	t.Run("首次检查不会被规则更新取消", func(t *testing.T) {
+		select {
+		case <-f.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("首次检查没有开始")
+		}
+		// 给可能的重复启动留出时间
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(1), f.calls.Load())
+		assert.Zero(t, f.canceled.Load())
+		assert.True(t, monitorSvc.IsTaskRunning(rule.ID))
+	})
+
+	t.Run("保存启用状态并发布事件", func(t *testing.T) {
+		saved, err := engine.GetRule(rule.ID)
+		require.NoError(t, err)
+		assert.True(t, saved.Enabled)
+		assert.Equal(t, []EventType{EventRuleUpdated}, bus.types())
+
+		rules, err := configMgr.Load()
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.True(t, rules[0].Enabled)
+	})
+
+	t.Run("重复启动返回错误", func(t *testing.T) {
+		err := engine.StartMonitoring(rule.ID)
+		assert.ErrorIs(t, err, monitor.ErrTaskAlreadyRunning)
+		assert.Equal(t, []EventType{EventRuleUpdated}, bus.types())
+	})
+}
+
+func TestEngine_StopMonitoring_RuleNotFound(t *testing.T) {
+	engine, bus := newTestEngine()
+
+	err := engine.StopMonitoring("missing")
+	assert.ErrorIs(t, err, ErrRuleNotFound)
+	assert.Empty(t, bus.types())
+}
@@ -0,0 +1,23 @@
+package core
+
+import "errors"
+
+var (
+	// ErrRuleNotFound 规则不存在
+	ErrRuleNotFound = errors.New("规则不存在")
+
+	// ErrInvalidRule 规则验证失败
+	ErrInvalidRule = errors.New("规则验证失败")
+
+	// ErrDeliveryNotFound 通知投递记录不存在
+	ErrDeliveryNotFound = errors.New("通知投递记录不存在")
+
+	// ErrSecretNotFound 密钥不存在
+	ErrSecretNotFound = errors.New("密钥不存在")
+
+	// ErrInvalidSecret 密钥名称无效
+	ErrInvalidSecret = errors.New("无效的密钥名称")
+
+	// ErrSecretsUnavailable 未配置密钥存储
+	ErrSecretsUnavailable = errors.New("未配置密钥存储")
+)
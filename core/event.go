@@ -17,15 +17,16 @@ const (
 	EventRuleStatusChanged EventType = "rule_status_changed"
 	EventContentChanged    EventType = "content_changed"
 	EventMonitorError      EventType = "monitor_error"
+	EventMonitorRecovered  EventType = "monitor_recovered"
 )
 
 // Event 事件
 type Event struct {
-	Type      EventType
-	RuleID    string
-	Rule      *models.MonitorRule
-	Timestamp time.Time
-	Data      interface{}
+	Type      EventType           `json:"type"`
+	RuleID    string              `json:"rule_id"`
+	Rule      *models.MonitorRule `json:"rule,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+	Data      interface{}         `json:"data,omitempty"`
 }
 
 // EventListener 事件监听器接口
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/monitor"
+	"github.com/zx06/apiwatch/notification"
+)
+
+// maxNotificationDiff 通知正文中差异文本的最大字符数
+const maxNotificationDiff = 200
+
+// notify 将检查结果发送到规则配置的通知渠道
+//
+// 检查失败只在连续失败次数达到规则告警策略的阈值时通知一次；
+// 恢复通知只在规则开启了 NotifyRecovery 且此前已发送过失败告警时发送。
+func (e *Engine) notify(rule *models.MonitorRule, eventType EventType, outcome *monitor.Outcome) {
+	threshold := rule.Alert.Threshold()
+
+	var msg *notification.Message
+	switch eventType {
+	case EventContentChanged:
+		msg = newChangeMessage(rule, outcome)
+	case EventMonitorError:
+		if outcome.Failures != threshold {
+			return
+		}
+		msg = newErrorMessage(rule, outcome)
+	case EventMonitorRecovered:
+		if rule.Alert == nil || !rule.Alert.NotifyRecovery || outcome.Failures < threshold {
+			return
+		}
+		msg = newRecoveryMessage(rule, outcome)
+	default:
+		return
+	}
+
+	if err := e.router.Send(rule, msg); err != nil {
+		slog.Warn("发送通知失败",
+			"rule_id", rule.ID,
+			"event", msg.Event,
+			"error", err,
+		)
+	}
+}
+
+// newChangeMessage 创建内容变化通知
+func newChangeMessage(rule *models.MonitorRule, outcome *monitor.Outcome) *notification.Message {
+	// 正文只包含差异摘要，完整内容通过Message的其他字段提供
+	body := outcome.Diff.Summary() + "\n" + outcome.Diff.Brief(maxNotificationDiff)
+	if rule.Description != "" {
+		body = rule.Description + "\n\n" + body
+	}
+
+	return &notification.Message{
+		Event:       notification.EventContentChanged,
+		Title:       fmt.Sprintf("内容变化: %s", rule.Name),
+		Body:        body,
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		URL:         rule.URL,
+		Description: rule.Description,
+		OldContent:  outcome.OldContent,
+		NewContent:  outcome.NewContent,
+		Diff:        outcome.Diff,
+		StatusCode:  outcome.StatusCode,
+		DurationMs:  outcome.DurationMs,
+		Timestamp:   outcome.CheckedAt,
+	}
+}
+
+// newErrorMessage 创建检查失败通知
+func newErrorMessage(rule *models.MonitorRule, outcome *monitor.Outcome) *notification.Message {
+	title := fmt.Sprintf("检查失败: %s", rule.Name)
+	var body string
+	switch outcome.Stage {
+	case monitor.StageFetch:
+		body = "HTTP请求失败: " + outcome.Error
+	case monitor.StageExtract:
+		body = "内容提取失败: " + outcome.Error
+	case monitor.StageCertificate:
+		title = fmt.Sprintf("证书即将过期: %s", rule.Name)
+		body = outcome.Error
+	default:
+		body = outcome.Error
+	}
+	if outcome.Failures > 1 {
+		body += fmt.Sprintf("\n已连续失败 %d 次", outcome.Failures)
+		if d := notification.FailureDuration(outcome.FailingSince, outcome.CheckedAt); d > 0 {
+			body += fmt.Sprintf("（持续 %s）", d)
+		}
+	}
+
+	return &notification.Message{
+		Event:       notification.EventMonitorError,
+		Title:       title,
+		Body:        body,
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		URL:         rule.URL,
+		Description: rule.Description,
+		OldContent:  outcome.OldContent,
+		StatusCode:  outcome.StatusCode,
+		DurationMs:  outcome.DurationMs,
+		Stage:       string(outcome.Stage),
+		Error:       outcome.Error,
+		Timestamp:   outcome.CheckedAt,
+
+		Failures:     outcome.Failures,
+		FailingSince: outcome.FailingSince,
+	}
+}
+
+// newRecoveryMessage 创建连续失败后恢复的通知
+func newRecoveryMessage(rule *models.MonitorRule, outcome *monitor.Outcome) *notification.Message {
+	body := fmt.Sprintf("连续失败 %d 次后恢复", outcome.Failures)
+	if d := notification.FailureDuration(outcome.FailingSince, outcome.CheckedAt); d > 0 {
+		body += fmt.Sprintf("，持续 %s", d)
+	}
+	if outcome.LastError != "" {
+		body += "\n最后一次错误: " + outcome.LastError
+	}
+
+	return &notification.Message{
+		Event:       notification.EventMonitorRecovered,
+		Title:       fmt.Sprintf("已恢复: %s", rule.Name),
+		Body:        body,
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		URL:         rule.URL,
+		Description: rule.Description,
+		NewContent:  outcome.NewContent,
+		StatusCode:  outcome.StatusCode,
+		DurationMs:  outcome.DurationMs,
+		Error:       outcome.LastError,
+		Timestamp:   outcome.CheckedAt,
+
+		Failures:     outcome.Failures,
+		FailingSince: outcome.FailingSince,
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/monitor"
+	"github.com/zx06/apiwatch/notification"
+)
+
+// recordingNotifier 记录收到的通知标题
+type recordingNotifier struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (n *recordingNotifier) Notify(title, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.titles = append(n.titles, title)
+	return nil
+}
+
+func (n *recordingNotifier) sent() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.titles...)
+}
+
+// newNotifyEngine 创建同步发送桌面通知的引擎
+func newNotifyEngine() (*Engine, *recordingNotifier) {
+	desktop := &recordingNotifier{}
+	return NewEngine(nil, nil, notification.NewRouter(nil, nil, desktop), nil), desktop
+}
+
+func newNotifyRule(alert *models.AlertPolicy) *models.MonitorRule {
+	return &models.MonitorRule{
+		ID:            "rule-1",
+		Name:          "测试规则",
+		NotifyEnabled: true,
+		ErrorChannels: []string{models.DesktopChannel},
+		Alert:         alert,
+	}
+}
+
+func TestEngine_Notify(t *testing.T) {
+	tests := []struct {
+		name      string
+		alert     *models.AlertPolicy
+		eventType EventType
+		failures  int
+		want      []string
+	}{
+		{name: "内容变化", eventType: EventContentChanged, want: []string{"内容变化: 测试规则"}},
+		{name: "默认首次失败即告警", eventType: EventMonitorError, failures: 1, want: []string{"检查失败: 测试规则"}},
+		{name: "未达到告警阈值", alert: &models.AlertPolicy{FailureThreshold: 3}, eventType: EventMonitorError, failures: 2, want: nil},
+		{name: "达到告警阈值", alert: &models.AlertPolicy{FailureThreshold: 3}, eventType: EventMonitorError, failures: 3, want: []string{"检查失败: 测试规则"}},
+		{name: "超过告警阈值不重复告警", alert: &models.AlertPolicy{FailureThreshold: 3}, eventType: EventMonitorError, failures: 4, want: nil},
+		{name: "未开启恢复通知", eventType: EventMonitorRecovered, failures: 2, want: nil},
+		{name: "未告警过不发送恢复通知", alert: &models.AlertPolicy{FailureThreshold: 3, NotifyRecovery: true}, eventType: EventMonitorRecovered, failures: 2, want: nil},
+		{name: "告警后恢复", alert: &models.AlertPolicy{FailureThreshold: 3, NotifyRecovery: true}, eventType: EventMonitorRecovered, failures: 3, want: []string{"已恢复: 测试规则"}},
+		{name: "其他事件不通知", eventType: EventRuleUpdated, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, desktop := newNotifyEngine()
+			outcome := &monitor.Outcome{
+				RuleID:    "rule-1",
+				Failures:  tt.failures,
+				Diff:      diff.Compute("旧", "新"),
+				CheckedAt: time.Now(),
+			}
+
+			engine.notify(newNotifyRule(tt.alert), tt.eventType, outcome)
+			assert.Equal(t, tt.want, desktop.sent())
+		})
+	}
+
+	t.Run("规则频率限制不丢弃告警和恢复通知", func(t *testing.T) {
+		engine, desktop := newNotifyEngine()
+		rule := newNotifyRule(&models.AlertPolicy{NotifyRecovery: true})
+		rule.RateLimit = &models.RateLimit{Max: 1, Window: models.Duration(time.Hour)}
+		changed := &monitor.Outcome{RuleID: rule.ID, Diff: diff.Compute("旧", "新"), CheckedAt: time.Now()}
+
+		engine.notify(rule, EventContentChanged, changed)
+		engine.notify(rule, EventContentChanged, changed)
+		engine.notify(rule, EventMonitorError, &monitor.Outcome{RuleID: rule.ID, Failures: 1, CheckedAt: time.Now()})
+		engine.notify(rule, EventMonitorRecovered, &monitor.Outcome{RuleID: rule.ID, Failures: 1, CheckedAt: time.Now()})
+
+		assert.Equal(t, []string{"内容变化: 测试规则", "检查失败: 测试规则", "已恢复: 测试规则"}, desktop.sent())
+	})
+}
+
+func TestFailureMessages_Duration(t *testing.T) {
+	rule := newNotifyRule(nil)
+	checkedAt := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	outcome := &monitor.Outcome{
+		Kind:         monitor.OutcomeError,
+		Stage:        monitor.StageFetch,
+		Error:        "timeout",
+		LastError:    "timeout",
+		Failures:     3,
+		FailingSince: checkedAt.Add(-90*time.Second - 400*time.Millisecond),
+		CheckedAt:    checkedAt,
+	}
+
+	t.Run("检查失败", func(t *testing.T) {
+		msg := newErrorMessage(rule, outcome)
+		assert.Contains(t, msg.Body, "（持续 1m30s）")
+		assert.Equal(t, 90*time.Second, notification.FailureDuration(msg.FailingSince, msg.Timestamp))
+	})
+
+	t.Run("恢复", func(t *testing.T) {
+		msg := newRecoveryMessage(rule, outcome)
+		assert.Contains(t, msg.Body, "，持续 1m30s")
+		assert.Equal(t, 90*time.Second, notification.FailureDuration(msg.FailingSince, msg.Timestamp))
+	})
+}
@@ -0,0 +1,305 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// contextLines unified diff 中每个变更块前后保留的上下文行数
+	contextLines = 3
+
+	// wordContext 词级差异中变更前后保留的上下文字符数
+	wordContext = 20
+
+	// maxWordDiffInput 计算词级差异的最大输入长度（字节），超过时只提供行级差异
+	maxWordDiffInput = 64 * 1024
+)
+
+// Result 内容差异
+type Result struct {
+	// Unified 行级unified diff
+	Unified string `json:"unified"`
+
+	// Words 词级差异，删除的部分标记为 [-...-]，新增的部分标记为 {+...+}
+	Words string `json:"words,omitempty"`
+
+	// JSON 两个值都是JSON对象或数组时的结构化差异
+	JSON []JSONChange `json:"json,omitempty"`
+
+	// Added 新增行数
+	Added int `json:"added"`
+
+	// Removed 删除行数
+	Removed int `json:"removed"`
+
+	// Approximate 为true时变化过大，差异按整体替换近似计算
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+// Compute 计算旧内容与新内容之间的差异
+func Compute(oldContent, newContent string) *Result {
+	result := &Result{}
+
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	ops, exact := diffTokens(oldLines, newLines)
+	result.Approximate = !exact
+
+	for _, o := range ops {
+		switch o.kind {
+		case opDelete:
+			result.Removed++
+		case opInsert:
+			result.Added++
+		}
+	}
+	result.Unified = formatUnified(oldLines, newLines, ops)
+
+	if len(oldContent)+len(newContent) <= maxWordDiffInput {
+		result.Words = wordDiff(oldContent, newContent)
+	}
+
+	result.JSON = jsonDiff(oldContent, newContent)
+
+	return result
+}
+
+// Summary 返回差异的简要说明，例如 "+2 -1 行"
+func (r *Result) Summary() string {
+	summary := fmt.Sprintf("+%d -%d 行", r.Added, r.Removed)
+	if len(r.JSON) > 0 {
+		summary += fmt.Sprintf("，%d 处字段变化", len(r.JSON))
+	}
+	return summary
+}
+
+// Brief 返回适合放在通知正文中的差异文本，长度不超过 maxRunes 个字符
+//
+// 优先使用结构化差异，其次是词级差异，最后是行级差异。
+func (r *Result) Brief(maxRunes int) string {
+	var text string
+	switch {
+	case len(r.JSON) > 0:
+		lines := make([]string, 0, len(r.JSON))
+		for _, c := range r.JSON {
+			lines = append(lines, c.String())
+		}
+		text = strings.Join(lines, "\n")
+	case r.Words != "":
+		text = r.Words
+	default:
+		text = r.Unified
+	}
+	return truncate(text, maxRunes)
+}
+
+// splitLines 按行拆分内容，末尾的换行符不产生空行
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.TrimSuffix(s, "\n")
+	return strings.Split(s, "\n")
+}
+
+// formatUnified 生成unified diff文本
+func formatUnified(a, b []string, ops []op) string {
+	// 找出所有变更的位置
+	changed := make([]int, 0)
+	for i, o := range ops {
+		if o.kind != opEqual {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- old\n+++ new\n")
+
+	// 将相距不超过 2*contextLines 的变更合并为一个块
+	for start := 0; start < len(changed); {
+		end := start
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*contextLines+1 {
+			end++
+		}
+
+		from := max(changed[start]-contextLines, 0)
+		to := min(changed[end]+contextLines+1, len(ops))
+		writeHunk(&sb, a, b, ops[from:to], ops[:from])
+
+		start = end + 1
+	}
+
+	return sb.String()
+}
+
+// writeHunk 输出一个变更块，before 为该块之前的所有操作（用于计算起始行号）
+func writeHunk(sb *strings.Builder, a, b []string, hunk, before []op) {
+	oldStart, newStart := 1, 1
+	for _, o := range before {
+		if o.kind != opInsert {
+			oldStart++
+		}
+		if o.kind != opDelete {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, o := range hunk {
+		if o.kind != opInsert {
+			oldCount++
+		}
+		if o.kind != opDelete {
+			newCount++
+		}
+	}
+
+	// unified diff 约定：行数为0时起始行号指向前一行
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, o := range hunk {
+		switch o.kind {
+		case opEqual:
+			sb.WriteString(" " + a[o.a] + "\n")
+		case opDelete:
+			sb.WriteString("-" + a[o.a] + "\n")
+		case opInsert:
+			sb.WriteString("+" + b[o.b] + "\n")
+		}
+	}
+}
+
+// hunkRange 格式化变更块的行范围
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// wordDiff 生成词级差异，较长的未变化部分只保留靠近变更处的上下文
+func wordDiff(oldContent, newContent string) string {
+	a := tokenizeWords(oldContent)
+	b := tokenizeWords(newContent)
+	ops, _ := diffTokens(a, b)
+
+	// 按类型合并连续的操作
+	type segment struct {
+		kind opKind
+		text strings.Builder
+	}
+	segments := make([]*segment, 0)
+	for _, o := range ops {
+		var token string
+		if o.kind == opInsert {
+			token = b[o.b]
+		} else {
+			token = a[o.a]
+		}
+		if n := len(segments); n == 0 || segments[n-1].kind != o.kind {
+			segments = append(segments, &segment{kind: o.kind})
+		}
+		segments[len(segments)-1].text.WriteString(token)
+	}
+
+	var sb strings.Builder
+	for i, seg := range segments {
+		text := seg.text.String()
+		switch seg.kind {
+		case opDelete:
+			sb.WriteString("[-" + text + "-]")
+		case opInsert:
+			sb.WriteString("{+" + text + "+}")
+		case opEqual:
+			sb.WriteString(elideEqual(text, i > 0, i < len(segments)-1))
+		}
+	}
+	return sb.String()
+}
+
+// elideEqual 省略未变化文本的中间部分
+// hasBefore/hasAfter 表示该段文本之前/之后是否有变更
+func elideEqual(text string, hasBefore, hasAfter bool) string {
+	runes := []rune(text)
+
+	keepHead, keepTail := 0, 0
+	if hasBefore {
+		keepHead = wordContext
+	}
+	if hasAfter {
+		keepTail = wordContext
+	}
+	if len(runes) <= keepHead+keepTail+1 {
+		return text
+	}
+
+	return string(runes[:keepHead]) + "…" + string(runes[len(runes)-keepTail:])
+}
+
+// tokenizeWords 将文本拆分为单词、空白和标点
+//
+// 连续的字母数字组成一个单词；中日韩文字等没有空格分隔的字符逐字拆分；
+// 连续的空白组成一个token，保证拼接后与原文一致。
+func tokenizeWords(s string) []string {
+	tokens := make([]string, 0, len(s)/4)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		j := i + size
+
+		switch {
+		case unicode.IsSpace(r):
+			for j < len(s) {
+				r2, size2 := utf8.DecodeRuneInString(s[j:])
+				if !unicode.IsSpace(r2) {
+					break
+				}
+				j += size2
+			}
+		case isWordRune(r):
+			for j < len(s) {
+				r2, size2 := utf8.DecodeRuneInString(s[j:])
+				if !isWordRune(r2) {
+					break
+				}
+				j += size2
+			}
+		}
+
+		tokens = append(tokens, s[i:j])
+		i = j
+	}
+	return tokens
+}
+
+// isWordRune 判断字符是否属于可连写的单词（不包括中日韩文字）
+func isWordRune(r rune) bool {
+	if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// truncate 按字符数截断文本
+func truncate(s string, maxRunes int) string {
+	if maxRunes <= 0 || utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	runes := []rune(s)
+	if maxRunes <= 3 {
+		return string(runes[:maxRunes])
+	}
+	return string(runes[:maxRunes-3]) + "..."
+}
@@ -0,0 +1,213 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// applyOps 根据编辑脚本从a重建b，用于验证脚本正确性
+func applyOps(a, b []string, ops []op) []string {
+	result := make([]string, 0, len(b))
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			result = append(result, a[o.a])
+		case opInsert:
+			result = append(result, b[o.b])
+		}
+	}
+	return result
+}
+
+func TestDiffTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		changes int
+	}{
+		{name: "完全相同", a: "abc", b: "abc", changes: 0},
+		{name: "都为空", a: "", b: "", changes: 0},
+		{name: "从空到有", a: "", b: "abc", changes: 3},
+		{name: "从有到空", a: "abc", b: "", changes: 3},
+		{name: "中间替换", a: "abcdef", b: "abxdef", changes: 2},
+		{name: "经典示例", a: "abcabba", b: "cbabac", changes: 5},
+		{name: "完全不同", a: "abc", b: "xyz", changes: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := strings.Split(tt.a, "")
+			b := strings.Split(tt.b, "")
+
+			ops, exact := diffTokens(a, b)
+			require.True(t, exact)
+			assert.Equal(t, b, applyOps(a, b, ops))
+
+			changes := 0
+			for _, o := range ops {
+				if o.kind != opEqual {
+					changes++
+				}
+			}
+			assert.Equal(t, tt.changes, changes)
+		})
+	}
+
+	t.Run("超过编辑距离上限时整体替换", func(t *testing.T) {
+		a := make([]string, maxEditDistance)
+		b := make([]string, maxEditDistance)
+		for i := range a {
+			a[i] = fmt.Sprintf("a%d", i)
+			b[i] = fmt.Sprintf("b%d", i)
+		}
+
+		ops, exact := diffTokens(a, b)
+		assert.False(t, exact)
+		assert.Len(t, ops, 2*maxEditDistance)
+		assert.Equal(t, b, applyOps(a, b, ops))
+	})
+}
+
+func TestCompute(t *testing.T) {
+	t.Run("内容相同", func(t *testing.T) {
+		result := Compute("a\nb\n", "a\nb\n")
+		assert.Empty(t, result.Unified)
+		assert.Equal(t, 0, result.Added)
+		assert.Equal(t, 0, result.Removed)
+	})
+
+	t.Run("行级差异", func(t *testing.T) {
+		oldContent := "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\n"
+		newContent := "line1\nline2\nline3\nline4\nline5\nline6\nchanged\nline8\n"
+
+		result := Compute(oldContent, newContent)
+		assert.Equal(t, 1, result.Added)
+		assert.Equal(t, 1, result.Removed)
+		assert.False(t, result.Approximate)
+		assert.Equal(t,
+			"--- old\n+++ new\n"+
+				"@@ -4,5 +4,5 @@\n"+
+				" line4\n line5\n line6\n-line7\n+changed\n line8\n",
+			result.Unified)
+	})
+
+	t.Run("相距较远的变化分为多个块", func(t *testing.T) {
+		lines := make([]string, 20)
+		for i := range lines {
+			lines[i] = fmt.Sprintf("line%d", i+1)
+		}
+		oldContent := strings.Join(lines, "\n")
+		lines[0] = "first"
+		lines[19] = "last"
+		newContent := strings.Join(lines, "\n")
+
+		result := Compute(oldContent, newContent)
+		assert.Equal(t, 2, strings.Count(result.Unified, "@@ -"))
+		assert.Contains(t, result.Unified, "@@ -1,4 +1,4 @@")
+		assert.Contains(t, result.Unified, "@@ -17,4 +17,4 @@")
+	})
+
+	t.Run("纯新增", func(t *testing.T) {
+		result := Compute("", "hello")
+		assert.Equal(t, 1, result.Added)
+		assert.Equal(t, 0, result.Removed)
+		assert.Contains(t, result.Unified, "@@ -0,0 +1 @@\n+hello\n")
+	})
+
+	t.Run("词级差异", func(t *testing.T) {
+		result := Compute("price is 100 USD", "price is 120 USD")
+		assert.Equal(t, "price is [-100-]{+120+} USD", result.Words)
+	})
+
+	t.Run("中文逐字比较", func(t *testing.T) {
+		result := Compute("今天天气晴", "今天天气阴")
+		assert.Equal(t, "今天天气[-晴-]{+阴+}", result.Words)
+	})
+
+	t.Run("长文本省略未变化部分", func(t *testing.T) {
+		prefix := strings.Repeat("word ", 50)
+		result := Compute(prefix+"old", prefix+"new")
+		assert.True(t, strings.HasPrefix(result.Words, "…"))
+		assert.True(t, strings.HasSuffix(result.Words, "[-old-]{+new+}"))
+	})
+
+	t.Run("非JSON内容没有结构化差异", func(t *testing.T) {
+		result := Compute("hello", "world")
+		assert.Nil(t, result.JSON)
+	})
+}
+
+func TestJSONDiff(t *testing.T) {
+	t.Run("对象字段变化", func(t *testing.T) {
+		oldContent := `{"name":"a","price":100,"tags":["x"],"old":true}`
+		newContent := `{"name":"a","price":120,"tags":["x","y"],"new":{"k":"v"}}`
+
+		changes := Compute(oldContent, newContent).JSON
+		assert.Equal(t, []JSONChange{
+			{Path: "new", Type: ChangeAdded, New: `{"k":"v"}`},
+			{Path: "old", Type: ChangeRemoved, Old: "true"},
+			{Path: "price", Type: ChangeChanged, Old: "100", New: "120"},
+			{Path: "tags.1", Type: ChangeAdded, New: `"y"`},
+		}, changes)
+	})
+
+	t.Run("格式不同但内容相同", func(t *testing.T) {
+		changes := jsonDiff(`{"a": 1, "b": [1, 2]}`, "{\n  \"b\": [1,2],\n  \"a\": 1\n}")
+		assert.Nil(t, changes)
+	})
+
+	t.Run("数字精度保留", func(t *testing.T) {
+		changes := jsonDiff(`{"id":12345678901234567890}`, `{"id":12345678901234567891}`)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "12345678901234567891", changes[0].New)
+	})
+
+	t.Run("类型变化", func(t *testing.T) {
+		changes := jsonDiff(`{"a":[1]}`, `{"a":{"0":1}}`)
+		assert.Equal(t, []JSONChange{
+			{Path: "a", Type: ChangeChanged, Old: "[1]", New: `{"0":1}`},
+		}, changes)
+	})
+
+	t.Run("键名中的点号被转义", func(t *testing.T) {
+		changes := jsonDiff(`{"a.b":1}`, `{"a.b":2}`)
+		require.Len(t, changes, 1)
+		assert.Equal(t, `a\.b`, changes[0].Path)
+	})
+
+	t.Run("标量值不计算结构化差异", func(t *testing.T) {
+		assert.Nil(t, jsonDiff(`1`, `2`))
+		assert.Nil(t, jsonDiff(`"a"`, `"b"`))
+		assert.Nil(t, jsonDiff(`{"a":1}`, `not json`))
+	})
+
+	t.Run("变化数量有上限", func(t *testing.T) {
+		oldItems := make([]string, maxJSONChanges+50)
+		newItems := make([]string, maxJSONChanges+50)
+		for i := range oldItems {
+			oldItems[i] = fmt.Sprint(i)
+			newItems[i] = fmt.Sprint(i + 1)
+		}
+		changes := jsonDiff("["+strings.Join(oldItems, ",")+"]", "["+strings.Join(newItems, ",")+"]")
+		assert.Len(t, changes, maxJSONChanges)
+	})
+}
+
+func TestResult_Brief(t *testing.T) {
+	t.Run("优先使用结构化差异", func(t *testing.T) {
+		result := Compute(`{"price":100}`, `{"price":120}`)
+		assert.Equal(t, "~ price: 100 → 120", result.Brief(200))
+		assert.Equal(t, "+1 -1 行，1 处字段变化", result.Summary())
+	})
+
+	t.Run("按字符数截断", func(t *testing.T) {
+		result := Compute("旧内容", strings.Repeat("新", 100))
+		brief := result.Brief(10)
+		assert.Equal(t, 10, len([]rune(brief)))
+		assert.True(t, strings.HasSuffix(brief, "..."))
+	})
+}
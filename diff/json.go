@@ -0,0 +1,202 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxJSONChanges 结构化差异最多记录的变化数
+const maxJSONChanges = 100
+
+// ChangeType 字段变化类型
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"   // 新增字段
+	ChangeRemoved ChangeType = "removed" // 删除字段
+	ChangeChanged ChangeType = "changed" // 值变化
+)
+
+// JSONChange JSON字段变化
+type JSONChange struct {
+	// Path 字段路径，使用与JSONPath提取器相同的点号语法，例如 data.items.0.price
+	Path string `json:"path"`
+
+	Type ChangeType `json:"type"`
+
+	// Old 旧值（JSON编码），新增时为空
+	Old string `json:"old,omitempty"`
+
+	// New 新值（JSON编码），删除时为空
+	New string `json:"new,omitempty"`
+}
+
+// String 返回变化的文本表示
+func (c JSONChange) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s → %s", path, c.Old, c.New)
+	}
+}
+
+// jsonDiff 当两个内容都是JSON对象或数组时返回字段级差异，否则返回nil
+func jsonDiff(oldContent, newContent string) []JSONChange {
+	oldValue, ok := parseJSONContainer(oldContent)
+	if !ok {
+		return nil
+	}
+	newValue, ok := parseJSONContainer(newContent)
+	if !ok {
+		return nil
+	}
+
+	changes := make([]JSONChange, 0)
+	compareJSON("", oldValue, newValue, &changes)
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// parseJSONContainer 解析JSON对象或数组
+func parseJSONContainer(s string) (interface{}, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	// 不允许尾随内容
+	if dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// compareJSON 递归比较两个JSON值
+func compareJSON(path string, oldValue, newValue interface{}, changes *[]JSONChange) {
+	if len(*changes) >= maxJSONChanges {
+		return
+	}
+
+	switch o := oldValue.(type) {
+	case map[string]interface{}:
+		if n, ok := newValue.(map[string]interface{}); ok {
+			compareObjects(path, o, n, changes)
+			return
+		}
+	case []interface{}:
+		if n, ok := newValue.([]interface{}); ok {
+			compareArrays(path, o, n, changes)
+			return
+		}
+	}
+
+	oldJSON := encodeJSON(oldValue)
+	newJSON := encodeJSON(newValue)
+	if oldJSON != newJSON {
+		*changes = append(*changes, JSONChange{
+			Path: path,
+			Type: ChangeChanged,
+			Old:  oldJSON,
+			New:  newJSON,
+		})
+	}
+}
+
+// compareObjects 按键名比较两个对象，键按字母顺序处理以保证结果稳定
+func compareObjects(path string, o, n map[string]interface{}, changes *[]JSONChange) {
+	keys := make([]string, 0, len(o)+len(n))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	for k := range n {
+		if _, ok := o[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if len(*changes) >= maxJSONChanges {
+			return
+		}
+
+		childPath := joinPath(path, k)
+		oldChild, inOld := o[k]
+		newChild, inNew := n[k]
+		switch {
+		case !inOld:
+			*changes = append(*changes, JSONChange{Path: childPath, Type: ChangeAdded, New: encodeJSON(newChild)})
+		case !inNew:
+			*changes = append(*changes, JSONChange{Path: childPath, Type: ChangeRemoved, Old: encodeJSON(oldChild)})
+		default:
+			compareJSON(childPath, oldChild, newChild, changes)
+		}
+	}
+}
+
+// compareArrays 按下标比较两个数组
+func compareArrays(path string, o, n []interface{}, changes *[]JSONChange) {
+	for i := 0; i < max(len(o), len(n)); i++ {
+		if len(*changes) >= maxJSONChanges {
+			return
+		}
+
+		childPath := joinPath(path, strconv.Itoa(i))
+		switch {
+		case i >= len(o):
+			*changes = append(*changes, JSONChange{Path: childPath, Type: ChangeAdded, New: encodeJSON(n[i])})
+		case i >= len(n):
+			*changes = append(*changes, JSONChange{Path: childPath, Type: ChangeRemoved, Old: encodeJSON(o[i])})
+		default:
+			compareJSON(childPath, o[i], n[i], changes)
+		}
+	}
+}
+
+// joinPath 拼接字段路径，键名中的 . 和通配符按gjson语法转义
+func joinPath(path, key string) string {
+	var sb strings.Builder
+	for _, c := range key {
+		if c == '.' || c == '*' || c == '?' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+
+	if path == "" {
+		return sb.String()
+	}
+	return path + "." + sb.String()
+}
+
+// encodeJSON 将值编码为紧凑的JSON（不转义HTML字符）
+func encodeJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,158 @@
+package diff
+
+// opKind 编辑操作类型
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op 一个编辑操作，equal/delete 使用 a 中的下标，insert 使用 b 中的下标
+type op struct {
+	kind opKind
+	a, b int
+}
+
+// maxEditDistance Myers算法允许的最大编辑距离，超过后退化为整体替换
+const maxEditDistance = 2000
+
+// diffTokens 计算两个序列之间的最短编辑脚本
+//
+// 先去除公共前后缀，再对中间部分使用Myers算法；编辑距离超过上限时中间部分按整体替换处理，
+// 此时第二个返回值为false。
+func diffTokens(a, b []string) ([]op, bool) {
+	// 公共前缀
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	// 公共后缀
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, a: i, b: i})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	midOps, exact := myers(midA, midB, maxEditDistance)
+	if !exact {
+		midOps = midOps[:0]
+		for i := range midA {
+			midOps = append(midOps, op{kind: opDelete, a: i})
+		}
+		for j := range midB {
+			midOps = append(midOps, op{kind: opInsert, b: j})
+		}
+	}
+	for _, o := range midOps {
+		o.a += prefix
+		o.b += prefix
+		ops = append(ops, o)
+	}
+
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, op{kind: opEqual, a: len(a) - suffix + i, b: len(b) - suffix + i})
+	}
+
+	return ops, exact
+}
+
+// myers Myers O(ND) 差异算法，编辑距离超过 maxD 时返回false
+func myers(a, b []string, maxD int) ([]op, bool) {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil, true
+	}
+
+	limit := min(n+m, maxD)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] 保存第d步开始前 k ∈ [-d-1, d+1] 范围内的v值，下标为 k+d+1
+	trace := make([][]int, 0, 16)
+
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 向下移动：插入
+			} else {
+				x = v[offset+k-1] + 1 // 向右移动：删除
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// backtrack 根据每一步的v值回溯出编辑脚本
+func backtrack(trace [][]int, n, m int) []op {
+	ops := make([]op, 0, n+m)
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		snapshot := trace[d]
+		get := func(k int) int { return snapshot[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := 0
+		if d > 0 {
+			prevX = get(prevK)
+		}
+		prevY := prevX - prevK
+
+		// 对角线部分为相同元素
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: opEqual, a: x, b: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, op{kind: opInsert, b: y})
+			} else {
+				x--
+				ops = append(ops, op{kind: opDelete, a: x})
+			}
+		}
+	}
+
+	// 反转为正序
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,108 @@
+package extractor
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 证书提取器支持的字段
+const (
+	CertFieldDays     = "days"      // 剩余有效天数
+	CertFieldNotAfter = "not_after" // 到期时间（UTC，RFC3339）
+	CertFieldSubject  = "subject"   // 证书主体
+	CertFieldIssuer   = "issuer"    // 签发者
+	CertFieldSANs     = "sans"      // 备用名称（域名、IP、邮箱和URI）
+)
+
+// DefaultCertFields 提取表达式为空时提取的字段
+//
+// 不包含每天都会变化的剩余有效天数，避免每次检查都视为内容变化；到期告警不依赖提取的字段。
+var DefaultCertFields = []string{CertFieldNotAfter, CertFieldIssuer, CertFieldSANs}
+
+// CertificateExtractor 从服务器证书链提取内容的提取器
+type CertificateExtractor interface {
+	// ExtractCertificates 从证书链提取内容，now 用于计算剩余有效天数
+	ExtractCertificates(certs []*x509.Certificate, now time.Time) (string, error)
+}
+
+// CertExtractor 服务器证书提取器
+//
+// 只检查证书链中的服务器证书，每行输出一个字段，格式为 "字段: 值"。
+type CertExtractor struct {
+	fields []string
+}
+
+// NewCertExtractor 创建证书提取器，expr 为逗号分隔的字段列表，为空时使用默认字段
+func NewCertExtractor(expr string) (*CertExtractor, error) {
+	if strings.TrimSpace(expr) == "" {
+		return &CertExtractor{fields: DefaultCertFields}, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(expr, ",") {
+		field = strings.TrimSpace(field)
+		switch field {
+		case CertFieldDays, CertFieldNotAfter, CertFieldSubject, CertFieldIssuer, CertFieldSANs:
+			fields = append(fields, field)
+		default:
+			return nil, fmt.Errorf("不支持的证书字段: %q", field)
+		}
+	}
+	return &CertExtractor{fields: fields}, nil
+}
+
+// Extract 证书提取器不处理响应体，总是返回错误
+func (e *CertExtractor) Extract(ctx context.Context, body []byte, contentType string) (string, error) {
+	return "", errors.New("证书提取器只能从服务器证书提取内容")
+}
+
+// ExtractCertificates 提取服务器证书的字段
+func (e *CertExtractor) ExtractCertificates(certs []*x509.Certificate, now time.Time) (string, error) {
+	if len(certs) == 0 {
+		return "", errors.New("响应中没有服务器证书")
+	}
+	leaf := certs[0]
+
+	lines := make([]string, 0, len(e.fields))
+	for _, field := range e.fields {
+		var value string
+		switch field {
+		case CertFieldDays:
+			value = strconv.Itoa(DaysUntilExpiry(leaf, now))
+		case CertFieldNotAfter:
+			value = leaf.NotAfter.UTC().Format(time.RFC3339)
+		case CertFieldSubject:
+			value = leaf.Subject.String()
+		case CertFieldIssuer:
+			value = leaf.Issuer.String()
+		case CertFieldSANs:
+			value = strings.Join(subjectAltNames(leaf), ", ")
+		}
+		lines = append(lines, field+": "+value)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// DaysUntilExpiry 返回证书在 now 之后的剩余有效天数，不足一天的部分舍去，已过期时为负数
+func DaysUntilExpiry(cert *x509.Certificate, now time.Time) int {
+	return int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+}
+
+// subjectAltNames 返回证书的所有备用名称
+func subjectAltNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
@@ -0,0 +1,77 @@
+package extractor
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertExtractor_ExtractCertificates(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "example.com"},
+		Issuer:      pkix.Name{CommonName: "Test CA", Organization: []string{"Test"}},
+		NotAfter:    now.Add(20*24*time.Hour + time.Hour),
+		DNSNames:    []string{"example.com", "www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	t.Run("默认字段", func(t *testing.T) {
+		ext, err := NewCertExtractor("")
+		require.NoError(t, err)
+
+		content, err := ext.ExtractCertificates([]*x509.Certificate{cert}, now)
+		require.NoError(t, err)
+		assert.Equal(t, "not_after: 2026-01-21T01:00:00Z\nissuer: CN=Test CA,O=Test\nsans: example.com, www.example.com, 127.0.0.1", content)
+	})
+
+	t.Run("指定字段和顺序", func(t *testing.T) {
+		ext, err := NewCertExtractor("not_after, subject")
+		require.NoError(t, err)
+
+		content, err := ext.ExtractCertificates([]*x509.Certificate{cert}, now)
+		require.NoError(t, err)
+		assert.Equal(t, "not_after: 2026-01-21T01:00:00Z\nsubject: CN=example.com", content)
+	})
+
+	t.Run("没有证书", func(t *testing.T) {
+		ext, err := NewCertExtractor("")
+		require.NoError(t, err)
+
+		_, err = ext.ExtractCertificates(nil, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("不处理响应体", func(t *testing.T) {
+		ext, err := NewCertExtractor("")
+		require.NoError(t, err)
+
+		_, err = ext.Extract(context.Background(), []byte("ok"), "text/plain")
+		assert.Error(t, err)
+	})
+}
+
+func TestDaysUntilExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		want     int
+	}{
+		{name: "不足一天", notAfter: now.Add(23 * time.Hour), want: 0},
+		{name: "剩余多天", notAfter: now.Add(14*24*time.Hour + time.Minute), want: 14},
+		{name: "已过期", notAfter: now.Add(-time.Hour), want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DaysUntilExpiry(&x509.Certificate{NotAfter: tt.notAfter}, now))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package extractor
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 
@@ -21,7 +22,7 @@ func NewCSSExtractor(selector string) *CSSExtractor {
 }
 
 // Extract 使用CSS选择器提取内容
-func (e *CSSExtractor) Extract(body []byte, contentType string) (string, error) {
+func (e *CSSExtractor) Extract(_ context.Context, body []byte, contentType string) (string, error) {
 	// 解析HTML
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
 	if err != nil {
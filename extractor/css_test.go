@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -87,7 +88,7 @@ func TestCSSExtractor_Extract(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			extractor := NewCSSExtractor(tt.selector)
-			result, err := extractor.Extract([]byte(tt.html), "text/html")
+			result, err := extractor.Extract(context.Background(), []byte(tt.html), "text/html")
 
 			if tt.wantErr {
 				require.Error(t, err)
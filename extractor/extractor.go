@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/zx06/apiwatch/models"
@@ -8,8 +9,8 @@ import (
 
 // Extractor 内容提取器接口
 type Extractor interface {
-	// Extract 从响应中提取内容
-	Extract(body []byte, contentType string) (string, error)
+	// Extract 从响应中提取内容，ctx 取消时尽快返回
+	Extract(ctx context.Context, body []byte, contentType string) (string, error)
 }
 
 // Factory 提取器工厂
@@ -29,6 +30,8 @@ func (f *Factory) Create(extractorType models.ExtractorType, expr string) (Extra
 		return NewRegexExtractor(expr)
 	case models.ExtractorJSON:
 		return NewJSONExtractor(expr), nil
+	case models.ExtractorCert:
+		return NewCertExtractor(expr)
 	default:
 		return nil, fmt.Errorf("不支持的提取器类型: %s", extractorType)
 	}
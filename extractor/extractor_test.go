@@ -35,6 +35,18 @@ func TestFactory_Create(t *testing.T) {
 			expr:          "data.value",
 			wantErr:       false,
 		},
+		{
+			name:          "创建证书提取器",
+			extractorType: models.ExtractorCert,
+			expr:          "",
+			wantErr:       false,
+		},
+		{
+			name:          "无效的证书字段",
+			extractorType: models.ExtractorCert,
+			expr:          "days,serial",
+			wantErr:       true,
+		},
 		{
 			name:          "无效的正则表达式",
 			extractorType: models.ExtractorRegex,
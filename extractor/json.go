@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/tidwall/gjson"
@@ -19,7 +20,7 @@ func NewJSONExtractor(path string) *JSONExtractor {
 }
 
 // Extract 使用JSON路径提取内容
-func (e *JSONExtractor) Extract(body []byte, contentType string) (string, error) {
+func (e *JSONExtractor) Extract(_ context.Context, body []byte, contentType string) (string, error) {
 	// 验证JSON格式
 	if !gjson.ValidBytes(body) {
 		return "", fmt.Errorf("无效的JSON格式")
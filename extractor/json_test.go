@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -126,7 +127,7 @@ func TestJSONExtractor_Extract(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			extractor := NewJSONExtractor(tt.path)
-			result, err := extractor.Extract([]byte(tt.json), "application/json")
+			result, err := extractor.Extract(context.Background(), []byte(tt.json), "application/json")
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -198,7 +199,7 @@ func TestJSONExtractor_ComplexScenarios(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			extractor := NewJSONExtractor(tt.path)
-			result, err := extractor.Extract([]byte(complexJSON), "application/json")
+			result, err := extractor.Extract(context.Background(), []byte(complexJSON), "application/json")
 			require.NoError(t, err)
 			assert.Equal(t, tt.want, result)
 		})
@@ -29,9 +29,10 @@ func NewRegexExtractor(pattern string) (*RegexExtractor, error) {
 }
 
 // Extract 使用正则表达式提取内容
-func (e *RegexExtractor) Extract(body []byte, contentType string) (string, error) {
+func (e *RegexExtractor) Extract(ctx context.Context, body []byte, contentType string) (string, error) {
 	// 使用context实现超时控制
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
 	defer cancel()
 
 	// 在goroutine中执行正则匹配
@@ -64,6 +65,9 @@ func (e *RegexExtractor) Extract(body []byte, contentType string) (string, error
 	// 等待结果或超时
 	select {
 	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return "", fmt.Errorf("内容提取已取消: %w", err)
+		}
 		return "", fmt.Errorf("正则表达式匹配超时（可能存在ReDoS风险）")
 	case err := <-errCh:
 		return "", err
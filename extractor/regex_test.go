@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -110,7 +111,7 @@ func TestRegexExtractor_Extract(t *testing.T) {
 			extractor, err := NewRegexExtractor(tt.pattern)
 			require.NoError(t, err)
 
-			result, err := extractor.Extract([]byte(tt.text), "text/plain")
+			result, err := extractor.Extract(context.Background(), []byte(tt.text), "text/plain")
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -135,7 +136,7 @@ func TestRegexExtractor_Timeout(t *testing.T) {
 	// 创建一个会导致大量回溯的输入
 	text := strings.Repeat("a", 25) + "c" // 故意不匹配
 
-	result, err := extractor.Extract([]byte(text), "text/plain")
+	result, err := extractor.Extract(context.Background(), []byte(text), "text/plain")
 
 	// 应该返回未匹配错误，而不是超时
 	// 因为Go的regexp引擎使用了优化算法，不会出现灾难性回溯
@@ -0,0 +1,233 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthType 认证方式
+type AuthType string
+
+const (
+	AuthBasic  AuthType = "basic"
+	AuthBearer AuthType = "bearer"
+	AuthAPIKey AuthType = "api_key"
+	AuthOAuth2 AuthType = "oauth2"
+)
+
+// tokenExpiryDelta 提前刷新OAuth2令牌的时间，避免令牌在请求途中过期；
+// 有效期较短的令牌最多提前一半有效期刷新
+const tokenExpiryDelta = 30 * time.Second
+
+// Auth 请求的认证方式，只使用 Type 对应的字段
+type Auth struct {
+	Type AuthType
+
+	// Username 和 Password 用于HTTP Basic认证
+	Username string
+	Password string
+
+	// Token 用于Bearer认证
+	Token string
+
+	// Name 和 Value 为API Key的请求头或查询参数名称和值，InQuery 为true时作为查询参数发送
+	Name    string
+	Value   string
+	InQuery bool
+
+	// TokenURL、ClientID、ClientSecret 和 Scopes 用于OAuth2客户端凭据模式
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// authorizeURL 返回加入API Key查询参数后的请求地址
+func (a *Auth) authorizeURL(rawURL string) (string, error) {
+	if a == nil || a.Type != AuthAPIKey || !a.InQuery {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set(a.Name, a.Value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// authorize 为请求设置认证信息，OAuth2认证时按需获取令牌
+func (f *HTTPFetcher) authorize(ctx context.Context, httpReq *http.Request, req *Request) error {
+	auth := req.Auth
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case AuthBasic:
+		httpReq.SetBasicAuth(auth.Username, auth.Password)
+	case AuthBearer:
+		httpReq.Header.Set("Authorization", "Bearer "+auth.Token)
+	case AuthAPIKey:
+		if !auth.InQuery {
+			httpReq.Header.Set(auth.Name, auth.Value)
+		}
+	case AuthOAuth2:
+		token, err := f.tokenSource(auth).token(ctx, f, req)
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Authorization", token.tokenType+" "+token.accessToken)
+	default:
+		return &requestError{err: fmt.Errorf("不支持的认证方式: %s", auth.Type)}
+	}
+	return nil
+}
+
+// oauth2Token OAuth2访问令牌
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+
+	// expires 过期时间，为零值时不过期（直到服务器拒绝）
+	expires time.Time
+}
+
+// tokenSource 缓存一组客户端凭据的访问令牌，过期前自动重新获取
+type tokenSource struct {
+	mu      sync.Mutex
+	current *oauth2Token
+	now     func() time.Time
+}
+
+// tokenKey 区分不同客户端凭据的令牌
+func tokenKey(auth *Auth) string {
+	return strings.Join([]string{auth.TokenURL, auth.ClientID, auth.ClientSecret, strings.Join(auth.Scopes, " ")}, "\x00")
+}
+
+// tokenSource 返回客户端凭据对应的令牌缓存，相同凭据的规则共享令牌
+func (f *HTTPFetcher) tokenSource(auth *Auth) *tokenSource {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := tokenKey(auth)
+	source, ok := f.tokens[key]
+	if !ok {
+		source = &tokenSource{now: time.Now}
+		if f.tokens == nil {
+			f.tokens = make(map[string]*tokenSource)
+		}
+		f.tokens[key] = source
+	}
+	return source
+}
+
+// token 返回有效的访问令牌，没有令牌或即将过期时从令牌地址获取
+func (s *tokenSource) token(ctx context.Context, f *HTTPFetcher, req *Request) (*oauth2Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && (s.current.expires.IsZero() || s.now().Before(s.current.expires)) {
+		return s.current, nil
+	}
+
+	token, lifetime, err := f.requestToken(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if lifetime > 0 {
+		token.expires = s.now().Add(tokenLifetime(lifetime))
+	}
+	s.current = token
+	return token, nil
+}
+
+// tokenLifetime 返回令牌在缓存中使用的时长，避免有效期不超过 tokenExpiryDelta 的令牌
+// 刚获取就被视为过期，导致每次请求都重新获取令牌
+func tokenLifetime(lifetime time.Duration) time.Duration {
+	return lifetime - min(tokenExpiryDelta, lifetime/2)
+}
+
+// invalidate 丢弃缓存的令牌，下次请求时重新获取
+func (s *tokenSource) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = nil
+}
+
+// requestToken 使用客户端凭据模式从令牌地址获取访问令牌，返回令牌和有效期（未知时为0）
+//
+// 客户端凭据通过HTTP Basic认证发送。
+func (f *HTTPFetcher) requestToken(ctx context.Context, req *Request) (*oauth2Token, time.Duration, error) {
+	auth := req.Auth
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, 0, &requestError{err: fmt.Errorf("无效的令牌地址: %w", err)}
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", userAgent)
+	httpReq.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	// 令牌请求与检查请求使用相同的代理
+	client, err := f.clientFor(req)
+	if err != nil {
+		return nil, 0, &requestError{err: err}
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取OAuth2令牌失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取OAuth2令牌失败: %w", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode < 300 {
+		return nil, 0, fmt.Errorf("解析OAuth2令牌失败: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		if result.Error != "" {
+			reason := strings.TrimSpace(result.Error + " " + result.ErrorDescription)
+			return nil, 0, fmt.Errorf("获取OAuth2令牌失败: %s: %w", reason, statusErr)
+		}
+		return nil, 0, fmt.Errorf("获取OAuth2令牌失败: %w", statusErr)
+	}
+	if result.AccessToken == "" {
+		return nil, 0, errors.New("获取OAuth2令牌失败: 响应中没有 access_token")
+	}
+
+	token := &oauth2Token{accessToken: result.AccessToken, tokenType: "Bearer"}
+	// 令牌类型不区分大小写，统一使用 Bearer
+	if result.TokenType != "" && !strings.EqualFold(result.TokenType, "bearer") {
+		token.tokenType = result.TokenType
+	}
+	return token, time.Duration(result.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,169 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFetcher_Auth(t *testing.T) {
+	var received atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Store(r.Clone(context.Background()))
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	fetch := func(t *testing.T, auth *Auth) *http.Request {
+		t.Helper()
+		req := &Request{
+			URL:     server.URL + "/data?page=1",
+			Method:  http.MethodGet,
+			Headers: map[string]string{"Authorization": "Bearer old"},
+			Auth:    auth,
+		}
+		_, err := NewHTTPFetcher().Fetch(context.Background(), req)
+		require.NoError(t, err)
+		return received.Load().(*http.Request)
+	}
+
+	t.Run("Basic认证", func(t *testing.T) {
+		r := fetch(t, &Auth{Type: AuthBasic, Username: "user", Password: "pass"})
+		username, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+	})
+
+	t.Run("Bearer认证覆盖请求头", func(t *testing.T) {
+		r := fetch(t, &Auth{Type: AuthBearer, Token: "token-1"})
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+	})
+
+	t.Run("请求头中的API Key", func(t *testing.T) {
+		r := fetch(t, &Auth{Type: AuthAPIKey, Name: "X-API-Key", Value: "key-1"})
+		assert.Equal(t, "key-1", r.Header.Get("X-API-Key"))
+		assert.Equal(t, "page=1", r.URL.RawQuery)
+	})
+
+	t.Run("查询参数中的API Key", func(t *testing.T) {
+		r := fetch(t, &Auth{Type: AuthAPIKey, Name: "api_key", Value: "key 1", InQuery: true})
+		assert.Equal(t, "key 1", r.URL.Query().Get("api_key"))
+		assert.Equal(t, "1", r.URL.Query().Get("page"))
+		assert.Empty(t, r.Header.Get("api_key"))
+	})
+}
+
+func TestTokenLifetime(t *testing.T) {
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		want     time.Duration
+	}{
+		{name: "提前30秒刷新", lifetime: time.Hour, want: time.Hour - 30*time.Second},
+		{name: "有效期等于提前量", lifetime: 30 * time.Second, want: 15 * time.Second},
+		{name: "有效期短于提前量", lifetime: 10 * time.Second, want: 5 * time.Second},
+		{name: "有效期1秒", lifetime: time.Second, want: 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenLifetime(tt.lifetime))
+		})
+	}
+}
+
+func TestHTTPFetcher_OAuth2(t *testing.T) {
+	var issued atomic.Int32
+	var revoked atomic.Bool
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		require.NoError(t, r.ParseForm())
+		if clientID != "client" || secret != "secret" || r.PostForm.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token-` + string(rune('0'+n)) + `","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	var lastAuth atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastAuth.Store(r.Header.Get("Authorization"))
+		if revoked.Load() && r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	auth := &Auth{
+		Type:         AuthOAuth2,
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}
+	f := NewHTTPFetcher()
+	req := &Request{URL: server.URL, Method: http.MethodGet, Auth: auth, Retry: RetryPolicy{MaxAttempts: 1}}
+
+	t.Run("获取令牌并在有效期内复用", func(t *testing.T) {
+		for range 3 {
+			_, err := f.Fetch(context.Background(), req)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, "Bearer token-1", lastAuth.Load())
+		assert.Equal(t, int32(1), issued.Load())
+	})
+
+	t.Run("令牌过期前重新获取", func(t *testing.T) {
+		source := f.tokenSource(auth)
+		source.now = func() time.Time { return time.Now().Add(time.Hour - 10*time.Second) }
+		defer func() { source.now = time.Now }()
+
+		_, err := f.Fetch(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-2", lastAuth.Load())
+	})
+
+	t.Run("令牌被拒绝后重新获取", func(t *testing.T) {
+		f := NewHTTPFetcher()
+		_, err := f.Fetch(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-3", lastAuth.Load())
+
+		revoked.Store(true)
+		defer revoked.Store(false)
+		f.tokenSource(auth).mu.Lock()
+		f.tokenSource(auth).current.accessToken = "token-1"
+		f.tokenSource(auth).mu.Unlock()
+
+		_, err = f.Fetch(context.Background(), req)
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+
+		_, err = f.Fetch(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer token-4", lastAuth.Load())
+	})
+
+	t.Run("客户端凭据无效", func(t *testing.T) {
+		bad := *req
+		bad.Auth = &Auth{Type: AuthOAuth2, TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "wrong"}
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &bad)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid_client")
+	})
+}
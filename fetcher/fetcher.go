@@ -1,10 +1,15 @@
 package fetcher
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,6 +19,24 @@ type Request struct {
 	Method  string
 	Headers map[string]string
 	Body    string
+
+	// Retry 超时和重试策略，零值使用默认策略
+	Retry RetryPolicy
+
+	// Robots 为true时在启用robots.txt检查的情况下遵守目标站点的robots.txt（用于抓取网页的规则）
+	Robots bool
+
+	// Proxy 该请求使用的代理地址，为空时使用默认代理，为 ProxyDirect 时不使用代理
+	Proxy string
+
+	// Auth 认证方式，为nil时不认证
+	Auth *Auth
+
+	// TLS TLS设置，为nil时使用默认设置
+	TLS *TLSConfig
+
+	// AnyStatus 为true时任何状态码都返回响应，不按状态码重试（用于只检查服务器证书的规则）
+	AnyStatus bool
 }
 
 // Response HTTP响应
@@ -21,89 +44,195 @@ type Response struct {
 	Body        []byte
 	ContentType string
 	StatusCode  int
+
+	// Certificates 服务器发送的证书链，第一个为服务器证书；非HTTPS请求时为nil
+	Certificates []*x509.Certificate
 }
 
 // Fetcher HTTP客户端接口
 type Fetcher interface {
-	// Fetch 发送HTTP请求并获取响应
-	Fetch(req *Request) (*Response, error)
+	// Fetch 发送HTTP请求并获取响应，ctx 取消时立即中止请求和重试等待
+	Fetch(ctx context.Context, req *Request) (*Response, error)
 }
 
+// userAgent 请求默认使用的User-Agent
+const userAgent = robotsAgent + "/1.0"
+
 // HTTPFetcher HTTP客户端实现
 type HTTPFetcher struct {
+	// client 使用默认代理和默认TLS设置的客户端
 	client *http.Client
+
+	// proxy 默认的代理选择
+	proxy proxyFunc
+
+	mu sync.Mutex
+
+	// clients 按请求单独设置的代理和TLS缓存的客户端
+	clients map[clientKey]*http.Client
+
+	// tokens 按OAuth2客户端凭据缓存的访问令牌
+	tokens map[string]*tokenSource
+
+	// secrets 解析 ${secret:name} 占位符，为nil时只能使用环境变量占位符
+	secrets SecretLookup
+
+	// limiter 按主机限制请求速率和并发数，为nil时不限制
+	limiter *HostLimiter
+
+	// robots 不为nil时检查robots.txt
+	robots *robotsCache
 }
 
 // NewHTTPFetcher 创建HTTP客户端
 func NewHTTPFetcher() *HTTPFetcher {
 	return &HTTPFetcher{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				// 最多允许10次重定向
-				if len(via) >= 10 {
-					return fmt.Errorf("重定向次数过多")
-				}
-				return nil
-			},
-		},
+		client: newClient(http.ProxyFromEnvironment, nil),
+		proxy:  http.ProxyFromEnvironment,
+	}
+}
+
+// SetHostLimiter 设置按主机的请求限制，需要在开始请求之前调用
+func (f *HTTPFetcher) SetHostLimiter(limiter *HostLimiter) {
+	f.limiter = limiter
+}
+
+// SetRespectRobots 设置是否对 Robots 为true的请求检查robots.txt，需要在开始请求之前调用
+func (f *HTTPFetcher) SetRespectRobots(enabled bool) {
+	if enabled {
+		f.robots = newRobotsCache()
+	} else {
+		f.robots = nil
 	}
 }
 
 // Fetch 发送HTTP请求并获取响应
-func (f *HTTPFetcher) Fetch(req *Request) (*Response, error) {
-	var lastErr error
+//
+// 按请求的重试策略重试网络错误和可重试的状态码，每次请求单独计算超时。
+// 请求中的 ${env:NAME} 和 ${secret:name} 占位符在发送前替换。
+func (f *HTTPFetcher) Fetch(ctx context.Context, req *Request) (*Response, error) {
+	policy := req.Retry.withDefaults()
 
-	// 重试机制：最多3次，指数退避
-	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			// 指数退避：1s, 2s, 4s
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			time.Sleep(backoff)
+	req, err := f.resolve(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// robots.txt禁止访问时不发送请求
+	if req.Robots && f.robots != nil {
+		if err := f.checkRobots(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	var lastErr error
+	var wait time.Duration
+	attempts := 0
+	for attempts < policy.MaxAttempts {
+		if attempts > 0 {
+			if err := sleep(ctx, wait); err != nil {
+				return nil, fmt.Errorf("请求已取消: %w", err)
+			}
 		}
+		attempts++
 
-		resp, err := f.doRequest(req)
+		resp, err := f.doRequest(ctx, req, policy.Timeout)
 		if err == nil {
 			return resp, nil
 		}
 
+		// 请求被取消时不再重试
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("请求已取消: %w", ctx.Err())
+		}
+
 		lastErr = err
+
+		var retry bool
+		if wait, retry = policy.retryAfter(attempts, err); !retry {
+			break
+		}
 	}
 
-	return nil, fmt.Errorf("请求失败（已重试3次）: %w", lastErr)
+	if attempts == 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("请求失败（已重试%d次）: %w", attempts, lastErr)
 }
 
-// doRequest 执行单次HTTP请求
-func (f *HTTPFetcher) doRequest(req *Request) (*Response, error) {
+// doRequest 执行单次HTTP请求，设置了主机限制时先等待限制允许
+func (f *HTTPFetcher) doRequest(ctx context.Context, req *Request, timeout time.Duration) (*Response, error) {
+	if f.limiter != nil {
+		if u, err := url.Parse(req.URL); err == nil && u.Host != "" {
+			release, err := f.limiter.Acquire(ctx, u.Hostname())
+			if err != nil {
+				return nil, fmt.Errorf("等待主机请求限制时取消: %w", err)
+			}
+			defer release()
+		}
+	}
+
+	client, err := f.clientFor(req)
+	if err != nil {
+		return nil, &requestError{err: err}
+	}
+
+	// 超时从获得主机限制之后开始计算
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// 创建HTTP请求
 	var bodyReader io.Reader
 	if req.Body != "" {
 		bodyReader = strings.NewReader(req.Body)
 	}
 
-	httpReq, err := http.NewRequest(req.Method, req.URL, bodyReader)
+	target, err := req.Auth.authorizeURL(req.URL)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, &requestError{err: err}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target, bodyReader)
+	if err != nil {
+		return nil, &requestError{err: err}
 	}
 
 	// 设置默认User-Agent
-	httpReq.Header.Set("User-Agent", "URL-Monitor/1.0")
+	httpReq.Header.Set("User-Agent", userAgent)
 
 	// 设置自定义请求头
 	for key, value := range req.Headers {
 		httpReq.Header.Set(key, value)
 	}
 
+	// 认证信息覆盖自定义请求头中的同名项
+	if err := f.authorize(ctx, httpReq, req); err != nil {
+		return nil, err
+	}
+
 	// 发送请求
-	httpResp, err := f.client.Do(httpReq)
+	httpResp, err := client.Do(httpReq)
 	if err != nil {
+		// 错误信息会显示给用户，去掉地址中可能包含密钥的查询参数和密码
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(urlErr.URL)
+		}
 		return nil, fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	// 检查状态码
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP错误: %d %s", httpResp.StatusCode, httpResp.Status)
+	if !req.AnyStatus && (httpResp.StatusCode < 200 || httpResp.StatusCode >= 300) {
+		// 令牌可能已被撤销，下次请求重新获取
+		if httpResp.StatusCode == http.StatusUnauthorized && req.Auth != nil && req.Auth.Type == AuthOAuth2 {
+			f.tokenSource(req.Auth).invalidate()
+		}
+		statusErr := &StatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status}
+		if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(httpResp.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, statusErr
 	}
 
 	// 限制响应大小（最大10MB）
@@ -125,9 +254,25 @@ func (f *HTTPFetcher) doRequest(req *Request) (*Response, error) {
 		}
 	}
 
-	return &Response{
+	resp := &Response{
 		Body:        body,
 		ContentType: httpResp.Header.Get("Content-Type"),
 		StatusCode:  httpResp.StatusCode,
-	}, nil
+	}
+	if httpResp.TLS != nil {
+		resp.Certificates = httpResp.TLS.PeerCertificates
+	}
+	return resp, nil
+}
+
+// redactURL 去掉地址中的查询参数和密码
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "..."
+	}
+	return u.Redacted()
 }
@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -15,7 +16,7 @@ func TestNewHTTPFetcher(t *testing.T) {
 	fetcher := NewHTTPFetcher()
 	assert.NotNil(t, fetcher)
 	assert.NotNil(t, fetcher.client)
-	assert.Equal(t, 30*time.Second, fetcher.client.Timeout)
+	assert.Equal(t, 30*time.Second, Request{}.Retry.withDefaults().Timeout)
 }
 
 func TestHTTPFetcher_Fetch_Success(t *testing.T) {
@@ -38,7 +39,7 @@ func TestHTTPFetcher_Fetch_Success(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	resp, err := fetcher.Fetch(req)
+	resp, err := fetcher.Fetch(context.Background(), req)
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Equal(t, "text/html", resp.ContentType)
@@ -68,7 +69,7 @@ func TestHTTPFetcher_Fetch_POST_WithBody(t *testing.T) {
 		Body:   expectedBody,
 	}
 
-	resp, err := fetcher.Fetch(req)
+	resp, err := fetcher.Fetch(context.Background(), req)
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Contains(t, string(resp.Body), "success")
@@ -96,7 +97,7 @@ func TestHTTPFetcher_Fetch_CustomHeaders(t *testing.T) {
 		},
 	}
 
-	resp, err := fetcher.Fetch(req)
+	resp, err := fetcher.Fetch(context.Background(), req)
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
@@ -113,7 +114,7 @@ func TestHTTPFetcher_Fetch_HTTPError(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	_, err := fetcher.Fetch(req)
+	_, err := fetcher.Fetch(context.Background(), req)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "HTTP错误: 404")
 }
@@ -140,7 +141,7 @@ func TestHTTPFetcher_Fetch_Retry(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	resp, err := fetcher.Fetch(req)
+	resp, err := fetcher.Fetch(context.Background(), req)
 	require.NoError(t, err)
 	assert.Equal(t, 3, attemptCount)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -161,12 +162,39 @@ func TestHTTPFetcher_Fetch_RetryExhausted(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	_, err := fetcher.Fetch(req)
+	_, err := fetcher.Fetch(context.Background(), req)
 	require.Error(t, err)
 	assert.Equal(t, 3, attemptCount)
 	assert.Contains(t, err.Error(), "请求失败（已重试3次）")
 }
 
+func TestHTTPFetcher_Fetch_Canceled(t *testing.T) {
+	attemptCount := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attemptCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher()
+	req := &Request{
+		URL:    server.URL,
+		Method: http.MethodGet,
+	}
+
+	// 在第一次重试等待期间取消
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := fetcher.Fetch(ctx, req)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, attemptCount)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
 func TestHTTPFetcher_Fetch_InvalidURL(t *testing.T) {
 	fetcher := NewHTTPFetcher()
 	req := &Request{
@@ -174,7 +202,7 @@ func TestHTTPFetcher_Fetch_InvalidURL(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	_, err := fetcher.Fetch(req)
+	_, err := fetcher.Fetch(context.Background(), req)
 	require.Error(t, err)
 }
 
@@ -194,7 +222,7 @@ func TestHTTPFetcher_Fetch_BodySizeLimit(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	_, err := fetcher.Fetch(req)
+	_, err := fetcher.Fetch(context.Background(), req)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "响应体过大")
 }
@@ -219,7 +247,7 @@ func TestHTTPFetcher_Fetch_Redirect(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	resp, err := fetcher.Fetch(req)
+	resp, err := fetcher.Fetch(context.Background(), req)
 	require.NoError(t, err)
 	assert.Contains(t, string(resp.Body), "Final destination")
 }
@@ -237,7 +265,7 @@ func TestHTTPFetcher_Fetch_TooManyRedirects(t *testing.T) {
 		Method: http.MethodGet,
 	}
 
-	_, err := fetcher.Fetch(req)
+	_, err := fetcher.Fetch(context.Background(), req)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "重定向")
 }
@@ -265,7 +293,7 @@ func TestHTTPFetcher_Fetch_DifferentMethods(t *testing.T) {
 				Method: method,
 			}
 
-			resp, err := fetcher.Fetch(req)
+			resp, err := fetcher.Fetch(context.Background(), req)
 			require.NoError(t, err)
 			assert.Equal(t, http.StatusOK, resp.StatusCode)
 		})
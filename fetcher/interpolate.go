@@ -0,0 +1,114 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// reservedEnvPrefix 程序自身配置使用的环境变量前缀，这些变量保存访问令牌和密钥存储的密码，
+// 不允许通过 ${env:NAME} 读取，避免规则把它们发送到任意地址
+const reservedEnvPrefix = "APIWATCH_"
+
+// SecretLookup 按名称查找密钥的值
+type SecretLookup interface {
+	Lookup(name string) (string, error)
+}
+
+// SetSecrets 设置解析 ${secret:name} 占位符使用的密钥存储，需要在开始请求之前调用
+func (f *HTTPFetcher) SetSecrets(secrets SecretLookup) {
+	f.secrets = secrets
+}
+
+// expand 替换字符串中的 ${env:NAME} 和 ${secret:name} 占位符
+//
+// 其他 ${...} 原样保留；环境变量未设置、使用保留前缀 APIWATCH_ 或密钥不存在时返回错误。
+func (f *HTTPFetcher) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:start])
+		s = s[start:]
+
+		kind, rest, ok := strings.Cut(s[2:], ":")
+		if !ok || (kind != "env" && kind != "secret") {
+			b.WriteString("${")
+			s = s[2:]
+			continue
+		}
+		name, rest, ok := strings.Cut(rest, "}")
+		if !ok || name == "" {
+			return "", fmt.Errorf("占位符不完整: %q", s)
+		}
+
+		value, err := f.lookup(kind, name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		s = rest
+	}
+}
+
+// lookup 返回环境变量或密钥的值
+func (f *HTTPFetcher) lookup(kind, name string) (string, error) {
+	if kind == "env" {
+		if strings.HasPrefix(strings.ToUpper(name), reservedEnvPrefix) {
+			return "", fmt.Errorf("不允许读取程序配置使用的环境变量: %s", name)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("环境变量未设置: %s", name)
+		}
+		return value, nil
+	}
+
+	if f.secrets == nil {
+		return "", errors.New("未配置密钥存储，无法解析密钥: " + name)
+	}
+	return f.secrets.Lookup(name)
+}
+
+// resolve 返回替换了占位符的请求副本，占位符可以出现在地址、请求头的值、请求体和认证信息中
+func (f *HTTPFetcher) resolve(req *Request) (*Request, error) {
+	resolved := *req
+
+	var err error
+	expand := func(s *string) {
+		if err == nil {
+			*s, err = f.expand(*s)
+		}
+	}
+
+	expand(&resolved.URL)
+	expand(&resolved.Body)
+	if len(req.Headers) > 0 {
+		resolved.Headers = maps.Clone(req.Headers)
+		for key, value := range resolved.Headers {
+			expand(&value)
+			resolved.Headers[key] = value
+		}
+	}
+	if req.Auth != nil {
+		auth := *req.Auth
+		for _, s := range []*string{&auth.Username, &auth.Password, &auth.Token, &auth.Value, &auth.TokenURL, &auth.ClientID, &auth.ClientSecret} {
+			expand(s)
+		}
+		resolved.Auth = &auth
+	}
+
+	if err != nil {
+		return nil, &requestError{err: fmt.Errorf("解析占位符失败: %w", err)}
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,124 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapSecrets 使用map保存的密钥
+type mapSecrets map[string]string
+
+func (m mapSecrets) Lookup(name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", errors.New("密钥不存在: " + name)
+	}
+	return value, nil
+}
+
+func TestHTTPFetcher_Expand(t *testing.T) {
+	t.Setenv("WATCH_TEST_TOKEN", "env-token")
+	f := NewHTTPFetcher()
+	f.SetSecrets(mapSecrets{"db": "secret-value"})
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"没有占位符", "plain text", "plain text"},
+		{"环境变量", "Bearer ${env:WATCH_TEST_TOKEN}", "Bearer env-token"},
+		{"密钥", `{"password":"${secret:db}"}`, `{"password":"secret-value"}`},
+		{"多个占位符", "${env:WATCH_TEST_TOKEN}:${secret:db}", "env-token:secret-value"},
+		{"保留其他占位符", "${name} ${other:x} $${", "${name} ${other:x} $${"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.expand(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("无法解析的占位符", func(t *testing.T) {
+		for _, in := range []string{"${env:WATCH_TEST_MISSING}", "${secret:missing}", "${secret:db"} {
+			_, err := f.expand(in)
+			assert.Error(t, err, in)
+		}
+
+		_, err := NewHTTPFetcher().expand("${secret:db}")
+		assert.ErrorContains(t, err, "未配置密钥存储")
+	})
+
+	t.Run("不允许读取程序配置使用的环境变量", func(t *testing.T) {
+		t.Setenv("APIWATCH_TOKEN", "api-token")
+		t.Setenv("APIWATCH_SECRETS_PASSPHRASE", "passphrase")
+		for _, in := range []string{"${env:APIWATCH_TOKEN}", "${env:APIWATCH_SECRETS_PASSPHRASE}", "${env:apiwatch_token}"} {
+			got, err := f.expand(in)
+			assert.ErrorContains(t, err, "不允许读取", in)
+			assert.Empty(t, got)
+		}
+	})
+}
+
+func TestHTTPFetcher_Fetch_Placeholders(t *testing.T) {
+	t.Setenv("WATCH_TEST_TOKEN", "env-token")
+
+	var received atomic.Value
+	var body atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Store(r.Clone(context.Background()))
+		data, _ := io.ReadAll(r.Body)
+		body.Store(string(data))
+	}))
+	defer server.Close()
+
+	f := NewHTTPFetcher()
+	f.SetSecrets(mapSecrets{"password": "p@ss", "key": "k1"})
+
+	req := &Request{
+		URL:     server.URL + "/data?key=${secret:key}",
+		Method:  http.MethodPost,
+		Headers: map[string]string{"X-Token": "${env:WATCH_TEST_TOKEN}"},
+		Body:    `{"password":"${secret:password}"}`,
+		Auth:    &Auth{Type: AuthBasic, Username: "user", Password: "${secret:password}"},
+	}
+	_, err := f.Fetch(context.Background(), req)
+	require.NoError(t, err)
+
+	r := received.Load().(*http.Request)
+	assert.Equal(t, "k1", r.URL.Query().Get("key"))
+	assert.Equal(t, "env-token", r.Header.Get("X-Token"))
+	assert.Equal(t, `{"password":"p@ss"}`, body.Load())
+	_, password, _ := r.BasicAuth()
+	assert.Equal(t, "p@ss", password)
+
+	assert.Equal(t, "${env:WATCH_TEST_TOKEN}", req.Headers["X-Token"], "不修改原请求")
+	assert.Equal(t, "${secret:password}", req.Auth.Password)
+
+	t.Run("密钥不存在时不发送请求", func(t *testing.T) {
+		received.Store((*http.Request)(nil))
+		_, err := f.Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet, Body: "${secret:missing}"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing")
+		assert.Nil(t, received.Load())
+	})
+
+	t.Run("错误信息中不包含查询参数", func(t *testing.T) {
+		_, err := f.Fetch(context.Background(), &Request{
+			URL:    "http://127.0.0.1:1/data?key=${secret:key}",
+			Method: http.MethodGet,
+			Retry:  RetryPolicy{MaxAttempts: 1},
+		})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "k1")
+	})
+}
@@ -0,0 +1,139 @@
+package fetcher
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimit 对同一主机的请求限制，字段为0表示不限制
+type HostLimit struct {
+	// Rate 每秒允许的请求数（令牌桶的填充速率）
+	Rate float64
+
+	// Burst 令牌桶容量，即允许的突发请求数，为0时为 Rate 向上取整（至少为1）
+	Burst int
+
+	// MaxConcurrent 同时进行的请求数上限
+	MaxConcurrent int
+}
+
+// HostLimiter 按主机限制请求速率和并发数
+//
+// 每个主机使用独立的令牌桶和并发槽，同一主机的所有规则共享这些限制。
+type HostLimiter struct {
+	defaults HostLimit
+	hosts    map[string]HostLimit
+
+	mu     sync.Mutex
+	states map[string]*hostState
+
+	now func() time.Time
+}
+
+// hostState 一个主机的令牌桶和并发槽
+type hostState struct {
+	limit  HostLimit
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// slots 并发槽，不限制并发时为nil
+	slots chan struct{}
+}
+
+// NewHostLimiter 创建主机限制器
+//
+// defaults 用于未单独配置的主机；hosts 按主机名（不含端口，不区分大小写）单独配置。
+func NewHostLimiter(defaults HostLimit, hosts map[string]HostLimit) *HostLimiter {
+	normalized := make(map[string]HostLimit, len(hosts))
+	for host, limit := range hosts {
+		normalized[strings.ToLower(host)] = limit
+	}
+	return &HostLimiter{
+		defaults: defaults,
+		hosts:    normalized,
+		states:   make(map[string]*hostState),
+		now:      time.Now,
+	}
+}
+
+// Acquire 等待主机的并发槽和令牌，返回请求结束后需要调用的释放函数
+//
+// ctx 取消时停止等待并返回错误。
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	state := l.state(strings.ToLower(host))
+
+	release := func() {}
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = func() { <-state.slots }
+	}
+
+	for {
+		wait := l.take(state)
+		if wait <= 0 {
+			return release, nil
+		}
+		if err := sleep(ctx, wait); err != nil {
+			release()
+			return nil, err
+		}
+	}
+}
+
+// state 返回主机的状态，不存在时创建
+func (l *HostLimiter) state(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state, ok := l.states[host]; ok {
+		return state
+	}
+
+	limit, ok := l.hosts[host]
+	if !ok {
+		limit = l.defaults
+	}
+	state := &hostState{limit: limit, last: l.now()}
+	if limit.Rate > 0 {
+		state.burst = float64(limit.Burst)
+		if limit.Burst <= 0 {
+			state.burst = max(math.Ceil(limit.Rate), 1)
+		}
+		state.tokens = state.burst
+	}
+	if limit.MaxConcurrent > 0 {
+		state.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+	l.states[host] = state
+	return state
+}
+
+// take 尝试取出一个令牌，成功时返回0，否则返回需要等待的时间
+func (l *HostLimiter) take(state *hostState) time.Duration {
+	if state.limit.Rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if elapsed := now.Sub(state.last).Seconds(); elapsed > 0 {
+		state.tokens = min(state.burst, state.tokens+elapsed*state.limit.Rate)
+	}
+	state.last = now
+
+	if state.tokens >= 1 {
+		state.tokens--
+		return 0
+	}
+	return time.Duration((1 - state.tokens) / state.limit.Rate * float64(time.Second))
+}
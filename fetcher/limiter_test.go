@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLimiter(t *testing.T) {
+	t.Run("令牌桶限制速率", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+		limiter := NewHostLimiter(HostLimit{}, map[string]HostLimit{
+			"API.example.com": {Rate: 2, Burst: 2},
+		})
+		limiter.now = func() time.Time { return now }
+
+		state := limiter.state("api.example.com")
+		assert.Zero(t, limiter.take(state))
+		assert.Zero(t, limiter.take(state))
+		assert.Equal(t, 500*time.Millisecond, limiter.take(state), "令牌用完后按速率等待")
+
+		now = now.Add(500 * time.Millisecond)
+		assert.Zero(t, limiter.take(state))
+
+		other := limiter.state("other.example.com")
+		for range 10 {
+			assert.Zero(t, limiter.take(other), "未配置的主机使用默认值（不限制）")
+		}
+	})
+
+	t.Run("限制同一主机的并发数", func(t *testing.T) {
+		limiter := NewHostLimiter(HostLimit{MaxConcurrent: 1}, nil)
+
+		release, err := limiter.Acquire(context.Background(), "example.com")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = limiter.Acquire(ctx, "example.com")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		otherRelease, err := limiter.Acquire(context.Background(), "other.example.com")
+		require.NoError(t, err, "不同主机的并发数分别计算")
+		otherRelease()
+
+		release()
+		release, err = limiter.Acquire(context.Background(), "example.com")
+		require.NoError(t, err)
+		release()
+	})
+}
+
+func TestHTTPFetcher_HostLimiter(t *testing.T) {
+	var current, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher()
+	fetcher.SetHostLimiter(NewHostLimiter(HostLimit{MaxConcurrent: 2}, nil))
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+}
@@ -0,0 +1,78 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProxy 创建记录请求的HTTP代理，直接返回固定的响应
+func newTestProxy(t *testing.T) (*httptest.Server, *atomic.Value) {
+	t.Helper()
+	var last atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last.Store(r.Clone(context.Background()))
+		w.Write([]byte("proxied"))
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &last
+}
+
+func TestHTTPFetcher_Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("direct"))
+	}))
+	defer target.Close()
+
+	t.Run("请求单独设置的代理", func(t *testing.T) {
+		proxy, last := newTestProxy(t)
+		f := NewHTTPFetcher()
+
+		proxyURL := "http://user:secret@" + proxy.Listener.Addr().String()
+		resp, err := f.Fetch(context.Background(), &Request{URL: "http://api.example.com/data", Method: http.MethodGet, Proxy: proxyURL})
+		require.NoError(t, err)
+		assert.Equal(t, "proxied", string(resp.Body))
+
+		r := last.Load().(*http.Request)
+		assert.Equal(t, "http://api.example.com/data", r.RequestURI)
+		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+		assert.Equal(t, auth, r.Header.Get("Proxy-Authorization"))
+
+		client, err := f.clientFor(&Request{Proxy: proxyURL})
+		require.NoError(t, err)
+		again, err := f.clientFor(&Request{Proxy: proxyURL})
+		require.NoError(t, err)
+		assert.Same(t, client, again, "相同的代理复用客户端")
+	})
+
+	t.Run("默认代理和不使用代理的请求", func(t *testing.T) {
+		proxy, last := newTestProxy(t)
+		f := NewHTTPFetcher()
+		require.NoError(t, f.SetProxy("http://"+proxy.Listener.Addr().String(), []string{"internal.example.com"}))
+
+		resp, err := f.Fetch(context.Background(), &Request{URL: "http://api.example.com/data", Method: http.MethodGet})
+		require.NoError(t, err)
+		assert.Equal(t, "proxied", string(resp.Body))
+		assert.Equal(t, "api.example.com", last.Load().(*http.Request).Host)
+
+		resp, err = f.Fetch(context.Background(), &Request{URL: target.URL, Method: http.MethodGet, Proxy: ProxyDirect})
+		require.NoError(t, err)
+		assert.Equal(t, "direct", string(resp.Body))
+	})
+
+	t.Run("无效的代理地址", func(t *testing.T) {
+		f := NewHTTPFetcher()
+		assert.Error(t, f.SetProxy("ftp://proxy.example.com", nil))
+
+		_, err := f.Fetch(context.Background(), &Request{URL: target.URL, Method: http.MethodGet, Proxy: "proxy.example.com:3128"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "代理")
+		assert.NotContains(t, err.Error(), "已重试", "代理地址无效时不重试")
+	})
+}
@@ -0,0 +1,173 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultTimeout 默认的单次请求超时时间
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultMaxAttempts 默认最多尝试的次数（包括第一次请求）
+	DefaultMaxAttempts = 3
+
+	// DefaultBackoffBase 和 DefaultBackoffMax 默认的重试退避时间
+	DefaultBackoffBase = time.Second
+	DefaultBackoffMax  = 30 * time.Second
+)
+
+// DefaultRetryStatus 默认需要重试的HTTP状态码
+var DefaultRetryStatus = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy 请求的超时和重试策略，零值字段使用默认值
+type RetryPolicy struct {
+	// Timeout 单次请求的超时时间
+	Timeout time.Duration
+
+	// MaxAttempts 最多尝试的次数（包括第一次请求）
+	MaxAttempts int
+
+	// BackoffBase 和 BackoffMax 为重试的退避时间：第n次重试前等待 BackoffBase*2^(n-1)，不超过 BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+
+	// Jitter 退避时间的随机浮动比例（0到1）
+	Jitter float64
+
+	// RetryStatus 需要重试的HTTP状态码
+	RetryStatus []int
+}
+
+// withDefaults 返回填充默认值后的策略
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultTimeout
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.BackoffBase <= 0 {
+		p.BackoffBase = DefaultBackoffBase
+	}
+	if p.BackoffMax <= 0 {
+		p.BackoffMax = max(DefaultBackoffMax, p.BackoffBase)
+	}
+	if len(p.RetryStatus) == 0 {
+		p.RetryStatus = DefaultRetryStatus
+	}
+	return p
+}
+
+// retryAfter 判断第 attempt 次请求失败后是否重试，返回重试前的等待时间
+//
+// 网络错误总是重试（服务器证书验证失败除外），HTTP错误只重试 RetryStatus 中的状态码。
+// 429和503响应带有 Retry-After 时按其等待，超过 BackoffMax 时不再重试。
+func (p RetryPolicy) retryAfter(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return 0, false
+	}
+
+	// 证书过期或不受信任时重试也不会成功
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return 0, false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if !slices.Contains(p.RetryStatus, statusErr.StatusCode) {
+			return 0, false
+		}
+		if statusErr.RetryAfter > 0 {
+			if statusErr.RetryAfter > p.BackoffMax {
+				return 0, false
+			}
+			return statusErr.RetryAfter, true
+		}
+	}
+
+	return p.backoff(attempt), true
+}
+
+// backoff 返回第 attempt 次请求失败后的退避时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BackoffBase
+	for i := 1; i < attempt && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.BackoffMax)
+
+	if p.Jitter > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return max(delay, 0)
+}
+
+// StatusError 服务器返回了非2xx状态码
+type StatusError struct {
+	StatusCode int
+	Status     string
+
+	// RetryAfter 429和503响应的 Retry-After 头指定的等待时间，未指定时为0
+	RetryAfter time.Duration
+}
+
+// Error 实现error接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP错误: %d %s", e.StatusCode, e.Status)
+}
+
+// requestError 无法创建请求（如URL无效），重试也不会成功
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string { return "创建请求失败: " + e.err.Error() }
+func (e *requestError) Unwrap() error { return e.err }
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和HTTP日期，无效或已过期时返回0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// sleep 等待指定时间，ctx 取消时提前返回错误
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BackoffBase: time.Second, BackoffMax: 5 * time.Second}.withDefaults()
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 4*time.Second, policy.backoff(3))
+	assert.Equal(t, 5*time.Second, policy.backoff(4))
+	assert.Equal(t, 5*time.Second, policy.backoff(50))
+
+	policy.Jitter = 0.5
+	for range 100 {
+		delay := policy.backoff(1)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1500*time.Millisecond)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	assert.Equal(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+	assert.Zero(t, parseRetryAfter("", now))
+}
+
+func TestHTTPFetcher_Fetch_RetryPolicy(t *testing.T) {
+	fast := RetryPolicy{BackoffBase: time.Millisecond, BackoffMax: 100 * time.Millisecond}
+
+	t.Run("不可重试的状态码只请求一次", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet, Retry: fast})
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("自定义可重试状态码和尝试次数", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusConflict)
+		}))
+		defer server.Close()
+
+		policy := fast
+		policy.MaxAttempts = 5
+		policy.RetryStatus = []int{http.StatusConflict}
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet, Retry: policy})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "已重试5次")
+		assert.Equal(t, int32(5), attempts.Load())
+	})
+
+	t.Run("按Retry-After等待", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		policy := fast
+		policy.BackoffMax = 2 * time.Second
+		start := time.Now()
+		resp, err := NewHTTPFetcher().Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet, Retry: policy})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Retry-After超过最大退避时间时不再重试", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet, Retry: fast})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("单次请求超时", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer server.Close()
+
+		policy := fast
+		policy.Timeout = 50 * time.Millisecond
+		policy.MaxAttempts = 2
+		start := time.Now()
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{URL: server.URL, Method: http.MethodGet, Retry: policy})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "已重试2次")
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,233 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots 目标站点的robots.txt禁止访问该地址
+var ErrDisallowedByRobots = errors.New("robots.txt禁止访问")
+
+const (
+	// robotsAgent 匹配robots.txt中 User-agent 的名称
+	robotsAgent = "URL-Monitor"
+
+	// robotsCacheTTL robots.txt的缓存时间
+	robotsCacheTTL = 24 * time.Hour
+
+	// robotsErrorTTL robots.txt暂时无法获取（5xx或网络错误）时缓存结果的时间
+	robotsErrorTTL = 10 * time.Minute
+)
+
+// robotsRule robots.txt中的一条 Allow 或 Disallow 规则
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules 适用于本程序的robots.txt规则
+type robotsRules struct {
+	rules []robotsRule
+}
+
+// parseRobots 解析robots.txt，返回适用于 agent 的规则
+//
+// 存在名称与 agent 相同（不区分大小写）的分组时使用这些分组，否则使用 User-agent 为 * 的分组。
+func parseRobots(data []byte, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+
+	var specific, wildcard []robotsRule
+	hasSpecific := false
+
+	var groupAgents []string
+	inRules := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// 规则之后出现的 User-agent 开始新的分组
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			name := strings.ToLower(value)
+			groupAgents = append(groupAgents, name)
+			if name == agent {
+				hasSpecific = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			// 空的 Disallow 表示允许访问所有地址
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{pattern: value, allow: key == "allow"}
+			for _, name := range groupAgents {
+				switch name {
+				case agent:
+					specific = append(specific, rule)
+				case "*":
+					wildcard = append(wildcard, rule)
+				}
+			}
+		}
+	}
+
+	if hasSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// allowed 检查是否允许访问 path（包含查询参数）
+//
+// 匹配的规则中模式最长的生效，长度相同时 Allow 优先；没有匹配的规则时允许访问。
+func (r *robotsRules) allowed(path string) bool {
+	allow := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !matchRobots(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			longest = n
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// matchRobots 检查路径是否匹配robots.txt的模式，模式中 * 匹配任意字符，结尾的 $ 表示匹配到路径末尾
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		// 锚定的模式中最后一段必须在路径末尾
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// robotsEntry 缓存的robots.txt规则
+type robotsEntry struct {
+	rules *robotsRules
+
+	// err 不为nil时robots.txt暂时无法获取，禁止访问该站点
+	err error
+
+	expires time.Time
+}
+
+// robotsCache 按站点（协议和主机）及请求使用的代理和TLS设置缓存robots.txt规则
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]robotsEntry
+	now     func() time.Time
+}
+
+// newRobotsCache 创建robots.txt缓存
+func newRobotsCache() *robotsCache {
+	return &robotsCache{
+		entries: make(map[string]robotsEntry),
+		now:     time.Now,
+	}
+}
+
+// checkRobots 检查目标站点的robots.txt是否允许访问该地址，不允许时返回 ErrDisallowedByRobots
+//
+// robots.txt不存在（4xx）时允许访问；返回5xx或网络错误时按RFC 9309视为禁止访问，
+// 该结果只缓存 robotsErrorTTL。
+func (f *HTTPFetcher) checkRobots(ctx context.Context, req *Request) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" {
+		// 无效的地址由请求本身报告错误
+		return nil
+	}
+	if u.Path == "/robots.txt" {
+		return nil
+	}
+
+	site := u.Scheme + "://" + u.Host
+	rules, err := f.robotsRules(ctx, site, req)
+	if err != nil {
+		return err
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !rules.allowed(path) {
+		return fmt.Errorf("%w: %s", ErrDisallowedByRobots, req.URL)
+	}
+	return nil
+}
+
+// robotsRules 返回站点的robots.txt规则，缓存过期时使用 req 的代理和TLS设置重新获取
+//
+// robots.txt暂时无法获取时返回 ErrDisallowedByRobots。
+func (f *HTTPFetcher) robotsRules(ctx context.Context, site string, req *Request) (*robotsRules, error) {
+	key := site + "|" + req.Proxy + "|" + req.TLS.key()
+
+	cache := f.robots
+	cache.mu.Lock()
+	entry, ok := cache.entries[key]
+	cache.mu.Unlock()
+	if ok && cache.now().Before(entry.expires) {
+		return entry.rules, entry.err
+	}
+
+	robotsReq := &Request{URL: site + "/robots.txt", Method: http.MethodGet, Proxy: req.Proxy, TLS: req.TLS}
+	resp, err := f.doRequest(ctx, robotsReq, DefaultTimeout)
+	ttl := robotsCacheTTL
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		entry = robotsEntry{rules: parseRobots(resp.Body, robotsAgent)}
+	case errors.As(err, &statusErr) && statusErr.StatusCode < 500:
+		// robots.txt不存在或无权访问，允许访问所有地址
+		entry = robotsEntry{rules: &robotsRules{}}
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	default:
+		entry = robotsEntry{err: fmt.Errorf("%w: 无法获取robots.txt: %w", ErrDisallowedByRobots, err)}
+		ttl = robotsErrorTTL
+	}
+
+	entry.expires = cache.now().Add(ttl)
+	cache.mu.Lock()
+	cache.entries[key] = entry
+	cache.mu.Unlock()
+	return entry.rules, entry.err
+}
@@ -0,0 +1,154 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRobots(t *testing.T) {
+	data := []byte(`# 示例
+User-agent: *
+Disallow: /private/
+Allow: /private/public$
+
+User-agent: Googlebot
+User-agent: URL-Monitor
+Disallow: /search
+Disallow: /*.pdf$
+Allow: /search/about
+`)
+
+	rules := parseRobots(data, robotsAgent)
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/private/data", true}, // 存在专门的分组时不使用 * 分组
+		{"/search", false},
+		{"/search?q=go", false},
+		{"/search/about", true},
+		{"/files/report.pdf", false},
+		{"/files/report.pdf?download=1", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, rules.allowed(tt.path), tt.path)
+	}
+
+	other := parseRobots(data, "OtherBot")
+	assert.False(t, other.allowed("/private/data"))
+	assert.True(t, other.allowed("/private/public"))
+	assert.False(t, other.allowed("/private/public/more"))
+	assert.True(t, other.allowed("/search"))
+
+	assert.True(t, parseRobots([]byte("User-agent: *\nDisallow:\n"), robotsAgent).allowed("/any"))
+}
+
+func TestHTTPFetcher_Robots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests.Add(1)
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher()
+	fetcher.SetRespectRobots(true)
+
+	_, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/private/page", Method: http.MethodGet, Robots: true})
+	assert.ErrorIs(t, err, ErrDisallowedByRobots)
+
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/public", Method: http.MethodGet, Robots: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), robotsRequests.Load(), "robots.txt被缓存")
+
+	_, err = fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/private/page", Method: http.MethodGet})
+	require.NoError(t, err, "非抓取请求不检查robots.txt")
+
+	t.Run("robots.txt不存在时允许访问", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		_, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/private", Method: http.MethodGet, Robots: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("robots.txt返回5xx时禁止访问并短时间缓存", func(t *testing.T) {
+		var robotsRequests, pageRequests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				robotsRequests.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			pageRequests.Add(1)
+		}))
+		defer server.Close()
+
+		fetcher := NewHTTPFetcher()
+		fetcher.SetRespectRobots(true)
+		now := time.Now()
+		fetcher.robots.now = func() time.Time { return now }
+
+		req := &Request{URL: server.URL + "/page", Method: http.MethodGet, Robots: true}
+		for range 2 {
+			_, err := fetcher.Fetch(context.Background(), req)
+			require.ErrorIs(t, err, ErrDisallowedByRobots)
+			assert.Contains(t, err.Error(), "503")
+		}
+		assert.Equal(t, int32(1), robotsRequests.Load(), "失败结果被缓存")
+		assert.Zero(t, pageRequests.Load())
+
+		now = now.Add(robotsErrorTTL)
+		_, err := fetcher.Fetch(context.Background(), req)
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Equal(t, int32(2), robotsRequests.Load(), "缓存过期后重新获取")
+	})
+
+	t.Run("无法连接时禁止访问并缓存", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		fetcher := NewHTTPFetcher()
+		fetcher.SetRespectRobots(true)
+		_, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/page", Method: http.MethodGet, Robots: true})
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.Len(t, fetcher.robots.entries, 1)
+	})
+
+	t.Run("使用请求的TLS设置获取robots.txt", func(t *testing.T) {
+		var robotsRequests atomic.Int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				robotsRequests.Add(1)
+				w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			}
+		}))
+		defer server.Close()
+		caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+		fetcher := NewHTTPFetcher()
+		fetcher.SetRespectRobots(true)
+		_, err := fetcher.Fetch(context.Background(), &Request{URL: server.URL + "/private", Method: http.MethodGet, Robots: true, TLS: &TLSConfig{CAFile: caFile}})
+		require.ErrorIs(t, err, ErrDisallowedByRobots)
+		assert.NotContains(t, err.Error(), "无法获取robots.txt")
+		assert.Equal(t, int32(1), robotsRequests.Load())
+	})
+}
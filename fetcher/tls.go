@@ -0,0 +1,137 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// pinPrefix 证书固定值的前缀，后接证书公钥（SubjectPublicKeyInfo）SHA-256摘要的Base64编码
+const pinPrefix = "sha256/"
+
+// TLSConfig 请求的TLS设置
+type TLSConfig struct {
+	// CAFile 验证服务器证书使用的CA证书（PEM），设置后代替系统的根证书
+	CAFile string
+
+	// CertFile 和 KeyFile 为双向TLS的客户端证书和私钥（PEM），每次握手时读取，更换证书文件后无需重启
+	CertFile string
+	KeyFile  string
+
+	// ServerName 验证证书和SNI使用的服务器名称，为空时使用请求的主机名
+	ServerName string
+
+	// MinVersion 最低TLS版本（如 tls.VersionTLS12），为0时使用默认值
+	MinVersion uint16
+
+	// Pins 允许的证书公钥摘要（sha256/<Base64>），验证通过的证书链中任意一个证书匹配即可，
+	// 服务器额外发送的证书不参与匹配
+	Pins []string
+
+	// InsecureSkipVerify 不验证服务器证书，设置了 Pins 时仍然检查证书固定（只匹配服务器证书）
+	InsecureSkipVerify bool
+}
+
+// key 返回区分不同TLS设置的字符串，用于复用客户端
+func (c *TLSConfig) key() string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf("%q|%q|%q|%q|%d|%q|%t", c.CAFile, c.CertFile, c.KeyFile, c.ServerName, c.MinVersion, c.Pins, c.InsecureSkipVerify)
+}
+
+// build 创建 tls.Config，c 为nil时返回nil（使用默认设置）
+func (c *TLSConfig) build() (*tls.Config, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         c.ServerName,
+		MinVersion:         c.MinVersion,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		data, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA证书文件中没有有效的证书: %s", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("客户端证书和私钥需要同时设置")
+		}
+		// 先加载一次，尽早报告证书文件的错误
+		if _, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile); err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		certFile, keyFile := c.CertFile, c.KeyFile
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+
+	if len(c.Pins) > 0 {
+		pins := make(map[string]bool, len(c.Pins))
+		for _, pin := range c.Pins {
+			if err := validatePin(pin); err != nil {
+				return nil, err
+			}
+			pins[pin] = true
+		}
+		insecure := c.InsecureSkipVerify
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			// 服务器可以在证书链中附带任意证书，只有验证过的证书链才能用于匹配；
+			// 跳过验证时没有验证过的证书链，只匹配服务器证书
+			chains := state.VerifiedChains
+			if insecure && len(state.PeerCertificates) > 0 {
+				chains = [][]*x509.Certificate{state.PeerCertificates[:1]}
+			}
+			for _, chain := range chains {
+				for _, cert := range chain {
+					if pins[CertificatePin(cert)] {
+						return nil
+					}
+				}
+			}
+			return errors.New("服务器证书与固定的公钥不匹配")
+		}
+	}
+
+	return config, nil
+}
+
+// CertificatePin 返回证书的固定值（sha256/<公钥摘要的Base64>）
+func CertificatePin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// validatePin 检查证书固定值的格式
+func validatePin(pin string) error {
+	encoded, ok := strings.CutPrefix(pin, pinPrefix)
+	if !ok {
+		return fmt.Errorf("证书固定值需要以 %s 开头: %q", pinPrefix, pin)
+	}
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sum) != sha256.Size {
+		return fmt.Errorf("无效的证书固定值: %q", pin)
+	}
+	return nil
+}
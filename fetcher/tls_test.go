@@ -0,0 +1,261 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePEM 将PEM数据写入临时文件并返回路径
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
+
+// newClientCert 生成自签名的客户端证书，返回证书、证书文件和私钥文件
+func newClientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "apiwatch"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return cert, writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "PRIVATE KEY", keyDER)
+}
+
+func TestHTTPFetcher_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	serverCert := server.Certificate()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", serverCert.Raw)
+
+	fetch := func(config *TLSConfig) error {
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{
+			URL:    server.URL,
+			Method: http.MethodGet,
+			TLS:    config,
+			Retry:  RetryPolicy{MaxAttempts: 1},
+		})
+		return err
+	}
+
+	t.Run("默认不信任私有CA", func(t *testing.T) {
+		assert.Error(t, fetch(nil))
+	})
+
+	t.Run("自定义CA证书", func(t *testing.T) {
+		assert.NoError(t, fetch(&TLSConfig{CAFile: caFile}))
+	})
+
+	t.Run("指定服务器名称", func(t *testing.T) {
+		// httptest的证书包含 example.com
+		assert.NoError(t, fetch(&TLSConfig{CAFile: caFile, ServerName: "example.com"}))
+		assert.Error(t, fetch(&TLSConfig{CAFile: caFile, ServerName: "other.example.org"}))
+	})
+
+	t.Run("跳过证书验证", func(t *testing.T) {
+		assert.NoError(t, fetch(&TLSConfig{InsecureSkipVerify: true}))
+	})
+
+	t.Run("响应包含服务器证书链", func(t *testing.T) {
+		resp, err := NewHTTPFetcher().Fetch(context.Background(), &Request{
+			URL:    server.URL,
+			Method: http.MethodGet,
+			TLS:    &TLSConfig{CAFile: caFile},
+			Retry:  RetryPolicy{MaxAttempts: 1},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Certificates)
+		assert.Equal(t, serverCert.Raw, resp.Certificates[0].Raw)
+	})
+
+	t.Run("错误状态码也返回服务器证书", func(t *testing.T) {
+		var requests atomic.Int32
+		failing := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		failing.TLS = server.TLS.Clone()
+		failing.StartTLS()
+		defer failing.Close()
+
+		resp, err := NewHTTPFetcher().Fetch(context.Background(), &Request{
+			URL:       failing.URL,
+			Method:    http.MethodGet,
+			TLS:       &TLSConfig{CAFile: caFile},
+			AnyStatus: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.NotEmpty(t, resp.Certificates)
+		assert.Equal(t, serverCert.Raw, resp.Certificates[0].Raw)
+		assert.Equal(t, int32(1), requests.Load(), "不按状态码重试")
+	})
+
+	t.Run("证书过期时不重试", func(t *testing.T) {
+		// 有效的CA签发的已过期服务器证书
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		ca := &x509.Certificate{
+			SerialNumber:          big.NewInt(2),
+			Subject:               pkix.Name{CommonName: "apiwatch CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &key.PublicKey, key)
+		require.NoError(t, err)
+		leaf := &x509.Certificate{
+			SerialNumber: big.NewInt(3),
+			Subject:      pkix.Name{CommonName: "expired"},
+			NotBefore:    time.Now().Add(-48 * time.Hour),
+			NotAfter:     time.Now().Add(-24 * time.Hour),
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, leaf, ca, &key.PublicKey, key)
+		require.NoError(t, err)
+
+		var connections atomic.Int32
+		expired := httptest.NewUnstartedServer(server.Config.Handler)
+		expired.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+		expired.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				connections.Add(1)
+			}
+		}
+		expired.StartTLS()
+		defer expired.Close()
+
+		_, err = NewHTTPFetcher().Fetch(context.Background(), &Request{
+			URL:    expired.URL,
+			Method: http.MethodGet,
+			TLS:    &TLSConfig{CAFile: writePEM(t, "expired-ca.pem", "CERTIFICATE", caDER)},
+			Retry:  RetryPolicy{MaxAttempts: 3, BackoffBase: time.Millisecond},
+		})
+		var certErr x509.CertificateInvalidError
+		require.ErrorAs(t, err, &certErr)
+		assert.Equal(t, x509.Expired, certErr.Reason)
+		assert.Equal(t, int32(1), connections.Load())
+	})
+
+	t.Run("证书固定", func(t *testing.T) {
+		assert.NoError(t, fetch(&TLSConfig{CAFile: caFile, Pins: []string{CertificatePin(serverCert)}}))
+
+		wrong := "sha256/" + "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+		err := fetch(&TLSConfig{InsecureSkipVerify: true, Pins: []string{wrong}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "固定的公钥不匹配", "跳过验证时仍检查证书固定")
+
+		err = fetch(&TLSConfig{Pins: []string{"md5/abc"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TLS设置无效")
+	})
+
+	t.Run("证书链中附带的固定证书不能通过检查", func(t *testing.T) {
+		// 服务器证书本身不匹配，额外发送的证书匹配但不在验证过的证书链中
+		pinned, _, _ := newClientCert(t)
+		cert := server.TLS.Certificates[0]
+		cert.Certificate = append(slices.Clone(cert.Certificate), pinned.Raw)
+		extra := httptest.NewUnstartedServer(server.Config.Handler)
+		extra.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+		extra.StartTLS()
+		defer extra.Close()
+
+		for _, config := range []*TLSConfig{
+			{CAFile: caFile, Pins: []string{CertificatePin(pinned)}},
+			{InsecureSkipVerify: true, Pins: []string{CertificatePin(pinned)}},
+		} {
+			_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{
+				URL:    extra.URL,
+				Method: http.MethodGet,
+				TLS:    config,
+				Retry:  RetryPolicy{MaxAttempts: 1},
+			})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "固定的公钥不匹配")
+		}
+	})
+
+	t.Run("最低TLS版本", func(t *testing.T) {
+		old := httptest.NewUnstartedServer(server.Config.Handler)
+		old.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+		old.StartTLS()
+		defer old.Close()
+
+		_, err := NewHTTPFetcher().Fetch(context.Background(), &Request{
+			URL:    old.URL,
+			Method: http.MethodGet,
+			TLS:    &TLSConfig{InsecureSkipVerify: true, MinVersion: tls.VersionTLS13},
+			Retry:  RetryPolicy{MaxAttempts: 1},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTPFetcher_MutualTLS(t *testing.T) {
+	clientCert, certFile, keyFile := newClientCert(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	f := NewHTTPFetcher()
+	req := &Request{URL: server.URL, Method: http.MethodGet, Retry: RetryPolicy{MaxAttempts: 1}}
+
+	req.TLS = &TLSConfig{CAFile: caFile}
+	_, err := f.Fetch(context.Background(), req)
+	assert.Error(t, err, "没有客户端证书时握手失败")
+
+	req.TLS = &TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+	resp, err := f.Fetch(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "apiwatch", string(resp.Body))
+
+	req.TLS = &TLSConfig{CAFile: caFile, CertFile: certFile}
+	_, err = f.Fetch(context.Background(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "同时设置")
+}
@@ -1,4 +1,4 @@
-export type ExtractorType = 'css' | 'regex' | 'json'
+export type ExtractorType = 'css' | 'regex' | 'json' | 'cert'
 
 export type RuleStatus = 'running' | 'paused' | 'error' | 'idle'
 
@@ -13,6 +13,7 @@ export interface MonitorRule {
   interval: string
   extractor_type: ExtractorType
   extractor_expr: string
+  cert_alert_days?: number
   notify_enabled: boolean
   enabled: boolean
   last_content: string
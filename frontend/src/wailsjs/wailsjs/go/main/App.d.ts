@@ -1,6 +1,9 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {history} from '../models';
 import {models} from '../models';
+import {notification} from '../models';
+import {secrets} from '../models';
 
 export function AddRule(arg1:models.MonitorRule):Promise<void>;
 
@@ -8,10 +11,22 @@ export function CheckNow(arg1:string):Promise<void>;
 
 export function DeleteRule(arg1:string):Promise<void>;
 
+export function DeleteSecret(arg1:string):Promise<void>;
+
+export function GetDeliveries(arg1:string,arg2:number):Promise<Array<notification.Delivery>>;
+
+export function GetHistory(arg1:string,arg2:number,arg3:string):Promise<Array<history.Entry>>;
+
 export function GetRule(arg1:string):Promise<models.MonitorRule>;
 
 export function GetRules():Promise<Array<models.MonitorRule>>;
 
+export function ListSecrets():Promise<Array<secrets.Info>>;
+
+export function ResendDelivery(arg1:string):Promise<void>;
+
+export function SetSecret(arg1:string,arg2:string):Promise<void>;
+
 export function StartMonitoring(arg1:string):Promise<void>;
 
 export function StopMonitoring(arg1:string):Promise<void>;
@@ -14,6 +14,18 @@ export function DeleteRule(arg1) {
   return window['go']['main']['App']['DeleteRule'](arg1);
 }
 
+export function DeleteSecret(arg1) {
+  return window['go']['main']['App']['DeleteSecret'](arg1);
+}
+
+export function GetDeliveries(arg1, arg2) {
+  return window['go']['main']['App']['GetDeliveries'](arg1, arg2);
+}
+
+export function GetHistory(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetHistory'](arg1, arg2, arg3);
+}
+
 export function GetRule(arg1) {
   return window['go']['main']['App']['GetRule'](arg1);
 }
@@ -22,6 +34,18 @@ export function GetRules() {
   return window['go']['main']['App']['GetRules']();
 }
 
+export function ListSecrets() {
+  return window['go']['main']['App']['ListSecrets']();
+}
+
+export function ResendDelivery(arg1) {
+  return window['go']['main']['App']['ResendDelivery'](arg1);
+}
+
+export function SetSecret(arg1, arg2) {
+  return window['go']['main']['App']['SetSecret'](arg1, arg2);
+}
+
 export function StartMonitoring(arg1) {
   return window['go']['main']['App']['StartMonitoring'](arg1);
 }
@@ -1,5 +1,211 @@
+export namespace history {
+	
+	export class Entry {
+	    rule_id: string;
+	    content: string;
+	    status_code: number;
+	    duration_ms: number;
+	    // Go type: time
+	    checked_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rule_id = source["rule_id"];
+	        this.content = source["content"];
+	        this.status_code = source["status_code"];
+	        this.duration_ms = source["duration_ms"];
+	        this.checked_at = this.convertValues(source["checked_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace models {
 	
+	export class ChatConfig {
+	    platform: string;
+	    webhook_url?: string;
+	    secret?: string;
+	    bot_token?: string;
+	    chat_id?: string;
+	    timeout?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.platform = source["platform"];
+	        this.webhook_url = source["webhook_url"];
+	        this.secret = source["secret"];
+	        this.bot_token = source["bot_token"];
+	        this.chat_id = source["chat_id"];
+	        this.timeout = source["timeout"];
+	    }
+	}
+	export class WebhookConfig {
+	    url: string;
+	    headers?: Record<string, string>;
+	    secret?: string;
+	    timeout?: number;
+	    max_retries?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WebhookConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.headers = source["headers"];
+	        this.secret = source["secret"];
+	        this.timeout = source["timeout"];
+	        this.max_retries = source["max_retries"];
+	    }
+	}
+	export class RateLimit {
+	    max: number;
+	    window: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RateLimit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max = source["max"];
+	        this.window = source["window"];
+	    }
+	}
+	export class MessageTemplate {
+	    title?: string;
+	    body?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessageTemplate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.body = source["body"];
+	    }
+	}
+	export class AlertPolicy {
+	    failure_threshold?: number;
+	    notify_recovery?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlertPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.failure_threshold = source["failure_threshold"];
+	        this.notify_recovery = source["notify_recovery"];
+	    }
+	}
+	export class RetryPolicy {
+	    timeout?: number;
+	    max_attempts?: number;
+	    backoff_base?: number;
+	    backoff_max?: number;
+	    jitter?: number;
+	    retry_status?: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RetryPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timeout = source["timeout"];
+	        this.max_attempts = source["max_attempts"];
+	        this.backoff_base = source["backoff_base"];
+	        this.backoff_max = source["backoff_max"];
+	        this.jitter = source["jitter"];
+	        this.retry_status = source["retry_status"];
+	    }
+	}
+	export class AuthConfig {
+	    type: string;
+	    username?: string;
+	    password?: string;
+	    token?: string;
+	    name?: string;
+	    value?: string;
+	    in?: string;
+	    token_url?: string;
+	    client_id?: string;
+	    client_secret?: string;
+	    scopes?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AuthConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.token = source["token"];
+	        this.name = source["name"];
+	        this.value = source["value"];
+	        this.in = source["in"];
+	        this.token_url = source["token_url"];
+	        this.client_id = source["client_id"];
+	        this.client_secret = source["client_secret"];
+	        this.scopes = source["scopes"];
+	    }
+	}
+	export class TLSConfig {
+	    ca_file?: string;
+	    cert_file?: string;
+	    key_file?: string;
+	    server_name?: string;
+	    min_version?: string;
+	    pins?: string[];
+	    insecure_skip_verify?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TLSConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ca_file = source["ca_file"];
+	        this.cert_file = source["cert_file"];
+	        this.key_file = source["key_file"];
+	        this.server_name = source["server_name"];
+	        this.min_version = source["min_version"];
+	        this.pins = source["pins"];
+	        this.insecure_skip_verify = source["insecure_skip_verify"];
+	    }
+	}
 	export class MonitorRule {
 	    id: string;
 	    name: string;
@@ -8,15 +214,33 @@ export namespace models {
 	    method: string;
 	    headers?: Record<string, string>;
 	    body?: string;
+	    retry?: RetryPolicy;
+	    proxy?: string;
+	    auth?: AuthConfig;
+	    tls?: TLSConfig;
 	    interval: number;
+	    schedule?: string;
+	    time_zone?: string;
 	    extractor_type: string;
 	    extractor_expr: string;
+	    cert_alert_days?: number;
 	    notify_enabled: boolean;
+	    webhooks?: WebhookConfig[];
+	    email_to?: string[];
+	    chats?: ChatConfig[];
+	    channels?: string[];
+	    error_channels?: string[];
+	    template?: MessageTemplate;
+	    rate_limit?: RateLimit;
+	    alert?: AlertPolicy;
 	    enabled: boolean;
 	    last_content: string;
 	    last_checked: string;
 	    status: string;
 	    error_message?: string;
+	    consecutive_failures?: number;
+	    failing_since?: string;
+	    next_run?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new MonitorRule(source);
@@ -31,16 +255,148 @@ export namespace models {
 	        this.method = source["method"];
 	        this.headers = source["headers"];
 	        this.body = source["body"];
+	        this.retry = this.convertValues(source["retry"], RetryPolicy);
+	        this.proxy = source["proxy"];
+	        this.auth = this.convertValues(source["auth"], AuthConfig);
+	        this.tls = this.convertValues(source["tls"], TLSConfig);
 	        this.interval = source["interval"];
+	        this.schedule = source["schedule"];
+	        this.time_zone = source["time_zone"];
 	        this.extractor_type = source["extractor_type"];
 	        this.extractor_expr = source["extractor_expr"];
+	        this.cert_alert_days = source["cert_alert_days"];
 	        this.notify_enabled = source["notify_enabled"];
+	        this.webhooks = this.convertValues(source["webhooks"], WebhookConfig);
+	        this.email_to = source["email_to"];
+	        this.chats = this.convertValues(source["chats"], ChatConfig);
+	        this.channels = source["channels"];
+	        this.error_channels = source["error_channels"];
+	        this.template = this.convertValues(source["template"], MessageTemplate);
+	        this.rate_limit = this.convertValues(source["rate_limit"], RateLimit);
+	        this.alert = this.convertValues(source["alert"], AlertPolicy);
 	        this.enabled = source["enabled"];
 	        this.last_content = source["last_content"];
 	        this.last_checked = source["last_checked"];
 	        this.status = source["status"];
 	        this.error_message = source["error_message"];
+	        this.consecutive_failures = source["consecutive_failures"];
+	        this.failing_since = source["failing_since"];
+	        this.next_run = source["next_run"];
+	    }
+	
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace notification {
+	
+	export class Delivery {
+	    id: string;
+	    rule_id?: string;
+	    channel: string;
+	    event?: string;
+	    title: string;
+	    status: string;
+	    attempts: number;
+	    last_error?: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	    // Go type: time
+	    next_attempt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Delivery(source);
 	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.rule_id = source["rule_id"];
+	        this.channel = source["channel"];
+	        this.event = source["event"];
+	        this.title = source["title"];
+	        this.status = source["status"];
+	        this.attempts = source["attempts"];
+	        this.last_error = source["last_error"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.next_attempt = this.convertValues(source["next_attempt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace secrets {
+	
+	export class Info {
+	    name: string;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Info(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
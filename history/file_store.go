@@ -0,0 +1,254 @@
+package history
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore 基于JSON Lines文件的历史存储，每个规则一个文件
+//
+// 新记录以追加方式写入；文件中超出保留策略的旧记录积累到一定数量后整体重写。
+type FileStore struct {
+	dir       string
+	retention Retention
+
+	// 已加载规则的记录缓存（按时间正序）
+	cache map[string]*ruleHistory
+	mu    sync.Mutex
+
+	now func() time.Time
+}
+
+// ruleHistory 单个规则的历史记录
+type ruleHistory struct {
+	entries []*Entry
+
+	// fileLines 文件中的记录行数（可能包含已过期的记录）
+	fileLines int
+}
+
+// NewFileStore 创建文件历史存储
+func NewFileStore(dir string, retention Retention) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建历史记录目录失败: %w", err)
+	}
+
+	return &FileStore{
+		dir:       dir,
+		retention: retention,
+		cache:     make(map[string]*ruleHistory),
+		now:       time.Now,
+	}, nil
+}
+
+// Record 记录一次提取结果
+func (s *FileStore) Record(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, err := s.load(entry.RuleID)
+	if err != nil {
+		return err
+	}
+
+	// 内容未变化时不重复记录
+	if n := len(h.entries); n > 0 && h.entries[n-1].Content == entry.Content {
+		return nil
+	}
+
+	if entry.CheckedAt.IsZero() {
+		entry.CheckedAt = s.now()
+	}
+
+	if err := s.appendLine(entry); err != nil {
+		return err
+	}
+	h.fileLines++
+	h.entries = s.retention.apply(append(h.entries, entry), s.now())
+
+	// 文件中过期记录过多时重写文件
+	if h.fileLines-len(h.entries) > s.compactThreshold() {
+		if err := s.rewrite(entry.RuleID, h); err != nil {
+			slog.Warn("压缩历史记录文件失败", "rule_id", entry.RuleID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// List 按时间倒序返回规则的历史记录
+func (s *FileStore) List(ruleID string, limit int, before time.Time) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, err := s.load(ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	entries := s.retention.apply(h.entries, s.now())
+	result := make([]*Entry, 0, min(limit, len(entries)))
+	for i := len(entries) - 1; i >= 0 && len(result) < limit; i-- {
+		if !before.IsZero() && !entries[i].CheckedAt.Before(before) {
+			continue
+		}
+		copied := *entries[i]
+		result = append(result, &copied)
+	}
+
+	return result, nil
+}
+
+// DeleteRule 删除规则的所有历史记录
+func (s *FileStore) DeleteRule(ruleID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cache, ruleID)
+
+	if err := os.Remove(s.path(ruleID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除历史记录失败: %w", err)
+	}
+	return nil
+}
+
+// load 加载规则的历史记录（调用方需持有锁）
+func (s *FileStore) load(ruleID string) (*ruleHistory, error) {
+	if h, ok := s.cache[ruleID]; ok {
+		return h, nil
+	}
+
+	h := &ruleHistory{entries: make([]*Entry, 0)}
+
+	file, err := os.Open(s.path(ruleID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.cache[ruleID] = h
+			return h, nil
+		}
+		return nil, fmt.Errorf("读取历史记录失败: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		h.fileLines++
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// 跳过损坏的行（例如写入过程中断）
+			slog.Warn("跳过无效的历史记录", "rule_id", ruleID, "error", err)
+			continue
+		}
+		h.entries = append(h.entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %w", err)
+	}
+
+	h.entries = s.retention.apply(h.entries, s.now())
+	s.cache[ruleID] = h
+	return h, nil
+}
+
+// appendLine 向规则的历史文件追加一条记录
+func (s *FileStore) appendLine(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化历史记录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path(entry.RuleID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开历史记录文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入历史记录失败: %w", err)
+	}
+	return nil
+}
+
+// rewrite 用当前保留的记录重写历史文件（先写临时文件再重命名）
+func (s *FileStore) rewrite(ruleID string, h *ruleHistory) error {
+	path := s.path(ruleID)
+	tempPath := path + ".tmp"
+
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, entry := range h.entries {
+		if err := enc.Encode(entry); err != nil {
+			file.Close()
+			os.Remove(tempPath)
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	h.fileLines = len(h.entries)
+	return nil
+}
+
+// compactThreshold 触发文件重写的过期记录数
+func (s *FileStore) compactThreshold() int {
+	if s.retention.MaxEntries > 0 {
+		return max(s.retention.MaxEntries/10, 10)
+	}
+	return 100
+}
+
+// path 返回规则历史文件路径
+func (s *FileStore) path(ruleID string) string {
+	return filepath.Join(s.dir, fileName(ruleID)+".jsonl")
+}
+
+// fileName 将规则ID转换为安全的文件名
+//
+// 只包含字母、数字、- 和 _ 的ID直接使用，其余情况使用以 ~ 开头的十六进制编码，
+// 避免路径分隔符等特殊字符，且不会与直接使用的ID冲突。
+func fileName(ruleID string) string {
+	for _, c := range ruleID {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return "~" + hex.EncodeToString([]byte(ruleID))
+		}
+	}
+	if ruleID == "" {
+		return "~"
+	}
+	return ruleID
+}
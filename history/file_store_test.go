@@ -0,0 +1,155 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_RecordAndList(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), Retention{})
+	require.NoError(t, err)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	contents := []string{"a", "a", "b", "b", "c"}
+	for i, c := range contents {
+		err := store.Record(&Entry{
+			RuleID:     "rule-1",
+			Content:    c,
+			StatusCode: 200,
+			CheckedAt:  base.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("只记录不同的内容", func(t *testing.T) {
+		entries, err := store.List("rule-1", 0, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		// 按时间倒序
+		assert.Equal(t, "c", entries[0].Content)
+		assert.Equal(t, "b", entries[1].Content)
+		assert.Equal(t, "a", entries[2].Content)
+		assert.Equal(t, base, entries[2].CheckedAt)
+	})
+
+	t.Run("限制数量", func(t *testing.T) {
+		entries, err := store.List("rule-1", 2, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "c", entries[0].Content)
+	})
+
+	t.Run("按时间分页", func(t *testing.T) {
+		entries, err := store.List("rule-1", 10, base.Add(4*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "b", entries[0].Content)
+	})
+
+	t.Run("不存在的规则返回空列表", func(t *testing.T) {
+		entries, err := store.List("missing", 10, time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestFileStore_Persistence(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileStore(dir, Retention{})
+	require.NoError(t, err)
+	require.NoError(t, store.Record(&Entry{RuleID: "rule-1", Content: "a"}))
+	require.NoError(t, store.Record(&Entry{RuleID: "rule-1", Content: "b"}))
+
+	// 重新打开后数据仍然存在，且继续去重
+	reopened, err := NewFileStore(dir, Retention{})
+	require.NoError(t, err)
+	require.NoError(t, reopened.Record(&Entry{RuleID: "rule-1", Content: "b"}))
+
+	entries, err := reopened.List("rule-1", 0, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].Content)
+	assert.False(t, entries[0].CheckedAt.IsZero())
+}
+
+func TestFileStore_Retention(t *testing.T) {
+	t.Run("按数量保留", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileStore(dir, Retention{MaxEntries: 10})
+		require.NoError(t, err)
+
+		for i := 0; i < 50; i++ {
+			require.NoError(t, store.Record(&Entry{RuleID: "r", Content: strings.Repeat("x", i+1)}))
+		}
+
+		entries, err := store.List("r", 100, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, entries, 10)
+		assert.Len(t, entries[0].Content, 50)
+
+		// 文件会被定期压缩，不会无限增长
+		data, err := os.ReadFile(filepath.Join(dir, "r.jsonl"))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, strings.Count(string(data), "\n"), 21)
+
+		reopened, err := NewFileStore(dir, Retention{MaxEntries: 10})
+		require.NoError(t, err)
+		entries, err = reopened.List("r", 100, time.Time{})
+		require.NoError(t, err)
+		assert.Len(t, entries, 10)
+	})
+
+	t.Run("按时间保留", func(t *testing.T) {
+		store, err := NewFileStore(t.TempDir(), Retention{MaxAge: time.Hour})
+		require.NoError(t, err)
+
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+
+		require.NoError(t, store.Record(&Entry{RuleID: "r", Content: "old", CheckedAt: now.Add(-2 * time.Hour)}))
+		require.NoError(t, store.Record(&Entry{RuleID: "r", Content: "new", CheckedAt: now.Add(-time.Minute)}))
+
+		entries, err := store.List("r", 10, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "new", entries[0].Content)
+	})
+}
+
+func TestFileStore_DeleteRule(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, Retention{})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Record(&Entry{RuleID: "r", Content: "a"}))
+	require.NoError(t, store.DeleteRule("r"))
+
+	entries, err := store.List("r", 10, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = os.Stat(filepath.Join(dir, "r.jsonl"))
+	assert.True(t, os.IsNotExist(err))
+
+	// 删除不存在的规则不报错
+	require.NoError(t, store.DeleteRule("missing"))
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "example-1", fileName("example-1"))
+	assert.Equal(t, "0b7c2f1e-8d1a-4c8e-9b3f-2a1d5e6f7a8b", fileName("0b7c2f1e-8d1a-4c8e-9b3f-2a1d5e6f7a8b"))
+
+	// 包含特殊字符的ID会被编码，不会逃逸出目录
+	encoded := fileName("../etc/passwd")
+	assert.True(t, strings.HasPrefix(encoded, "~"))
+	assert.NotContains(t, encoded, "/")
+	assert.NotEqual(t, fileName("a.b"), fileName("a_b"))
+}
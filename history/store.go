@@ -0,0 +1,80 @@
+package history
+
+import (
+	"time"
+)
+
+// DefaultListLimit 未指定数量时返回的记录数
+const DefaultListLimit = 50
+
+// Entry 一条内容历史记录
+type Entry struct {
+	RuleID     string    `json:"rule_id"`
+	Content    string    `json:"content"`
+	StatusCode int       `json:"status_code"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Store 历史记录存储接口
+type Store interface {
+	// Record 记录一次提取结果，内容与该规则最近一条记录相同时忽略
+	Record(entry *Entry) error
+
+	// List 按时间倒序返回规则的历史记录
+	// limit <= 0 时使用 DefaultListLimit；before 不为零值时只返回早于该时间的记录
+	List(ruleID string, limit int, before time.Time) ([]*Entry, error)
+
+	// DeleteRule 删除规则的所有历史记录
+	DeleteRule(ruleID string) error
+}
+
+// Retention 保留策略
+type Retention struct {
+	// MaxEntries 每个规则最多保留的记录数，<= 0 表示不限制
+	MaxEntries int
+
+	// MaxAge 记录最长保留时间，<= 0 表示不限制
+	MaxAge time.Duration
+}
+
+// apply 按保留策略裁剪记录（记录按时间正序排列），返回保留的部分
+func (r Retention) apply(entries []*Entry, now time.Time) []*Entry {
+	if r.MaxAge > 0 {
+		cutoff := now.Add(-r.MaxAge)
+		i := 0
+		for i < len(entries) && entries[i].CheckedAt.Before(cutoff) {
+			i++
+		}
+		entries = entries[i:]
+	}
+
+	if r.MaxEntries > 0 && len(entries) > r.MaxEntries {
+		entries = entries[len(entries)-r.MaxEntries:]
+	}
+
+	return entries
+}
+
+// NoOpStore 空实现（不记录历史）
+type NoOpStore struct{}
+
+// NewNoOpStore 创建空历史存储
+func NewNoOpStore() *NoOpStore {
+	return &NoOpStore{}
+}
+
+// Record 空实现
+func (s *NoOpStore) Record(entry *Entry) error {
+	return nil
+}
+
+// List 空实现，始终返回空列表
+func (s *NoOpStore) List(ruleID string, limit int, before time.Time) ([]*Entry, error) {
+	return []*Entry{}, nil
+}
+
+// DeleteRule 空实现
+func (s *NoOpStore) DeleteRule(ruleID string) error {
+	return nil
+}
@@ -5,17 +5,12 @@ import (
 	"embed"
 	"log/slog"
 	"os"
-	"time"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
-	"github.com/zx06/apiwatch/config"
-	"github.com/zx06/apiwatch/core"
-	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/bootstrap"
 	"github.com/zx06/apiwatch/logger"
-	"github.com/zx06/apiwatch/models"
-	"github.com/zx06/apiwatch/monitor"
 	"github.com/zx06/apiwatch/notification"
 )
 
@@ -30,43 +25,14 @@ func main() {
 
 	slog.Info("API Watch 启动中...")
 
-	// 创建配置管理器
-	configMgr, err := config.NewYAMLManager("")
+	// 装配核心组件（临时通知器将在startup中替换为Wails通知器）
+	components, err := bootstrap.Build(bootstrap.Options{})
 	if err != nil {
-		slog.Error("创建配置管理器失败", "error", err)
+		slog.Error("创建核心组件失败", "error", err)
 		os.Exit(1)
 	}
-
-	// 创建HTTP客户端
-	httpFetcher := fetcher.NewHTTPFetcher()
-
-	// 创建临时通知器（将在startup中替换为Wails通知器）
-	notifier := notification.NewNoOpNotifier()
-
-	// 创建核心引擎（先创建，以便设置回调）
-	var engine *core.Engine
-
-	// 创建规则更新回调函数
-	onRuleUpdate := func(rule *models.MonitorRule) {
-		if engine != nil {
-			// 更新Engine中的规则
-			engine.UpdateRuleInMemory(rule)
-
-			// 发布规则状态变化事件
-			engine.PublishEvent(core.Event{
-				Type:      core.EventRuleStatusChanged,
-				RuleID:    rule.ID,
-				Rule:      rule,
-				Timestamp: time.Now(),
-			})
-		}
-	}
-
-	// 创建监控服务
-	monitorSvc := monitor.NewMonitorService(httpFetcher, notifier, onRuleUpdate)
-
-	// 初始化引擎
-	engine = core.NewEngine(configMgr, monitorSvc, notifier)
+	engine := components.Engine
+	router := components.Router
 
 	// 初始化引擎
 	if err := engine.Initialize(); err != nil {
@@ -96,9 +62,9 @@ func main() {
 			app.startup(ctx)
 			eventListener.ctx = ctx
 
-			// 创建Wails通知器并更新引擎
-			wailsNotifier := notification.NewWailsNotifier(ctx)
-			monitorSvc.UpdateNotifier(wailsNotifier)
+			// 创建Wails通知器作为桌面通知渠道
+			wailsNotifier := notification.NewWailsNotifier(ctx, components.Settings.Notifications.DesktopMode)
+			router.SetDesktop(wailsNotifier)
 
 			slog.Info("API Watch 已启动")
 		},
@@ -0,0 +1,361 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+)
+
+// WebhookConfig Webhook通知配置
+type WebhookConfig struct {
+	// URL 接收通知的地址，以POST方式发送JSON
+	URL string `json:"url" yaml:"url"`
+
+	// Headers 附加的请求头
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Secret 签名密钥，设置后请求会携带HMAC-SHA256签名
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// Timeout 单次请求超时时间，为0时使用默认值
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// MaxRetries 失败后的最大重试次数，由通知发件箱按退避时间重试；为0时使用发件箱的默认次数
+	MaxRetries int `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+}
+
+// Validate 验证Webhook配置
+func (c *WebhookConfig) Validate() error {
+	if c.URL == "" {
+		return errors.New("Webhook URL不能为空")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("无效的Webhook URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Webhook URL必须使用http或https协议: %s", c.URL)
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("Webhook超时时间不能为负数")
+	}
+
+	if c.MaxRetries < 0 {
+		return errors.New("Webhook重试次数不能为负数")
+	}
+
+	return nil
+}
+
+// SMTPSecurity SMTP连接加密方式
+type SMTPSecurity string
+
+const (
+	SMTPStartTLS SMTPSecurity = "starttls" // 明文连接后通过STARTTLS升级（默认）
+	SMTPTLS      SMTPSecurity = "tls"      // 隐式TLS（通常为465端口）
+	SMTPNone     SMTPSecurity = "none"     // 不加密，仅用于本地中继
+)
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host     string       `json:"host" yaml:"host"`
+	Port     int          `json:"port,omitempty" yaml:"port,omitempty"`
+	Username string       `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string       `json:"password,omitempty" yaml:"password,omitempty"`
+	From     string       `json:"from" yaml:"from"`
+	Security SMTPSecurity `json:"security,omitempty" yaml:"security,omitempty"`
+
+	// Timeout 连接和发送的超时时间，为0时使用默认值
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// EffectiveSecurity 返回加密方式，未配置时为STARTTLS
+func (c *SMTPConfig) EffectiveSecurity() SMTPSecurity {
+	if c.Security == "" {
+		return SMTPStartTLS
+	}
+	return c.Security
+}
+
+// EffectivePort 返回端口，未配置时根据加密方式选择默认端口
+func (c *SMTPConfig) EffectivePort() int {
+	if c.Port > 0 {
+		return c.Port
+	}
+
+	switch c.EffectiveSecurity() {
+	case SMTPTLS:
+		return 465
+	case SMTPNone:
+		return 25
+	default:
+		return 587
+	}
+}
+
+// Validate 验证SMTP配置
+func (c *SMTPConfig) Validate() error {
+	if c.Host == "" {
+		return errors.New("SMTP服务器地址不能为空")
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("无效的SMTP端口: %d", c.Port)
+	}
+
+	if c.From == "" {
+		return errors.New("发件人地址不能为空")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("无效的发件人地址: %w", err)
+	}
+
+	switch c.EffectiveSecurity() {
+	case SMTPStartTLS, SMTPTLS, SMTPNone:
+	default:
+		return fmt.Errorf("无效的SMTP加密方式: %s", c.Security)
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("SMTP超时时间不能为负数")
+	}
+
+	return nil
+}
+
+// ValidateEmailAddresses 验证收件人地址列表
+func ValidateEmailAddresses(addresses []string) error {
+	for _, address := range addresses {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("无效的邮箱地址 %q: %w", address, err)
+		}
+	}
+	return nil
+}
+
+// ChatPlatform 聊天平台
+type ChatPlatform string
+
+const (
+	ChatSlack    ChatPlatform = "slack"
+	ChatDiscord  ChatPlatform = "discord"
+	ChatTelegram ChatPlatform = "telegram"
+	ChatDingTalk ChatPlatform = "dingtalk" // 钉钉
+	ChatFeishu   ChatPlatform = "feishu"   // 飞书
+	ChatWeCom    ChatPlatform = "wecom"    // 企业微信
+)
+
+// ChatConfig 聊天平台机器人通知配置
+type ChatConfig struct {
+	Platform ChatPlatform `json:"platform" yaml:"platform"`
+
+	// WebhookURL 机器人的Webhook地址（Telegram以外的平台）
+	WebhookURL string `json:"webhook_url,omitempty" yaml:"webhook_url,omitempty"`
+
+	// Secret 钉钉/飞书机器人的加签密钥，为空时不签名
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// BotToken 和 ChatID 仅用于Telegram
+	BotToken string `json:"bot_token,omitempty" yaml:"bot_token,omitempty"`
+	ChatID   string `json:"chat_id,omitempty" yaml:"chat_id,omitempty"`
+
+	// Timeout 请求超时时间，为0时使用默认值
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Validate 验证聊天平台配置
+func (c *ChatConfig) Validate() error {
+	switch c.Platform {
+	case ChatTelegram:
+		if c.BotToken == "" || c.ChatID == "" {
+			return errors.New("Telegram需要配置bot_token和chat_id")
+		}
+	case ChatSlack, ChatDiscord, ChatDingTalk, ChatFeishu, ChatWeCom:
+		if c.WebhookURL == "" {
+			return fmt.Errorf("%s需要配置webhook_url", c.Platform)
+		}
+		u, err := url.Parse(c.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("无效的webhook_url: %s", c.WebhookURL)
+		}
+	case "":
+		return errors.New("聊天平台不能为空")
+	default:
+		return fmt.Errorf("不支持的聊天平台: %s", c.Platform)
+	}
+
+	if c.Secret != "" && c.Platform != ChatDingTalk && c.Platform != ChatFeishu {
+		return fmt.Errorf("%s不支持加签密钥", c.Platform)
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("超时时间不能为负数")
+	}
+
+	return nil
+}
+
+// DesktopMode 桌面通知方式
+type DesktopMode string
+
+const (
+	DesktopToast  DesktopMode = "toast"  // 系统通知（默认）
+	DesktopDialog DesktopMode = "dialog" // 消息对话框，需要用户关闭
+	DesktopBoth   DesktopMode = "both"   // 同时使用系统通知和消息对话框
+)
+
+// Validate 验证桌面通知方式，空值表示使用默认方式
+func (m DesktopMode) Validate() error {
+	switch m {
+	case "", DesktopToast, DesktopDialog, DesktopBoth:
+		return nil
+	default:
+		return fmt.Errorf("无效的桌面通知方式: %s", m)
+	}
+}
+
+// RateLimit 通知频率限制：任意 Window 时间内最多发送 Max 条通知，超出的通知被丢弃
+type RateLimit struct {
+	Max    int      `json:"max" yaml:"max"`
+	Window Duration `json:"window" yaml:"window"`
+}
+
+// Validate 验证频率限制
+func (l *RateLimit) Validate() error {
+	if l.Max <= 0 {
+		return errors.New("频率限制的通知数必须大于0")
+	}
+	if l.Window <= 0 {
+		return errors.New("频率限制的时间窗口必须大于0")
+	}
+	return nil
+}
+
+// AlertPolicy 检查失败的告警策略
+type AlertPolicy struct {
+	// FailureThreshold 连续失败达到该次数时发送一次告警，默认为1
+	FailureThreshold int `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty"`
+
+	// NotifyRecovery 为true时，发送过告警的规则首次检查成功后发送一次恢复通知
+	NotifyRecovery bool `json:"notify_recovery,omitempty" yaml:"notify_recovery,omitempty"`
+}
+
+// Threshold 返回告警的连续失败次数，未配置时为1
+func (p *AlertPolicy) Threshold() int {
+	if p == nil || p.FailureThreshold <= 0 {
+		return 1
+	}
+	return p.FailureThreshold
+}
+
+// Validate 验证告警策略
+func (p *AlertPolicy) Validate() error {
+	if p.FailureThreshold < 0 {
+		return errors.New("告警的连续失败次数不能为负数")
+	}
+	return nil
+}
+
+// MessageTemplate 通知消息模板，使用Go text/template语法，为空的部分使用默认格式
+type MessageTemplate struct {
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	Body  string `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// IsEmpty 检查模板是否未设置任何内容
+func (t *MessageTemplate) IsEmpty() bool {
+	return t == nil || (t.Title == "" && t.Body == "")
+}
+
+// ChannelType 通知渠道类型
+type ChannelType string
+
+const (
+	ChannelDesktop ChannelType = "desktop" // 桌面通知（图形界面中为系统通知，其他场景下忽略）
+	ChannelWebhook ChannelType = "webhook"
+	ChannelEmail   ChannelType = "email"
+	ChannelChat    ChannelType = "chat"
+)
+
+// DesktopChannel 内置的桌面通知渠道名称，未配置渠道的规则默认使用它
+const DesktopChannel = "desktop"
+
+// ChannelConfig 命名通知渠道配置，按 Type 使用对应的字段
+type ChannelConfig struct {
+	Type ChannelType `json:"type" yaml:"type"`
+
+	Webhook *WebhookConfig `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Chat    *ChatConfig    `json:"chat,omitempty" yaml:"chat,omitempty"`
+
+	// EmailTo 邮件收件人，使用全局SMTP配置发送
+	EmailTo []string `json:"email_to,omitempty" yaml:"email_to,omitempty"`
+
+	// Template 该渠道使用的消息模板，优先于规则的模板
+	Template *MessageTemplate `json:"template,omitempty" yaml:"template,omitempty"`
+
+	// RateLimit 该渠道的通知频率限制（所有规则共享）
+	RateLimit *RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+
+	// Digest 大于0时，该时间窗口内发往该渠道的通知合并为一条摘要发送
+	Digest Duration `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// Validate 验证通知渠道配置
+func (c *ChannelConfig) Validate() error {
+	switch c.Type {
+	case ChannelDesktop:
+	case ChannelWebhook:
+		if c.Webhook == nil {
+			return errors.New("webhook渠道需要配置webhook")
+		}
+		if err := c.Webhook.Validate(); err != nil {
+			return err
+		}
+	case ChannelChat:
+		if c.Chat == nil {
+			return errors.New("chat渠道需要配置chat")
+		}
+		if err := c.Chat.Validate(); err != nil {
+			return err
+		}
+	case ChannelEmail:
+		if len(c.EmailTo) == 0 {
+			return errors.New("email渠道需要配置email_to")
+		}
+		if err := ValidateEmailAddresses(c.EmailTo); err != nil {
+			return err
+		}
+	case "":
+		return errors.New("渠道类型不能为空")
+	default:
+		return fmt.Errorf("不支持的渠道类型: %s", c.Type)
+	}
+
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Digest < 0 {
+		return errors.New("摘要时间窗口不能为负数")
+	}
+	return nil
+}
+
+// validateChannelNames 验证规则引用的渠道名称列表
+func validateChannelNames(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" {
+			return errors.New("渠道名称不能为空")
+		}
+		if seen[name] {
+			return fmt.Errorf("渠道重复: %s", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  WebhookConfig
+		wantErr bool
+	}{
+		{name: "有效的配置", config: WebhookConfig{URL: "https://hooks.example.com/x", Timeout: Duration(5 * time.Second), MaxRetries: 3}},
+		{name: "空URL", config: WebhookConfig{}, wantErr: true},
+		{name: "不支持的协议", config: WebhookConfig{URL: "ftp://example.com"}, wantErr: true},
+		{name: "负数超时", config: WebhookConfig{URL: "http://example.com", Timeout: Duration(-time.Second)}, wantErr: true},
+		{name: "负数重试次数", config: WebhookConfig{URL: "http://example.com", MaxRetries: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMonitorRule_ValidateWebhooks(t *testing.T) {
+	rule := &MonitorRule{
+		Name:          "测试规则",
+		URL:           "https://example.com",
+		Interval:      Duration(time.Minute),
+		ExtractorType: ExtractorCSS,
+		ExtractorExpr: "title",
+		Webhooks: []WebhookConfig{
+			{URL: "https://hooks.example.com/a"},
+			{URL: ""},
+		},
+	}
+
+	err := rule.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "第2个Webhook配置无效")
+}
+
+func TestSMTPConfig_Validate(t *testing.T) {
+	valid := SMTPConfig{Host: "smtp.example.com", From: "API Watch <watch@example.com>"}
+
+	tests := []struct {
+		name    string
+		modify  func(c *SMTPConfig)
+		wantErr bool
+	}{
+		{name: "有效的配置", modify: func(c *SMTPConfig) {}},
+		{name: "空服务器地址", modify: func(c *SMTPConfig) { c.Host = "" }, wantErr: true},
+		{name: "无效端口", modify: func(c *SMTPConfig) { c.Port = 70000 }, wantErr: true},
+		{name: "空发件人", modify: func(c *SMTPConfig) { c.From = "" }, wantErr: true},
+		{name: "无效发件人", modify: func(c *SMTPConfig) { c.From = "watch" }, wantErr: true},
+		{name: "无效加密方式", modify: func(c *SMTPConfig) { c.Security = "ssl" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			err := config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("默认端口", func(t *testing.T) {
+		assert.Equal(t, 587, (&SMTPConfig{}).EffectivePort())
+		assert.Equal(t, 465, (&SMTPConfig{Security: SMTPTLS}).EffectivePort())
+		assert.Equal(t, 25, (&SMTPConfig{Security: SMTPNone}).EffectivePort())
+		assert.Equal(t, 2525, (&SMTPConfig{Port: 2525}).EffectivePort())
+	})
+}
+
+func TestMonitorRule_ValidateEmailTo(t *testing.T) {
+	rule := &MonitorRule{
+		Name:          "测试规则",
+		URL:           "https://example.com",
+		Interval:      Duration(time.Minute),
+		ExtractorType: ExtractorCSS,
+		ExtractorExpr: "title",
+		EmailTo:       []string{"ops@example.com", "invalid"},
+	}
+
+	err := rule.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid")
+}
+
+func TestChatConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ChatConfig
+		wantErr bool
+	}{
+		{name: "Slack", config: ChatConfig{Platform: ChatSlack, WebhookURL: "https://hooks.slack.com/services/x"}},
+		{name: "钉钉加签", config: ChatConfig{Platform: ChatDingTalk, WebhookURL: "https://oapi.dingtalk.com/robot/send", Secret: "SEC"}},
+		{name: "Telegram", config: ChatConfig{Platform: ChatTelegram, BotToken: "123:abc", ChatID: "-100"}},
+		{name: "空平台", config: ChatConfig{WebhookURL: "https://example.com"}, wantErr: true},
+		{name: "不支持的平台", config: ChatConfig{Platform: "irc", WebhookURL: "https://example.com"}, wantErr: true},
+		{name: "缺少Webhook地址", config: ChatConfig{Platform: ChatWeCom}, wantErr: true},
+		{name: "无效Webhook地址", config: ChatConfig{Platform: ChatDiscord, WebhookURL: "discord"}, wantErr: true},
+		{name: "Telegram缺少chat_id", config: ChatConfig{Platform: ChatTelegram, BotToken: "123:abc"}, wantErr: true},
+		{name: "Slack不支持加签", config: ChatConfig{Platform: ChatSlack, WebhookURL: "https://hooks.slack.com/x", Secret: "s"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestChannelConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ChannelConfig
+		wantErr bool
+	}{
+		{name: "桌面通知", config: ChannelConfig{Type: ChannelDesktop}},
+		{name: "Webhook", config: ChannelConfig{Type: ChannelWebhook, Webhook: &WebhookConfig{URL: "https://hooks.example.com/x"}}},
+		{name: "邮件", config: ChannelConfig{Type: ChannelEmail, EmailTo: []string{"ops@example.com"}}},
+		{name: "聊天机器人", config: ChannelConfig{Type: ChannelChat, Chat: &ChatConfig{Platform: ChatWeCom, WebhookURL: "https://qyapi.weixin.qq.com/x"}}},
+		{name: "空类型", config: ChannelConfig{}, wantErr: true},
+		{name: "不支持的类型", config: ChannelConfig{Type: "sms"}, wantErr: true},
+		{name: "Webhook缺少配置", config: ChannelConfig{Type: ChannelWebhook}, wantErr: true},
+		{name: "邮件缺少收件人", config: ChannelConfig{Type: ChannelEmail}, wantErr: true},
+		{name: "无效的聊天配置", config: ChannelConfig{Type: ChannelChat, Chat: &ChatConfig{Platform: ChatSlack}}, wantErr: true},
+		{name: "频率限制和摘要", config: ChannelConfig{Type: ChannelDesktop, RateLimit: &RateLimit{Max: 3, Window: Duration(time.Hour)}, Digest: Duration(10 * time.Minute)}},
+		{name: "频率限制缺少窗口", config: ChannelConfig{Type: ChannelDesktop, RateLimit: &RateLimit{Max: 3}}, wantErr: true},
+		{name: "频率限制数量为0", config: ChannelConfig{Type: ChannelDesktop, RateLimit: &RateLimit{Window: Duration(time.Hour)}}, wantErr: true},
+		{name: "负数摘要窗口", config: ChannelConfig{Type: ChannelDesktop, Digest: Duration(-time.Minute)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMonitorRule_ValidateChannels(t *testing.T) {
+	rule := &MonitorRule{
+		Name:          "测试规则",
+		URL:           "https://example.com",
+		Interval:      Duration(time.Minute),
+		ExtractorType: ExtractorCSS,
+		ExtractorExpr: "title",
+		Channels:      []string{"ops", "desktop"},
+		ErrorChannels: []string{"ops"},
+	}
+	require.NoError(t, rule.Validate())
+
+	t.Run("渠道重复", func(t *testing.T) {
+		rule.Channels = []string{"ops", "ops"}
+		err := rule.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "渠道重复")
+	})
+
+	t.Run("渠道名称为空", func(t *testing.T) {
+		rule.Channels = nil
+		rule.ErrorChannels = []string{""}
+		err := rule.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "错误通知渠道无效")
+	})
+}
+
+func TestAlertPolicy(t *testing.T) {
+	var none *AlertPolicy
+	assert.Equal(t, 1, none.Threshold(), "未配置时第一次失败即告警")
+	assert.Equal(t, 1, (&AlertPolicy{NotifyRecovery: true}).Threshold())
+	assert.Equal(t, 3, (&AlertPolicy{FailureThreshold: 3}).Threshold())
+
+	rule := &MonitorRule{
+		Name:          "测试规则",
+		URL:           "https://example.com",
+		Interval:      Duration(time.Minute),
+		ExtractorType: ExtractorCSS,
+		ExtractorExpr: "title",
+		Alert:         &AlertPolicy{FailureThreshold: 3, NotifyRecovery: true},
+	}
+	require.NoError(t, rule.Validate())
+
+	rule.Alert.FailureThreshold = -1
+	err := rule.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "连续失败次数")
+}
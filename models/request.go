@@ -0,0 +1,227 @@
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RetryPolicy 检查请求的超时和重试策略，未设置的字段使用默认值
+type RetryPolicy struct {
+	// Timeout 单次请求的超时时间，默认30秒
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// MaxAttempts 最多尝试的次数（包括第一次请求），默认为3，为1时不重试
+	MaxAttempts int `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+
+	// BackoffBase 和 BackoffMax 为重试的退避时间：第n次重试前等待 BackoffBase*2^(n-1)，不超过 BackoffMax
+	BackoffBase Duration `json:"backoff_base,omitempty" yaml:"backoff_base,omitempty"`
+	BackoffMax  Duration `json:"backoff_max,omitempty" yaml:"backoff_max,omitempty"`
+
+	// Jitter 退避时间的随机浮动比例（0到1），如0.2表示在±20%范围内随机
+	Jitter float64 `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+
+	// RetryStatus 需要重试的HTTP状态码，为空时重试 408、429、500、502、503 和 504
+	RetryStatus []int `json:"retry_status,omitempty" yaml:"retry_status,omitempty"`
+}
+
+// Validate 验证重试策略
+func (p *RetryPolicy) Validate() error {
+	if p.Timeout < 0 {
+		return errors.New("请求超时时间不能为负数")
+	}
+	if p.MaxAttempts < 0 {
+		return errors.New("最多尝试次数不能为负数")
+	}
+	if p.BackoffBase < 0 || p.BackoffMax < 0 {
+		return errors.New("重试退避时间不能为负数")
+	}
+	if p.BackoffBase > 0 && p.BackoffMax > 0 && p.BackoffBase > p.BackoffMax {
+		return errors.New("重试的初始退避时间不能大于最大退避时间")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("重试退避的随机比例必须在0到1之间")
+	}
+	for _, code := range p.RetryStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("无效的重试状态码: %d", code)
+		}
+		if code >= http.StatusOK && code < http.StatusMultipleChoices {
+			return fmt.Errorf("成功的状态码不能重试: %d", code)
+		}
+	}
+	return nil
+}
+
+// HostLimit 对同一主机的请求限制，字段为0表示不限制
+type HostLimit struct {
+	// Rate 每秒允许的请求数，如0.5表示每2秒一次
+	Rate float64 `json:"rate,omitempty" yaml:"rate,omitempty"`
+
+	// Burst 允许的突发请求数，默认为 Rate 向上取整（至少为1）
+	Burst int `json:"burst,omitempty" yaml:"burst,omitempty"`
+
+	// MaxConcurrent 同时进行的请求数上限
+	MaxConcurrent int `json:"max_concurrent,omitempty" yaml:"max_concurrent,omitempty"`
+}
+
+// Validate 验证主机请求限制
+func (l *HostLimit) Validate() error {
+	if l.Rate < 0 || l.Burst < 0 || l.MaxConcurrent < 0 {
+		return errors.New("主机请求限制不能为负数")
+	}
+	if l.Burst > 0 && l.Rate == 0 {
+		return errors.New("设置突发请求数时需要同时设置请求速率")
+	}
+	return nil
+}
+
+// ProxyDirect 代理设置为该值时不使用代理直接连接
+const ProxyDirect = "direct"
+
+// ValidateProxy 验证代理设置，可以为空、ProxyDirect 或 http、https、socks5、socks5h 代理地址
+func ValidateProxy(proxy string) error {
+	if proxy == "" || proxy == ProxyDirect {
+		return nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("无效的代理地址: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("不支持的代理协议: %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("代理地址缺少主机")
+	}
+	return nil
+}
+
+// AuthType 请求认证方式
+type AuthType string
+
+const (
+	AuthBasic  AuthType = "basic"
+	AuthBearer AuthType = "bearer"
+	AuthAPIKey AuthType = "api_key"
+	AuthOAuth2 AuthType = "oauth2"
+)
+
+// API Key的发送位置
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+)
+
+// AuthConfig 检查请求的认证配置，只使用 Type 对应的字段
+type AuthConfig struct {
+	Type AuthType `json:"type" yaml:"type"`
+
+	// Username 和 Password 用于HTTP Basic认证
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+
+	// Token 用于Bearer认证
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+
+	// Name 和 Value 为API Key的名称和值，In 为发送位置（header 或 query），默认为 header
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	In    string `json:"in,omitempty" yaml:"in,omitempty"`
+
+	// TokenURL、ClientID、ClientSecret 和 Scopes 用于OAuth2客户端凭据模式，令牌过期前自动重新获取
+	TokenURL     string   `json:"token_url,omitempty" yaml:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// Validate 验证认证配置
+func (c *AuthConfig) Validate() error {
+	switch c.Type {
+	case AuthBasic:
+		if c.Username == "" {
+			return errors.New("Basic认证的用户名不能为空")
+		}
+	case AuthBearer:
+		if c.Token == "" {
+			return errors.New("Bearer认证的令牌不能为空")
+		}
+	case AuthAPIKey:
+		if c.Name == "" || c.Value == "" {
+			return errors.New("API Key的名称和值不能为空")
+		}
+		if c.In != "" && c.In != APIKeyInHeader && c.In != APIKeyInQuery {
+			return fmt.Errorf("无效的API Key位置: %s", c.In)
+		}
+	case AuthOAuth2:
+		if c.ClientID == "" {
+			return errors.New("OAuth2的客户端ID不能为空")
+		}
+		u, err := url.Parse(c.TokenURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("无效的OAuth2令牌地址: %s", c.TokenURL)
+		}
+	default:
+		return fmt.Errorf("无效的认证方式: %s", c.Type)
+	}
+	return nil
+}
+
+// TLSVersions TLS设置中允许的最低版本
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig 检查请求的TLS设置
+type TLSConfig struct {
+	// CAFile 验证服务器证书使用的CA证书文件（PEM），设置后代替系统的根证书
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+
+	// CertFile 和 KeyFile 为双向TLS的客户端证书和私钥文件（PEM）
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+
+	// ServerName 验证证书和SNI使用的服务器名称，默认为请求的主机名
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+
+	// MinVersion 最低TLS版本：1.0、1.1、1.2 或 1.3
+	MinVersion string `json:"min_version,omitempty" yaml:"min_version,omitempty"`
+
+	// Pins 固定的证书公钥摘要（sha256/<Base64>），验证通过的证书链中任意一个证书匹配即可
+	Pins []string `json:"pins,omitempty" yaml:"pins,omitempty"`
+
+	// InsecureSkipVerify 不验证服务器证书（仅用于测试环境），设置了 Pins 时仍然检查证书固定（只匹配服务器证书）
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// Validate 验证TLS设置
+func (c *TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("客户端证书和私钥需要同时设置")
+	}
+	if _, ok := TLSVersions[c.MinVersion]; c.MinVersion != "" && !ok {
+		return fmt.Errorf("无效的最低TLS版本: %s", c.MinVersion)
+	}
+	for _, pin := range c.Pins {
+		encoded, ok := strings.CutPrefix(pin, "sha256/")
+		if !ok {
+			return fmt.Errorf("证书固定值需要以 sha256/ 开头: %q", pin)
+		}
+		if sum, err := base64.StdEncoding.DecodeString(encoded); err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("无效的证书固定值: %q", pin)
+		}
+	}
+	return nil
+}
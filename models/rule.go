@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/zx06/apiwatch/schedule"
 )
 
 // Duration 自定义Duration类型，支持JSON序列化/反序列化
@@ -65,8 +67,12 @@ const (
 	ExtractorCSS   ExtractorType = "css"
 	ExtractorRegex ExtractorType = "regex"
 	ExtractorJSON  ExtractorType = "json"
+	ExtractorCert  ExtractorType = "cert" // 检查服务器证书，提取表达式为逗号分隔的字段列表
 )
 
+// DefaultCertAlertDays 证书剩余有效天数的默认告警阈值
+const DefaultCertAlertDays = 14
+
 // RuleStatus 规则状态
 type RuleStatus string
 
@@ -89,15 +95,71 @@ type MonitorRule struct {
 	Method        string            `json:"method" yaml:"method"`
 	Headers       map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 	Body          string            `json:"body,omitempty" yaml:"body,omitempty"`
+	Retry         *RetryPolicy      `json:"retry,omitempty" yaml:"retry,omitempty"` // 请求超时和重试策略
+	Proxy         string            `json:"proxy,omitempty" yaml:"proxy,omitempty"` // 代理地址，为空时使用全局设置，direct 表示不使用代理
+	Auth          *AuthConfig       `json:"auth,omitempty" yaml:"auth,omitempty"`   // 请求认证
+	TLS           *TLSConfig        `json:"tls,omitempty" yaml:"tls,omitempty"`     // TLS设置
 	Interval      Duration          `json:"interval" yaml:"interval"`
+	Schedule      string            `json:"schedule,omitempty" yaml:"schedule,omitempty"`   // cron表达式，设置后代替 Interval
+	TimeZone      string            `json:"time_zone,omitempty" yaml:"time_zone,omitempty"` // cron表达式使用的时区，默认为本地时区
 	ExtractorType ExtractorType     `json:"extractor_type" yaml:"extractor_type"`
 	ExtractorExpr string            `json:"extractor_expr" yaml:"extractor_expr"`
+	CertAlertDays int               `json:"cert_alert_days,omitempty" yaml:"cert_alert_days,omitempty"` // 证书剩余有效天数少于该值时告警，为0时使用默认值
 	NotifyEnabled bool              `json:"notify_enabled" yaml:"notify_enabled"`
+	Webhooks      []WebhookConfig   `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+	EmailTo       []string          `json:"email_to,omitempty" yaml:"email_to,omitempty"`             // 邮件通知收件人
+	Chats         []ChatConfig      `json:"chats,omitempty" yaml:"chats,omitempty"`                   // 聊天平台机器人
+	Channels      []string          `json:"channels,omitempty" yaml:"channels,omitempty"`             // 内容变化通知的渠道，为空时使用桌面通知
+	ErrorChannels []string          `json:"error_channels,omitempty" yaml:"error_channels,omitempty"` // 检查失败通知的渠道，为空时不通知
+	Template      *MessageTemplate  `json:"template,omitempty" yaml:"template,omitempty"`             // 通知消息模板
+	RateLimit     *RateLimit        `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`         // 该规则的内容变化通知频率限制
+	Alert         *AlertPolicy      `json:"alert,omitempty" yaml:"alert,omitempty"`                   // 检查失败的告警策略
 	Enabled       bool              `json:"enabled" yaml:"enabled"`
 	LastContent   string            `json:"last_content" yaml:"last_content"`
 	LastChecked   string            `json:"last_checked" yaml:"last_checked"` // RFC3339 格式的时间字符串
 	Status        RuleStatus        `json:"status" yaml:"status"`
 	ErrorMessage  string            `json:"error_message,omitempty" yaml:"error_message,omitempty"`
+
+	// ConsecutiveFailures 当前连续失败的次数，检查成功后清零
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty" yaml:"consecutive_failures,omitempty"`
+
+	// FailingSince 本轮连续失败开始的时间（RFC3339），检查成功后清空
+	FailingSince string `json:"failing_since,omitempty" yaml:"failing_since,omitempty"`
+
+	// NextRun 下次计划检查的时间（RFC3339），监控未运行时为空；不保存到配置文件
+	NextRun string `json:"next_run,omitempty" yaml:"-"`
+}
+
+// NextRunAfter 返回规则在 after 之后的下次计划检查时间
+//
+// 设置了 Schedule 时按cron表达式在 TimeZone 时区计算，否则为 after 加上检查间隔。
+func (r *MonitorRule) NextRunAfter(after time.Time) (time.Time, error) {
+	if r.Schedule == "" {
+		return after.Add(time.Duration(r.Interval)), nil
+	}
+
+	cron, err := schedule.Parse(r.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := schedule.LoadLocation(r.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := cron.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron表达式没有可执行的时间: %q", r.Schedule)
+	}
+	return next, nil
+}
+
+// CertAlertThreshold 返回证书剩余有效天数的告警阈值
+func (r *MonitorRule) CertAlertThreshold() int {
+	if r.CertAlertDays > 0 {
+		return r.CertAlertDays
+	}
+	return DefaultCertAlertDays
 }
 
 // Validate 验证规则的有效性
@@ -132,21 +194,99 @@ func (r *MonitorRule) Validate() error {
 		return fmt.Errorf("无效的HTTP方法: %s", r.Method)
 	}
 
-	if time.Duration(r.Interval) < time.Second {
-		return errors.New("检查间隔不能小于1秒")
+	if r.Schedule != "" {
+		if _, err := r.NextRunAfter(time.Now()); err != nil {
+			return fmt.Errorf("无效的检查计划: %w", err)
+		}
+	} else {
+		if r.TimeZone != "" {
+			return errors.New("时区只能与cron表达式一起使用")
+		}
+		if time.Duration(r.Interval) < time.Second {
+			return errors.New("检查间隔不能小于1秒")
+		}
 	}
 
-	if r.ExtractorExpr == "" {
+	if r.Retry != nil {
+		if err := r.Retry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateProxy(r.Proxy); err != nil {
+		return err
+	}
+
+	if r.Auth != nil {
+		if err := r.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.TLS != nil {
+		if err := r.TLS.Validate(); err != nil {
+			return fmt.Errorf("TLS设置无效: %w", err)
+		}
+	}
+
+	// 证书监控的提取表达式可以为空，表示提取默认字段
+	if r.ExtractorExpr == "" && r.ExtractorType != ExtractorCert {
 		return errors.New("提取表达式不能为空")
 	}
 
 	// 验证提取器类型
 	validExtractors := map[ExtractorType]bool{
-		ExtractorCSS: true, ExtractorRegex: true, ExtractorJSON: true,
+		ExtractorCSS: true, ExtractorRegex: true, ExtractorJSON: true, ExtractorCert: true,
 	}
 	if !validExtractors[r.ExtractorType] {
 		return fmt.Errorf("无效的提取器类型: %s", r.ExtractorType)
 	}
 
+	if r.ExtractorType == ExtractorCert {
+		if u, _ := url.Parse(r.URL); u.Scheme != "https" {
+			return errors.New("证书监控的URL必须使用https协议")
+		}
+	} else if r.CertAlertDays != 0 {
+		return errors.New("证书告警天数只能用于证书监控")
+	}
+	if r.CertAlertDays < 0 {
+		return errors.New("证书告警天数不能为负数")
+	}
+
+	for i := range r.Webhooks {
+		if err := r.Webhooks[i].Validate(); err != nil {
+			return fmt.Errorf("第%d个Webhook配置无效: %w", i+1, err)
+		}
+	}
+
+	if err := ValidateEmailAddresses(r.EmailTo); err != nil {
+		return err
+	}
+
+	for i := range r.Chats {
+		if err := r.Chats[i].Validate(); err != nil {
+			return fmt.Errorf("第%d个聊天机器人配置无效: %w", i+1, err)
+		}
+	}
+
+	if err := validateChannelNames(r.Channels); err != nil {
+		return fmt.Errorf("通知渠道无效: %w", err)
+	}
+	if err := validateChannelNames(r.ErrorChannels); err != nil {
+		return fmt.Errorf("错误通知渠道无效: %w", err)
+	}
+
+	if r.RateLimit != nil {
+		if err := r.RateLimit.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.Alert != nil {
+		if err := r.Alert.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
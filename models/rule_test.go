@@ -181,6 +181,7 @@ func TestExtractorType_Constants(t *testing.T) {
 	assert.Equal(t, ExtractorType("css"), ExtractorCSS)
 	assert.Equal(t, ExtractorType("regex"), ExtractorRegex)
 	assert.Equal(t, ExtractorType("json"), ExtractorJSON)
+	assert.Equal(t, ExtractorType("cert"), ExtractorCert)
 }
 
 func TestRuleStatus_Constants(t *testing.T) {
@@ -193,3 +194,110 @@ func TestRuleStatus_Constants(t *testing.T) {
 func TestDefaultMethod(t *testing.T) {
 	assert.Equal(t, http.MethodGet, DefaultMethod)
 }
+
+func TestMonitorRule_Schedule(t *testing.T) {
+	newRule := func() *MonitorRule {
+		return &MonitorRule{
+			Name:          "测试规则",
+			URL:           "https://example.com",
+			Schedule:      "*/5 9-18 * * MON-FRI",
+			TimeZone:      "Asia/Shanghai",
+			ExtractorType: ExtractorCSS,
+			ExtractorExpr: "title",
+		}
+	}
+
+	t.Run("设置cron表达式时不需要检查间隔", func(t *testing.T) {
+		assert.NoError(t, newRule().Validate())
+	})
+
+	t.Run("按时区计算下次检查时间", func(t *testing.T) {
+		// 北京时间星期五 18:56，下次为星期一 09:00
+		after := time.Date(2024, 1, 5, 10, 56, 0, 0, time.UTC)
+		next, err := newRule().NextRunAfter(after)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 1, 8, 1, 0, 0, 0, time.UTC), next.UTC())
+		assert.Equal(t, "Asia/Shanghai", next.Location().String())
+	})
+
+	t.Run("未设置cron表达式时使用检查间隔", func(t *testing.T) {
+		rule := newRule()
+		rule.Schedule = ""
+		rule.TimeZone = ""
+		rule.Interval = Duration(time.Minute)
+		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		next, err := rule.NextRunAfter(after)
+		require.NoError(t, err)
+		assert.Equal(t, after.Add(time.Minute), next)
+	})
+
+	invalid := []struct {
+		name   string
+		modify func(r *MonitorRule)
+		want   string
+	}{
+		{name: "无效的cron表达式", modify: func(r *MonitorRule) { r.Schedule = "* * *" }, want: "无效的检查计划"},
+		{name: "没有可执行的时间", modify: func(r *MonitorRule) { r.Schedule = "0 0 30 2 *" }, want: "没有可执行的时间"},
+		{name: "无效的时区", modify: func(r *MonitorRule) { r.TimeZone = "Mars/Olympus" }, want: "无效的时区"},
+		{name: "时区需要cron表达式", modify: func(r *MonitorRule) {
+			r.Schedule = ""
+			r.Interval = Duration(time.Minute)
+		}, want: "时区只能与cron表达式一起使用"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := newRule()
+			tt.modify(rule)
+			err := rule.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestMonitorRule_CertMonitor(t *testing.T) {
+	newRule := func() *MonitorRule {
+		return &MonitorRule{
+			Name:          "证书",
+			URL:           "https://example.com",
+			Interval:      Duration(time.Hour),
+			ExtractorType: ExtractorCert,
+		}
+	}
+
+	t.Run("提取表达式可以为空", func(t *testing.T) {
+		rule := newRule()
+		require.NoError(t, rule.Validate())
+		assert.Equal(t, DefaultCertAlertDays, rule.CertAlertThreshold())
+	})
+
+	t.Run("自定义告警天数", func(t *testing.T) {
+		rule := newRule()
+		rule.CertAlertDays = 30
+		require.NoError(t, rule.Validate())
+		assert.Equal(t, 30, rule.CertAlertThreshold())
+	})
+
+	invalid := []struct {
+		name   string
+		modify func(r *MonitorRule)
+		want   string
+	}{
+		{name: "需要https", modify: func(r *MonitorRule) { r.URL = "http://example.com" }, want: "必须使用https协议"},
+		{name: "告警天数为负数", modify: func(r *MonitorRule) { r.CertAlertDays = -1 }, want: "不能为负数"},
+		{name: "告警天数只能用于证书监控", modify: func(r *MonitorRule) {
+			r.ExtractorType = ExtractorCSS
+			r.ExtractorExpr = ".content"
+			r.CertAlertDays = 7
+		}, want: "只能用于证书监控"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := newRule()
+			tt.modify(rule)
+			err := rule.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
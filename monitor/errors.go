@@ -0,0 +1,20 @@
+package monitor
+
+import "errors"
+
+var (
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("任务不存在")
+
+	// ErrTaskNotRunning 任务不存在或未启动
+	ErrTaskNotRunning = errors.New("任务不存在或未启动")
+
+	// ErrTaskAlreadyRunning 任务已在运行中
+	ErrTaskAlreadyRunning = errors.New("任务已在运行中")
+
+	// ErrCheckCanceled 检查因任务停止、更新或服务关闭而取消
+	ErrCheckCanceled = errors.New("检查已取消")
+
+	// ErrCertificateExpiring 服务器证书的剩余有效天数低于告警阈值
+	ErrCertificateExpiring = errors.New("证书有效期不足")
+)
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/zx06/apiwatch/diff"
+)
+
+// OutcomeKind 检查结果类型
+type OutcomeKind string
+
+const (
+	OutcomeChanged   OutcomeKind = "changed"   // 内容发生变化
+	OutcomeUnchanged OutcomeKind = "unchanged" // 内容未变化（包括没有旧内容的首次检查）
+	OutcomeError     OutcomeKind = "error"     // 检查失败
+)
+
+// ErrorStage 检查失败的阶段
+type ErrorStage string
+
+const (
+	StageFetch       ErrorStage = "fetch"       // HTTP请求
+	StageExtract     ErrorStage = "extract"     // 内容提取
+	StageCertificate ErrorStage = "certificate" // 证书剩余有效天数低于告警阈值
+)
+
+// outcomeBufferSize 检查结果通道的缓冲大小
+const outcomeBufferSize = 256
+
+// Outcome 一次检查的结果，由监控任务发送到 Service.Outcomes 通道
+type Outcome struct {
+	RuleID string      `json:"rule_id"`
+	Kind   OutcomeKind `json:"kind"`
+
+	// OldContent 上一次提取的内容，首次检查时为空
+	OldContent string `json:"old_content,omitempty"`
+
+	// NewContent 本次提取的内容，检查失败时为空
+	NewContent string `json:"new_content,omitempty"`
+
+	// Diff 新旧内容的差异，仅在内容变化时设置
+	Diff *diff.Result `json:"diff,omitempty"`
+
+	StatusCode int   `json:"status_code,omitempty"`
+	DurationMs int64 `json:"duration_ms"`
+
+	// Stage 和 Error 仅在检查失败时设置
+	Stage ErrorStage `json:"stage,omitempty"`
+	Error string     `json:"error,omitempty"`
+	Err   error      `json:"-"`
+
+	// Failures 连续失败次数：检查失败时包括本次；检查成功时为此前的连续失败次数，大于0表示规则刚刚恢复
+	Failures int `json:"failures,omitempty"`
+
+	// FailingSince 本轮连续失败开始的时间
+	FailingSince time.Time `json:"failing_since,omitzero"`
+
+	// LastError 规则恢复时，恢复前最后一次失败的错误信息
+	LastError string `json:"last_error,omitempty"`
+
+	CheckedAt time.Time `json:"checked_at"`
+}
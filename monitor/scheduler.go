@@ -0,0 +1,346 @@
+package monitor
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency 默认同时执行检查的最大数量
+const DefaultConcurrency = 8
+
+// scheduleEntry 调度器中的一个任务
+type scheduleEntry struct {
+	task *Task
+
+	// next 下次检查时间
+	next time.Time
+
+	// index 在队列中的位置，不在队列中（正在检查或等待检查计划更新）时为-1
+	index int
+
+	// active 已到达检查时间，正在等待worker或正在检查
+	active bool
+
+	// rescheduled 检查期间检查计划发生变化，检查完成后从当前时间重新计算下次检查时间
+	rescheduled bool
+
+	// rerun 检查被取消，结束后立即重新检查
+	rerun bool
+}
+
+// scheduleQueue 按下次检查时间排序的最小堆
+type scheduleQueue []*scheduleEntry
+
+func (q scheduleQueue) Len() int           { return len(q) }
+func (q scheduleQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x any) {
+	entry := x.(*scheduleEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() any {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// Scheduler 监控任务调度器
+//
+// 所有任务共享一个按下次检查时间排序的优先队列，由单个调度goroutine取出到期的任务，
+// 交给固定数量的worker执行，因此goroutine数量和同时进行的检查数量与规则数量无关。
+// 检查完成后根据规则的检查计划计算下次检查时间并重新放入队列。
+type Scheduler struct {
+	mu      sync.Mutex
+	queue   scheduleQueue
+	entries map[*Task]*scheduleEntry
+
+	// wake 队列变化时唤醒调度goroutine
+	wake chan struct{}
+	jobs chan *scheduleEntry
+	stop chan struct{}
+
+	// ctx 所有定期检查使用的上下文，关闭调度器时取消
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	loopDone  chan struct{}
+	workersWg sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewScheduler 创建并启动调度器，concurrency 为同时执行检查的最大数量，不大于0时使用默认值
+func NewScheduler(concurrency int) *Scheduler {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		ctx:      ctx,
+		cancel:   cancel,
+		entries:  make(map[*Task]*scheduleEntry),
+		wake:     make(chan struct{}, 1),
+		jobs:     make(chan *scheduleEntry),
+		stop:     make(chan struct{}),
+		loopDone: make(chan struct{}),
+	}
+
+	go s.loop()
+	s.workersWg.Add(concurrency)
+	for range concurrency {
+		go s.work()
+	}
+	return s
+}
+
+// Add 加入任务并立即安排一次检查，任务已在调度器中时不做任何操作
+func (s *Scheduler) Add(task *Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[task]; exists {
+		return
+	}
+
+	entry := &scheduleEntry{task: task, next: time.Now(), index: -1}
+	s.entries[task] = entry
+	heap.Push(&s.queue, entry)
+	s.signal()
+}
+
+// Remove 移除任务，正在进行的检查会继续完成，但之后不再安排检查
+func (s *Scheduler) Remove(task *Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[task]
+	if !exists {
+		return
+	}
+
+	delete(s.entries, task)
+	if entry.index >= 0 {
+		heap.Remove(&s.queue, entry.index)
+	}
+	s.signal()
+}
+
+// Reschedule 检查计划变化后从当前时间重新计算任务的下次检查时间
+func (s *Scheduler) Reschedule(task *Task) {
+	s.mu.Lock()
+	entry, exists := s.entries[task]
+	active := exists && entry.active
+	if active {
+		// 检查完成后再重新计算
+		entry.rescheduled = true
+	}
+	s.mu.Unlock()
+
+	if !exists || active {
+		return
+	}
+
+	// 计算下次检查时间需要获取任务的锁，不能在持有调度器的锁时进行
+	next, ok := task.scheduleNext(time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[task] != entry {
+		return
+	}
+	if entry.active {
+		entry.rescheduled = true
+		return
+	}
+	switch {
+	case !ok && entry.index >= 0:
+		heap.Remove(&s.queue, entry.index)
+	case ok && entry.index >= 0:
+		entry.next = next
+		heap.Fix(&s.queue, entry.index)
+	case ok:
+		entry.next = next
+		heap.Push(&s.queue, entry)
+	}
+	s.signal()
+}
+
+// RunNow 安排任务立即检查，任务正在检查时在本次检查结束后立即再检查一次
+func (s *Scheduler) RunNow(task *Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[task]
+	switch {
+	case !exists:
+		return
+	case entry.active:
+		entry.rerun = true
+	case entry.index >= 0:
+		entry.next = time.Now()
+		heap.Fix(&s.queue, entry.index)
+	default:
+		entry.next = time.Now()
+		heap.Push(&s.queue, entry)
+	}
+	s.signal()
+}
+
+// Len 返回调度器中的任务数量
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Close 停止调度，取消正在进行的检查并等待其结束
+func (s *Scheduler) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		close(s.stop)
+		<-s.loopDone
+		close(s.jobs)
+		s.workersWg.Wait()
+	})
+}
+
+// signal 唤醒调度goroutine，调用方需持有锁
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop 调度循环，将到期的任务交给worker
+func (s *Scheduler) loop() {
+	defer close(s.loopDone)
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		entry, wait := s.popDue(time.Now())
+		if entry != nil {
+			// 所有worker都忙时在这里等待，达到并发上限的到期任务按时间顺序依次执行
+			select {
+			case s.jobs <- entry:
+			case <-s.stop:
+				return
+			}
+			continue
+		}
+
+		var fire <-chan time.Time
+		if wait >= 0 {
+			timer.Reset(wait)
+			fire = timer.C
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-fire:
+		}
+		timer.Stop()
+	}
+}
+
+// popDue 取出到期的任务并标记为正在检查
+//
+// 没有到期的任务时返回距离最早的检查时间的等待时间，队列为空时等待时间为-1。
+func (s *Scheduler) popDue(now time.Time) (*scheduleEntry, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, -1
+	}
+	if wait := s.queue[0].next.Sub(now); wait > 0 {
+		return nil, wait
+	}
+
+	entry := heap.Pop(&s.queue).(*scheduleEntry)
+	entry.active = true
+	return entry, 0
+}
+
+// work 执行调度循环交来的检查，直到调度器关闭
+func (s *Scheduler) work() {
+	defer s.workersWg.Done()
+
+	for entry := range s.jobs {
+		s.mu.Lock()
+		removed := s.entries[entry.task] != entry
+		s.mu.Unlock()
+
+		if !removed {
+			if err := entry.task.RunOnce(s.ctx); err != nil && !errors.Is(err, ErrCheckCanceled) {
+				slog.Error("定期检查失败",
+					"rule_id", entry.task.GetRule().ID,
+					"error", err,
+				)
+			}
+		}
+		s.finish(entry)
+	}
+}
+
+// finish 检查完成后计算下次检查时间并重新放入队列
+func (s *Scheduler) finish(entry *scheduleEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 下次检查时间从本次计划的时间算起，检查耗时不会累积成偏移
+	after := entry.next
+	for s.entries[entry.task] == entry {
+		if entry.rerun {
+			entry.rerun = false
+			entry.rescheduled = false
+			entry.next = time.Now()
+			heap.Push(&s.queue, entry)
+			s.signal()
+			break
+		}
+		if entry.rescheduled {
+			entry.rescheduled = false
+			after = time.Now()
+		}
+
+		// 计算下次检查时间需要获取任务的锁，不能在持有调度器的锁时进行
+		s.mu.Unlock()
+		next, ok := entry.task.scheduleNext(after)
+		s.mu.Lock()
+
+		// 计算期间检查计划再次变化时重新计算
+		if entry.rescheduled || entry.rerun {
+			continue
+		}
+		if ok && s.entries[entry.task] == entry {
+			entry.next = next
+			heap.Push(&s.queue, entry)
+			s.signal()
+		}
+		break
+	}
+	entry.active = false
+}
@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/extractor"
+	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/models"
+)
+
+// blockingFetcher 统计请求次数和最大并发数，release 关闭前请求一直阻塞
+type blockingFetcher struct {
+	release chan struct{}
+
+	calls   atomic.Int32
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+func newBlockingFetcher() *blockingFetcher {
+	return &blockingFetcher{release: make(chan struct{})}
+}
+
+func (f *blockingFetcher) Fetch(ctx context.Context, req *fetcher.Request) (*fetcher.Response, error) {
+	f.calls.Add(1)
+	n := f.current.Add(1)
+	defer f.current.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if n <= peak || f.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	select {
+	case <-f.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &fetcher.Response{Body: []byte(`{"v":1}`), ContentType: "application/json", StatusCode: 200}, nil
+}
+
+func newTestTask(t *testing.T, id string, interval time.Duration, f fetcher.Fetcher, scheduler *Scheduler) *Task {
+	t.Helper()
+	rule := &models.MonitorRule{
+		ID:            id,
+		URL:           "https://api.example.com/" + id,
+		ExtractorType: models.ExtractorJSON,
+		ExtractorExpr: "v",
+		Interval:      models.Duration(interval),
+	}
+	task, err := NewTask(rule, f, extractor.NewFactory(), nil)
+	require.NoError(t, err)
+	task.scheduler = scheduler
+	return task
+}
+
+// snapshot 在持有任务的锁时复制规则，避免与检查同时读写
+func snapshot(task *Task) models.MonitorRule {
+	task.mu.RLock()
+	defer task.mu.RUnlock()
+	return *task.rule
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("同时进行的检查不超过并发上限", func(t *testing.T) {
+		f := newBlockingFetcher()
+		scheduler := NewScheduler(2)
+		defer scheduler.Close()
+
+		for i := range 5 {
+			require.NoError(t, newTestTask(t, fmt.Sprintf("r%d", i), time.Hour, f, scheduler).Start())
+		}
+
+		assert.Eventually(t, func() bool { return f.current.Load() == 2 }, time.Second, 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, int32(2), f.calls.Load(), "达到并发上限后其他检查等待")
+
+		close(f.release)
+		assert.Eventually(t, func() bool { return f.calls.Load() == 5 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(2), f.peak.Load())
+	})
+
+	t.Run("按间隔定期检查并在停止后不再检查", func(t *testing.T) {
+		f := newBlockingFetcher()
+		close(f.release)
+		scheduler := NewScheduler(1)
+		defer scheduler.Close()
+
+		task := newTestTask(t, "r1", 20*time.Millisecond, f, scheduler)
+		require.NoError(t, task.Start())
+		assert.Eventually(t, func() bool { return f.calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+		assert.NotEmpty(t, snapshot(task).NextRun)
+
+		task.Stop()
+		assert.Eventually(t, func() bool { return scheduler.Len() == 0 }, time.Second, 5*time.Millisecond)
+		calls := f.calls.Load()
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(t, calls, f.calls.Load())
+		assert.Empty(t, snapshot(task).NextRun)
+	})
+
+	t.Run("停止任务时取消正在进行的检查", func(t *testing.T) {
+		f := newBlockingFetcher()
+		defer close(f.release)
+		outcomes := make(chan Outcome, 1)
+		scheduler := NewScheduler(1)
+		defer scheduler.Close()
+
+		task := newTestTask(t, "r1", time.Hour, f, scheduler)
+		task.outcomes = outcomes
+		require.NoError(t, task.Start())
+		assert.Eventually(t, func() bool { return f.current.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+		task.Stop()
+		assert.Eventually(t, func() bool { return f.current.Load() == 0 }, time.Second, 5*time.Millisecond)
+		assert.Zero(t, snapshot(task).ConsecutiveFailures, "取消的检查不计为失败")
+		assert.Empty(t, outcomes)
+	})
+
+	t.Run("更新任务时取消检查并按新配置重新检查", func(t *testing.T) {
+		f := newBlockingFetcher()
+		defer close(f.release)
+		scheduler := NewScheduler(1)
+		defer scheduler.Close()
+
+		task := newTestTask(t, "r1", time.Hour, f, scheduler)
+		require.NoError(t, task.Start())
+		assert.Eventually(t, func() bool { return f.current.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+		updated := snapshot(task)
+		updated.URL = "https://api.example.com/new"
+		require.NoError(t, task.Update(&updated))
+		assert.Eventually(t, func() bool { return f.calls.Load() == 2 }, time.Second, 5*time.Millisecond)
+		task.Stop()
+	})
+
+	t.Run("检查进行中不阻塞停止和更新", func(t *testing.T) {
+		f := newBlockingFetcher()
+		scheduler := NewScheduler(1)
+		defer scheduler.Close()
+		defer close(f.release)
+
+		task := newTestTask(t, "r1", time.Hour, f, scheduler)
+		require.NoError(t, task.Start())
+		assert.Eventually(t, func() bool { return f.current.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			updated := snapshot(task)
+			updated.Interval = models.Duration(time.Minute)
+			assert.NoError(t, task.Update(&updated))
+			assert.True(t, task.IsRunning())
+			task.Stop()
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("检查进行中时停止任务被阻塞")
+		}
+	})
+
+	t.Run("更新检查计划后重新计算下次检查时间", func(t *testing.T) {
+		f := newBlockingFetcher()
+		close(f.release)
+		scheduler := NewScheduler(1)
+		defer scheduler.Close()
+
+		task := newTestTask(t, "r1", time.Hour, f, scheduler)
+		require.NoError(t, task.Start())
+		assert.Eventually(t, func() bool { return snapshot(task).NextRun != "" }, time.Second, 5*time.Millisecond)
+
+		updated := snapshot(task)
+		updated.Interval = models.Duration(20 * time.Millisecond)
+		require.NoError(t, task.Update(&updated))
+		assert.Eventually(t, func() bool { return f.calls.Load() >= 3 }, time.Second, 5*time.Millisecond)
+		task.Stop()
+	})
+}
@@ -1,14 +1,15 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"sync"
 
 	"github.com/zx06/apiwatch/extractor"
 	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/history"
 	"github.com/zx06/apiwatch/models"
-	"github.com/zx06/apiwatch/notification"
 )
 
 // Service 监控服务接口
@@ -22,6 +23,9 @@ type Service interface {
 	// StopAll 停止所有任务
 	StopAll()
 
+	// Shutdown 停止所有任务并取消正在进行的检查，最多等待到 ctx 结束
+	Shutdown(ctx context.Context) error
+
 	// UpdateTask 更新任务配置
 	UpdateTask(rule *models.MonitorRule) error
 
@@ -33,6 +37,9 @@ type Service interface {
 
 	// IsTaskRunning 检查任务是否在运行
 	IsTaskRunning(ruleID string) bool
+
+	// Outcomes 返回检查结果通道，每次检查完成（包括失败）后发送一个结果
+	Outcomes() <-chan Outcome
 }
 
 // MonitorService 监控服务实现
@@ -40,25 +47,41 @@ type MonitorService struct {
 	tasks            map[string]*Task
 	fetcher          fetcher.Fetcher
 	extractorFactory *extractor.Factory
-	notifier         notification.Notifier
+	history          history.Store
 	mu               sync.RWMutex
 
+	// manual 为true时不启动定时检查，任务只能通过RunTaskOnce手动执行
+	manual bool
+
+	// scheduler 安排所有任务的定期检查，首次启动任务时创建
+	scheduler   *Scheduler
+	concurrency int
+
 	// 回调函数，用于通知规则更新
 	onRuleUpdate func(*models.MonitorRule)
+
+	// 检查结果通道
+	outcomes chan Outcome
+
+	// ctx 手动检查使用的上下文，关闭服务时取消
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewMonitorService 创建监控服务
 func NewMonitorService(
 	fetcher fetcher.Fetcher,
-	notifier notification.Notifier,
 	onRuleUpdate func(*models.MonitorRule),
 ) *MonitorService {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &MonitorService{
+		ctx:              ctx,
+		cancel:           cancel,
 		tasks:            make(map[string]*Task),
 		fetcher:          fetcher,
 		extractorFactory: extractor.NewFactory(),
-		notifier:         notifier,
 		onRuleUpdate:     onRuleUpdate,
+		outcomes:         make(chan Outcome, outcomeBufferSize),
 	}
 }
 
@@ -70,7 +93,7 @@ func (s *MonitorService) StartTask(rule *models.MonitorRule) error {
 	// 检查任务是否已存在
 	if task, exists := s.tasks[rule.ID]; exists {
 		if task.IsRunning() {
-			return fmt.Errorf("任务已在运行中: %s", rule.ID)
+			return fmt.Errorf("%w: %s", ErrTaskAlreadyRunning, rule.ID)
 		}
 		// 停止旧任务
 		task.Stop()
@@ -78,14 +101,22 @@ func (s *MonitorService) StartTask(rule *models.MonitorRule) error {
 	}
 
 	// 创建新任务
-	task, err := NewTask(rule, s.fetcher, s.extractorFactory, s.notifier, s.onRuleUpdate)
+	task, err := NewTask(rule, s.fetcher, s.extractorFactory, s.onRuleUpdate)
 	if err != nil {
 		return fmt.Errorf("创建任务失败: %w", err)
 	}
+	task.history = s.history
+	task.outcomes = s.outcomes
 
-	// 启动任务
-	if err := task.Start(); err != nil {
-		return fmt.Errorf("启动任务失败: %w", err)
+	// 启动任务（手动模式下只登记任务，不启动定时检查）
+	if !s.manual {
+		if s.scheduler == nil {
+			s.scheduler = NewScheduler(s.concurrency)
+		}
+		task.scheduler = s.scheduler
+		if err := task.Start(); err != nil {
+			return fmt.Errorf("启动任务失败: %w", err)
+		}
 	}
 
 	s.tasks[rule.ID] = task
@@ -105,7 +136,7 @@ func (s *MonitorService) StopTask(ruleID string) error {
 
 	task, exists := s.tasks[ruleID]
 	if !exists {
-		return fmt.Errorf("任务不存在: %s", ruleID)
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, ruleID)
 	}
 
 	task.Stop()
@@ -129,6 +160,34 @@ func (s *MonitorService) StopAll() {
 	slog.Info("监控服务已停止所有任务", "count", len(s.tasks))
 }
 
+// Shutdown 停止所有任务并取消正在进行的检查
+//
+// 等待正在进行的检查结束，ctx 结束时不再等待并返回错误。
+func (s *MonitorService) Shutdown(ctx context.Context) error {
+	s.cancel()
+	s.StopAll()
+
+	s.mu.RLock()
+	scheduler := s.scheduler
+	s.mu.RUnlock()
+	if scheduler == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待检查结束超时: %w", ctx.Err())
+	}
+}
+
 // UpdateTask 更新任务配置
 func (s *MonitorService) UpdateTask(rule *models.MonitorRule) error {
 	s.mu.Lock()
@@ -136,27 +195,14 @@ func (s *MonitorService) UpdateTask(rule *models.MonitorRule) error {
 
 	task, exists := s.tasks[rule.ID]
 	if !exists {
-		return fmt.Errorf("任务不存在: %s", rule.ID)
-	}
-
-	// 如果任务正在运行，需要重启
-	wasRunning := task.IsRunning()
-	if wasRunning {
-		task.Stop()
+		return fmt.Errorf("%w: %s", ErrTaskNotFound, rule.ID)
 	}
 
-	// 更新任务配置
+	// 更新任务配置，检查计划变化时调度器会重新计算下次检查时间
 	if err := task.Update(rule); err != nil {
 		return fmt.Errorf("更新任务失败: %w", err)
 	}
 
-	// 如果之前在运行，重新启动
-	if wasRunning {
-		if err := task.Start(); err != nil {
-			return fmt.Errorf("重启任务失败: %w", err)
-		}
-	}
-
 	slog.Info("监控服务已更新任务",
 		"rule_id", rule.ID,
 		"rule_name", rule.Name,
@@ -172,12 +218,12 @@ func (s *MonitorService) RunTaskOnce(ruleID string) error {
 	s.mu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("任务不存在或未启动: %s，请先启动监控", ruleID)
+		return fmt.Errorf("%w: %s，请先启动监控", ErrTaskNotRunning, ruleID)
 	}
 
 	slog.Info("手动执行任务检查", "rule_id", ruleID)
 
-	return task.RunOnce()
+	return task.RunOnce(s.ctx)
 }
 
 // GetTaskStatus 获取任务状态
@@ -214,17 +260,33 @@ func (s *MonitorService) GetActiveTasks() int {
 	return len(s.tasks)
 }
 
-// UpdateNotifier 更新通知器（用于Wails启动后替换通知器）
-func (s *MonitorService) UpdateNotifier(notifier notification.Notifier) {
+// SetManual 设置手动模式
+//
+// 手动模式下StartTask只登记任务而不启动定时检查，适用于命令行等一次性执行的场景。
+// 需要在启动任何任务之前调用。
+func (s *MonitorService) SetManual(manual bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.manual = manual
+}
 
-	s.notifier = notifier
+// SetConcurrency 设置同时执行定期检查的最大数量，不大于0时使用默认值，需要在启动任何任务之前调用
+func (s *MonitorService) SetConcurrency(concurrency int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.concurrency = concurrency
+}
 
-	// 更新所有活跃任务的通知器
-	for _, task := range s.tasks {
-		task.UpdateNotifier(notifier)
-	}
+// SetHistory 设置历史记录存储，需要在启动任何任务之前调用
+func (s *MonitorService) SetHistory(store history.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = store
+}
 
-	slog.Info("监控服务已更新通知器")
+// Outcomes 返回检查结果通道
+//
+// 通道有缓冲，消费者处理过慢导致通道写满时新的结果会被丢弃。
+func (s *MonitorService) Outcomes() <-chan Outcome {
+	return s.outcomes
 }
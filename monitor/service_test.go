@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/models"
+)
+
+// stuckFetcher 忽略取消，直到 release 关闭才返回
+type stuckFetcher struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *stuckFetcher) Fetch(ctx context.Context, req *fetcher.Request) (*fetcher.Response, error) {
+	close(f.started)
+	<-f.release
+	return nil, ctx.Err()
+}
+
+func TestMonitorService_Shutdown(t *testing.T) {
+	rule := func() *models.MonitorRule {
+		return &models.MonitorRule{
+			ID:            "r1",
+			URL:           "https://api.example.com",
+			ExtractorType: models.ExtractorJSON,
+			ExtractorExpr: "v",
+			Interval:      models.Duration(time.Hour),
+		}
+	}
+
+	t.Run("取消正在进行的检查", func(t *testing.T) {
+		f := newBlockingFetcher()
+		svc := NewMonitorService(f, nil)
+		require.NoError(t, svc.StartTask(rule()))
+		assert.Eventually(t, func() bool { return f.current.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, svc.Shutdown(ctx))
+		assert.Equal(t, int32(0), f.current.Load())
+		assert.False(t, svc.IsTaskRunning("r1"))
+	})
+
+	t.Run("超过期限后不再等待", func(t *testing.T) {
+		f := &stuckFetcher{started: make(chan struct{}), release: make(chan struct{})}
+		defer close(f.release)
+		svc := NewMonitorService(f, nil)
+		require.NoError(t, svc.StartTask(rule()))
+		<-f.started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := svc.Shutdown(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -1,31 +1,48 @@
 package monitor
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/zx06/apiwatch/diff"
 	"github.com/zx06/apiwatch/extractor"
 	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/history"
 	"github.com/zx06/apiwatch/models"
-	"github.com/zx06/apiwatch/notification"
 )
 
 // Task 监控任务
+//
+// 任务本身不启动goroutine，定期检查由 Scheduler 安排。
 type Task struct {
 	rule      *models.MonitorRule
 	fetcher   fetcher.Fetcher
 	extractor extractor.Extractor
-	notifier  notification.Notifier
+	history   history.Store
 
-	ticker  *time.Ticker
-	stopCh  chan struct{}
+	// mu 保护规则和运行状态，检查过程中的HTTP请求不持有该锁
 	mu      sync.RWMutex
 	running bool
 
+	// runMu 保证同一任务的检查（定期检查和手动检查）依次执行
+	runMu sync.Mutex
+
+	// cancel 取消正在进行的检查，没有检查时为nil
+	cancel context.CancelFunc
+
+	// scheduler 为nil时任务只能手动检查
+	scheduler *Scheduler
+
 	// 回调函数，用于通知状态变化
 	onUpdate func(*models.MonitorRule)
+
+	// 检查结果通道，为nil时不发送结果
+	outcomes chan<- Outcome
 }
 
 // NewTask 创建监控任务
@@ -33,7 +50,6 @@ func NewTask(
 	rule *models.MonitorRule,
 	fetcher fetcher.Fetcher,
 	extractorFactory *extractor.Factory,
-	notifier notification.Notifier,
 	onUpdate func(*models.MonitorRule),
 ) (*Task, error) {
 	// 创建提取器
@@ -46,89 +62,158 @@ func NewTask(
 		rule:      rule,
 		fetcher:   fetcher,
 		extractor: ext,
-		notifier:  notifier,
-		stopCh:    make(chan struct{}),
 		onUpdate:  onUpdate,
 	}, nil
 }
 
-// Start 启动监控任务
+// Start 启动监控任务，立即执行一次检查，之后按检查计划定期检查
 func (t *Task) Start() error {
 	t.mu.Lock()
-	defer t.mu.Unlock()
-
 	if t.running {
-		return fmt.Errorf("任务已在运行中")
+		t.mu.Unlock()
+		return ErrTaskAlreadyRunning
 	}
-
 	t.running = true
-	t.ticker = time.NewTicker(time.Duration(t.rule.Interval))
-
-	// 启动监控goroutine
-	go t.run()
 
 	slog.Info("监控任务已启动",
 		"rule_id", t.rule.ID,
 		"rule_name", t.rule.Name,
 		"interval", t.rule.Interval,
+		"schedule", t.rule.Schedule,
 	)
+	t.mu.Unlock()
 
+	if t.scheduler != nil {
+		t.scheduler.Add(t)
+	}
 	return nil
 }
 
-// Stop 停止监控任务
+// Stop 停止监控任务并取消正在进行的检查
 func (t *Task) Stop() {
 	t.mu.Lock()
-	defer t.mu.Unlock()
-
 	if !t.running {
+		t.mu.Unlock()
 		return
 	}
-
 	t.running = false
-	close(t.stopCh)
-
-	if t.ticker != nil {
-		t.ticker.Stop()
+	t.rule.NextRun = ""
+	if t.cancel != nil {
+		t.cancel()
 	}
 
 	slog.Info("监控任务已停止",
 		"rule_id", t.rule.ID,
 		"rule_name", t.rule.Name,
 	)
+	t.mu.Unlock()
+
+	if t.scheduler != nil {
+		t.scheduler.Remove(t)
+	}
 }
 
-// run 监控循环
-func (t *Task) run() {
-	// 立即执行一次检查
-	if err := t.RunOnce(); err != nil {
-		slog.Error("首次检查失败",
+// scheduleNext 计算 after 之后的下次检查时间并记录到规则中
+//
+// 任务已停止或无法计算时（如cron表达式没有可执行的时间）返回false，任务等待检查计划更新。
+func (t *Task) scheduleNext(after time.Time) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.running {
+		return time.Time{}, false
+	}
+
+	next, err := t.rule.NextRunAfter(after)
+	// 上次检查耗时超过间隔时跳过错过的时间点，避免连续检查
+	if now := time.Now(); err == nil && next.Before(now) {
+		next, err = t.rule.NextRunAfter(now)
+	}
+	if err != nil {
+		slog.Error("计算下次检查时间失败",
 			"rule_id", t.rule.ID,
 			"error", err,
 		)
+		t.rule.NextRun = ""
+		t.notifyUpdate()
+		return time.Time{}, false
+	}
+
+	t.rule.NextRun = next.Format(time.RFC3339)
+	t.notifyUpdate()
+	return next, true
+}
+
+// RunOnce 执行一次检查
+//
+// HTTP请求和内容提取期间不持有任务的锁，因此不会阻塞 Stop、Update 和 IsRunning。
+// ctx 取消、任务停止或更新时检查会被取消，返回 ErrCheckCanceled，不计为失败也不发送结果。
+func (t *Task) RunOnce(ctx context.Context) error {
+	t.runMu.Lock()
+	defer t.runMu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, ext, lastError := t.begin(cancel)
+	defer t.end()
+
+	startTime := time.Now()
+	resp, err := t.fetcher.Fetch(ctx, req)
+	if ctx.Err() != nil {
+		return t.canceled(ctx, lastError)
 	}
+	if err != nil {
+		// 证书已过期时TLS握手失败，按证书到期告警而不是请求失败
+		if expiredErr := t.expiredError(err); expiredErr != nil {
+			t.fail(StageCertificate, expiredErr, expiredErr, 0, startTime)
+			return expiredErr
+		}
 
-	// 定期检查
-	for {
-		select {
-		case <-t.stopCh:
-			return
-		case <-t.ticker.C:
-			if err := t.RunOnce(); err != nil {
-				slog.Error("定期检查失败",
-					"rule_id", t.rule.ID,
-					"error", err,
-				)
-			}
+		// 服务器返回错误状态码时记录状态码
+		statusCode := 0
+		var statusErr *fetcher.StatusError
+		if errors.As(err, &statusErr) {
+			statusCode = statusErr.StatusCode
 		}
+		t.fail(StageFetch, fmt.Errorf("HTTP请求失败: %w", err), err, statusCode, startTime)
+		return err
+	}
+
+	// 提取内容，证书提取器从服务器证书链提取
+	var content string
+	if certExt, ok := ext.(extractor.CertificateExtractor); ok {
+		content, err = certExt.ExtractCertificates(resp.Certificates, startTime)
+	} else {
+		content, err = ext.Extract(ctx, resp.Body, resp.ContentType)
+	}
+	if ctx.Err() != nil {
+		return t.canceled(ctx, lastError)
+	}
+	if err != nil {
+		t.fail(StageExtract, fmt.Errorf("内容提取失败: %w", err), err, resp.StatusCode, startTime)
+		return err
 	}
+
+	// 证书即将过期时视为检查失败，按规则的告警策略通知，续期后恢复
+	if err := t.checkExpiry(resp.Certificates, startTime); err != nil {
+		t.fail(StageCertificate, err, err, resp.StatusCode, startTime)
+		return err
+	}
+
+	t.complete(resp.StatusCode, content, startTime, lastError)
+	return nil
 }
 
-// RunOnce 执行一次检查
-func (t *Task) RunOnce() error {
+// begin 将状态更新为运行中，并返回本次检查使用的请求、提取器和上次的错误信息
+//
+// cancel 用于在任务停止或更新时取消本次检查。
+func (t *Task) begin(cancel context.CancelFunc) (*fetcher.Request, extractor.Extractor, string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	t.cancel = cancel
+
 	slog.Debug("开始检查",
 		"rule_id", t.rule.ID,
 		"rule_name", t.rule.Name,
@@ -136,33 +221,190 @@ func (t *Task) RunOnce() error {
 	)
 
 	// 更新状态为运行中
+	lastError := t.rule.ErrorMessage
 	t.rule.Status = models.StatusRunning
 	t.rule.ErrorMessage = ""
 	t.notifyUpdate()
 
-	// 发送HTTP请求
 	req := &fetcher.Request{
 		URL:     t.rule.URL,
 		Method:  t.rule.Method,
 		Headers: t.rule.Headers,
 		Body:    t.rule.Body,
+		Retry:   retryPolicy(t.rule.Retry),
+		Robots:  t.rule.ExtractorType == models.ExtractorCSS,
+		Proxy:   t.rule.Proxy,
+		Auth:    auth(t.rule.Auth),
+		TLS:     tlsConfig(t.rule.TLS),
+
+		// 证书监控只关心服务器证书，服务器返回错误状态码时同样检查
+		AnyStatus: t.rule.ExtractorType == models.ExtractorCert,
 	}
+	return req, t.extractor, lastError
+}
 
-	resp, err := t.fetcher.Fetch(req)
-	if err != nil {
-		t.handleError(fmt.Errorf("HTTP请求失败: %w", err))
-		return err
+// checkExpiry 证书监控规则的服务器证书剩余有效天数低于告警阈值时返回 ErrCertificateExpiring
+func (t *Task) checkExpiry(certs []*x509.Certificate, now time.Time) error {
+	t.mu.RLock()
+	enabled := t.rule.ExtractorType == models.ExtractorCert
+	threshold := t.rule.CertAlertThreshold()
+	t.mu.RUnlock()
+
+	if !enabled || len(certs) == 0 {
+		return nil
 	}
 
-	// 提取内容
-	content, err := t.extractor.Extract(resp.Body, resp.ContentType)
-	if err != nil {
-		t.handleError(fmt.Errorf("内容提取失败: %w", err))
-		return err
+	days := extractor.DaysUntilExpiry(certs[0], now)
+	if days < 0 {
+		return certificateExpired(certs[0])
+	}
+	if days < threshold {
+		return fmt.Errorf("%w: 剩余 %d 天（%s 到期），低于告警阈值 %d 天",
+			ErrCertificateExpiring, days, certs[0].NotAfter.UTC().Format(time.RFC3339), threshold)
+	}
+	return nil
+}
+
+// expiredError 证书监控规则因证书已过期导致TLS握手失败时返回 ErrCertificateExpiring，否则返回nil
+func (t *Task) expiredError(err error) error {
+	t.mu.RLock()
+	enabled := t.rule.ExtractorType == models.ExtractorCert
+	t.mu.RUnlock()
+
+	var certErr x509.CertificateInvalidError
+	if !enabled || !errors.As(err, &certErr) || certErr.Reason != x509.Expired || certErr.Cert == nil {
+		return nil
+	}
+	return certificateExpired(certErr.Cert)
+}
+
+// certificateExpired 返回证书已过期的 ErrCertificateExpiring
+func certificateExpired(cert *x509.Certificate) error {
+	return fmt.Errorf("%w: 证书已于 %s 过期", ErrCertificateExpiring, cert.NotAfter.UTC().Format(time.RFC3339))
+}
+
+// retryPolicy 将规则的重试策略转换为请求的重试策略，未配置时使用默认策略
+func retryPolicy(p *models.RetryPolicy) fetcher.RetryPolicy {
+	if p == nil {
+		return fetcher.RetryPolicy{}
 	}
+	return fetcher.RetryPolicy{
+		Timeout:     time.Duration(p.Timeout),
+		MaxAttempts: p.MaxAttempts,
+		BackoffBase: time.Duration(p.BackoffBase),
+		BackoffMax:  time.Duration(p.BackoffMax),
+		Jitter:      p.Jitter,
+		RetryStatus: p.RetryStatus,
+	}
+}
+
+// auth 将规则的认证配置转换为请求的认证方式，未配置时返回nil
+func auth(c *models.AuthConfig) *fetcher.Auth {
+	if c == nil {
+		return nil
+	}
+	return &fetcher.Auth{
+		Type:         fetcher.AuthType(c.Type),
+		Username:     c.Username,
+		Password:     c.Password,
+		Token:        c.Token,
+		Name:         c.Name,
+		Value:        c.Value,
+		InQuery:      c.In == models.APIKeyInQuery,
+		TokenURL:     c.TokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+	}
+}
+
+// tlsConfig 将规则的TLS设置转换为请求的TLS设置，未配置时返回nil
+func tlsConfig(c *models.TLSConfig) *fetcher.TLSConfig {
+	if c == nil {
+		return nil
+	}
+	return &fetcher.TLSConfig{
+		CAFile:             c.CAFile,
+		CertFile:           c.CertFile,
+		KeyFile:            c.KeyFile,
+		ServerName:         c.ServerName,
+		MinVersion:         models.TLSVersions[c.MinVersion],
+		Pins:               c.Pins,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+}
+
+// end 检查结束后清除取消函数
+func (t *Task) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancel = nil
+}
+
+// canceled 检查被取消时恢复检查前的错误信息，返回 ErrCheckCanceled
+func (t *Task) canceled(ctx context.Context, lastError string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.running && t.rule.ErrorMessage == "" && lastError != "" {
+		t.rule.ErrorMessage = lastError
+		t.rule.Status = models.StatusError
+		t.notifyUpdate()
+	}
+
+	slog.Info("检查已取消",
+		"rule_id", t.rule.ID,
+		"rule_name", t.rule.Name,
+	)
+	return fmt.Errorf("%w: %w", ErrCheckCanceled, context.Cause(ctx))
+}
+
+// fail 记录检查失败并发送结果
+func (t *Task) fail(stage ErrorStage, err, cause error, statusCode int, startTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.handleError(err, startTime)
+	t.emitError(stage, cause, statusCode, startTime)
+}
+
+// complete 记录检查成功的结果，检测内容变化并发送结果
+func (t *Task) complete(statusCode int, content string, startTime time.Time, lastError string) {
+	checkedAt := time.Now()
+	durationMs := checkedAt.Sub(startTime).Milliseconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
 	// 更新最后检查时间
-	t.rule.LastChecked = time.Now().Format(time.RFC3339)
+	t.rule.LastChecked = checkedAt.Format(time.RFC3339)
+
+	// 记录历史（存储会忽略与上一条相同的内容）
+	if t.history != nil {
+		entry := &history.Entry{
+			RuleID:     t.rule.ID,
+			Content:    content,
+			StatusCode: statusCode,
+			DurationMs: durationMs,
+			CheckedAt:  checkedAt,
+		}
+		if err := t.history.Record(entry); err != nil {
+			slog.Warn("记录历史失败",
+				"rule_id", t.rule.ID,
+				"error", err,
+			)
+		}
+	}
+
+	outcome := Outcome{
+		RuleID:     t.rule.ID,
+		Kind:       OutcomeUnchanged,
+		OldContent: t.rule.LastContent,
+		NewContent: content,
+		StatusCode: statusCode,
+		DurationMs: durationMs,
+		CheckedAt:  checkedAt,
+	}
 
 	// 检测内容变化
 	if t.rule.LastContent != "" && t.rule.LastContent != content {
@@ -172,16 +414,25 @@ func (t *Task) RunOnce() error {
 			"rule_name", t.rule.Name,
 		)
 
-		// 发送通知
-		if t.rule.NotifyEnabled {
-			if err := t.sendNotification(content); err != nil {
-				slog.Warn("发送通知失败",
-					"rule_id", t.rule.ID,
-					"error", err,
-				)
-			}
-		}
+		outcome.Kind = OutcomeChanged
+		outcome.Diff = diff.Compute(t.rule.LastContent, content)
+	}
+
+	// 从连续失败中恢复
+	if t.rule.ConsecutiveFailures > 0 {
+		outcome.Failures = t.rule.ConsecutiveFailures
+		outcome.FailingSince = t.failingSince()
+		outcome.LastError = lastError
+
+		slog.Info("监控已恢复",
+			"rule_id", t.rule.ID,
+			"rule_name", t.rule.Name,
+			"failures", t.rule.ConsecutiveFailures,
+		)
+		t.rule.ConsecutiveFailures = 0
+		t.rule.FailingSince = ""
 	}
+	t.emit(outcome)
 
 	// 更新内容
 	t.rule.LastContent = content
@@ -192,31 +443,36 @@ func (t *Task) RunOnce() error {
 		"rule_id", t.rule.ID,
 		"content_length", len(content),
 	)
-
-	return nil
 }
 
 // Update 更新任务配置
 func (t *Task) Update(rule *models.MonitorRule) error {
 	t.mu.Lock()
-	defer t.mu.Unlock()
 
 	// 检查是否需要重新创建提取器
 	if rule.ExtractorType != t.rule.ExtractorType || rule.ExtractorExpr != t.rule.ExtractorExpr {
 		factory := extractor.NewFactory()
 		ext, err := factory.Create(rule.ExtractorType, rule.ExtractorExpr)
 		if err != nil {
+			t.mu.Unlock()
 			return fmt.Errorf("创建提取器失败: %w", err)
 		}
 		t.extractor = ext
 	}
 
-	// 检查是否需要重新创建ticker
-	if rule.Interval != t.rule.Interval && t.running {
-		if t.ticker != nil {
-			t.ticker.Stop()
-		}
-		t.ticker = time.NewTicker(time.Duration(rule.Interval))
+	// 检查计划变化时需要重新计算下次检查时间
+	reschedule := rule.Interval != t.rule.Interval ||
+		rule.Schedule != t.rule.Schedule ||
+		rule.TimeZone != t.rule.TimeZone
+	if !reschedule {
+		rule.NextRun = t.rule.NextRun
+	}
+	reschedule = reschedule && t.running
+
+	// 取消使用旧配置进行的检查，之后按新配置立即重新检查
+	rerun := t.cancel != nil && t.running
+	if t.cancel != nil {
+		t.cancel()
 	}
 
 	// 更新规则
@@ -226,7 +482,16 @@ func (t *Task) Update(rule *models.MonitorRule) error {
 		"rule_id", t.rule.ID,
 		"rule_name", t.rule.Name,
 	)
-
+	t.mu.Unlock()
+
+	if t.scheduler != nil {
+		switch {
+		case rerun:
+			t.scheduler.RunNow(t)
+		case reschedule:
+			t.scheduler.Reschedule(t)
+		}
+	}
 	return nil
 }
 
@@ -244,34 +509,66 @@ func (t *Task) GetRule() *models.MonitorRule {
 	return t.rule
 }
 
-// handleError 处理错误
-func (t *Task) handleError(err error) {
+// handleError 处理错误，累计连续失败次数，调用方需持有锁
+func (t *Task) handleError(err error, startTime time.Time) {
 	t.rule.Status = models.StatusError
 	t.rule.ErrorMessage = err.Error()
+	t.rule.ConsecutiveFailures++
+	if t.rule.FailingSince == "" {
+		t.rule.FailingSince = startTime.Format(time.RFC3339)
+	}
 	t.notifyUpdate()
 
 	slog.Error("任务执行错误",
 		"rule_id", t.rule.ID,
 		"rule_name", t.rule.Name,
+		"failures", t.rule.ConsecutiveFailures,
 		"error", err,
 	)
 }
 
-// sendNotification 发送通知
-func (t *Task) sendNotification(newContent string) error {
-	title := fmt.Sprintf("内容变化: %s", t.rule.Name)
-
-	// 限制消息长度
-	message := newContent
-	if len(message) > 200 {
-		message = message[:200] + "..."
+// failingSince 解析本轮连续失败开始的时间，未记录或格式无效时返回零值
+func (t *Task) failingSince() time.Time {
+	since, err := time.Parse(time.RFC3339, t.rule.FailingSince)
+	if err != nil {
+		return time.Time{}
 	}
+	return since
+}
+
+// emitError 发送检查失败的结果
+func (t *Task) emitError(stage ErrorStage, err error, statusCode int, startTime time.Time) {
+	now := time.Now()
+	t.emit(Outcome{
+		RuleID:     t.rule.ID,
+		Kind:       OutcomeError,
+		OldContent: t.rule.LastContent,
+		StatusCode: statusCode,
+		DurationMs: now.Sub(startTime).Milliseconds(),
+		Stage:      stage,
+		Error:      err.Error(),
+		Err:        err,
+		CheckedAt:  now,
+
+		Failures:     t.rule.ConsecutiveFailures,
+		FailingSince: t.failingSince(),
+	})
+}
 
-	if t.rule.Description != "" {
-		message = t.rule.Description + "\n\n" + message
+// emit 发送检查结果，通道已满时丢弃结果，避免阻塞监控任务
+func (t *Task) emit(outcome Outcome) {
+	if t.outcomes == nil {
+		return
 	}
 
-	return t.notifier.Notify(title, message)
+	select {
+	case t.outcomes <- outcome:
+	default:
+		slog.Warn("检查结果通道已满，丢弃结果",
+			"rule_id", outcome.RuleID,
+			"kind", outcome.Kind,
+		)
+	}
 }
 
 // notifyUpdate 通知规则更新
@@ -280,10 +577,3 @@ func (t *Task) notifyUpdate() {
 		t.onUpdate(t.rule)
 	}
 }
-
-// UpdateNotifier 更新通知器
-func (t *Task) UpdateNotifier(notifier notification.Notifier) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.notifier = notifier
-}
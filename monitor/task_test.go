@@ -0,0 +1,193 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/extractor"
+	"github.com/zx06/apiwatch/fetcher"
+	"github.com/zx06/apiwatch/models"
+)
+
+// stubFetcher 返回预设的响应或错误
+type stubFetcher struct {
+	resp *fetcher.Response
+	err  error
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, req *fetcher.Request) (*fetcher.Response, error) {
+	return f.resp, f.err
+}
+
+func jsonResponse(body string) *fetcher.Response {
+	return &fetcher.Response{Body: []byte(body), ContentType: "application/json", StatusCode: 200}
+}
+
+func TestTask_Outcomes(t *testing.T) {
+	f := &stubFetcher{resp: jsonResponse(`{"v":1}`)}
+	task := newTestTask(t, "outcome", time.Minute, f, nil)
+	outcomes := make(chan Outcome, 1)
+	task.outcomes = outcomes
+
+	t.Run("首次检查没有旧内容", func(t *testing.T) {
+		require.NoError(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, "outcome", outcome.RuleID)
+		assert.Equal(t, OutcomeUnchanged, outcome.Kind)
+		assert.Empty(t, outcome.OldContent)
+		assert.Equal(t, "1", outcome.NewContent)
+		assert.Equal(t, 200, outcome.StatusCode)
+		assert.Nil(t, outcome.Diff)
+	})
+
+	t.Run("内容未变化", func(t *testing.T) {
+		require.NoError(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeUnchanged, outcome.Kind)
+		assert.Equal(t, "1", outcome.OldContent)
+	})
+
+	t.Run("内容变化时包含差异", func(t *testing.T) {
+		f.resp = jsonResponse(`{"v":2}`)
+		require.NoError(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeChanged, outcome.Kind)
+		assert.Equal(t, "1", outcome.OldContent)
+		assert.Equal(t, "2", outcome.NewContent)
+		assert.NotNil(t, outcome.Diff)
+	})
+
+	t.Run("请求失败", func(t *testing.T) {
+		f.resp, f.err = nil, &fetcher.StatusError{StatusCode: 502, Status: "502 Bad Gateway"}
+		require.Error(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeError, outcome.Kind)
+		assert.Equal(t, StageFetch, outcome.Stage)
+		assert.Equal(t, 502, outcome.StatusCode)
+		assert.Equal(t, 1, outcome.Failures)
+		assert.NotEmpty(t, outcome.Error)
+		assert.Equal(t, models.StatusError, snapshot(task).Status)
+	})
+
+	t.Run("提取失败累计连续失败次数", func(t *testing.T) {
+		f.resp, f.err = jsonResponse(`{"other":1}`), nil
+		require.Error(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeError, outcome.Kind)
+		assert.Equal(t, StageExtract, outcome.Stage)
+		assert.Equal(t, 2, outcome.Failures)
+		assert.False(t, outcome.FailingSince.IsZero())
+	})
+
+	t.Run("恢复时包含此前的失败信息", func(t *testing.T) {
+		f.resp = jsonResponse(`{"v":2}`)
+		require.NoError(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeUnchanged, outcome.Kind)
+		assert.Equal(t, 2, outcome.Failures)
+		assert.NotEmpty(t, outcome.LastError)
+		assert.Equal(t, 0, snapshot(task).ConsecutiveFailures)
+	})
+}
+
+// certFetcher 返回指定到期时间的服务器证书，记录最后一次请求
+type certFetcher struct {
+	notAfter time.Time
+	req      *fetcher.Request
+}
+
+func (f *certFetcher) Fetch(ctx context.Context, req *fetcher.Request) (*fetcher.Response, error) {
+	f.req = req
+	cert := &x509.Certificate{DNSNames: []string{"example.com"}, NotAfter: f.notAfter}
+	return &fetcher.Response{StatusCode: 200, Certificates: []*x509.Certificate{cert}}, nil
+}
+
+func TestTask_CertMonitor(t *testing.T) {
+	newCertTask := func(t *testing.T, notAfter time.Time) (*Task, chan Outcome) {
+		t.Helper()
+		rule := &models.MonitorRule{
+			ID:            "cert",
+			URL:           "https://example.com",
+			ExtractorType: models.ExtractorCert,
+			ExtractorExpr: "days,sans",
+			Interval:      models.Duration(time.Hour),
+		}
+		task, err := NewTask(rule, &certFetcher{notAfter: notAfter}, extractor.NewFactory(), nil)
+		require.NoError(t, err)
+		outcomes := make(chan Outcome, 1)
+		task.outcomes = outcomes
+		return task, outcomes
+	}
+
+	t.Run("提取服务器证书的字段", func(t *testing.T) {
+		task, outcomes := newCertTask(t, time.Now().Add(30*24*time.Hour+time.Hour))
+
+		require.NoError(t, task.RunOnce(context.Background()))
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeUnchanged, outcome.Kind)
+		assert.Equal(t, "days: 30\nsans: example.com", outcome.NewContent)
+		assert.True(t, task.fetcher.(*certFetcher).req.AnyStatus, "证书监控不检查状态码")
+	})
+
+	t.Run("低于告警阈值时检查失败", func(t *testing.T) {
+		task, outcomes := newCertTask(t, time.Now().Add(5*24*time.Hour+time.Hour))
+
+		err := task.RunOnce(context.Background())
+		require.ErrorIs(t, err, ErrCertificateExpiring)
+		assert.Contains(t, err.Error(), "剩余 5 天")
+
+		outcome := <-outcomes
+		assert.Equal(t, OutcomeError, outcome.Kind)
+		assert.Equal(t, StageCertificate, outcome.Stage)
+		assert.Equal(t, 1, outcome.Failures)
+		assert.Equal(t, models.StatusError, snapshot(task).Status)
+	})
+
+	t.Run("自定义告警阈值", func(t *testing.T) {
+		task, outcomes := newCertTask(t, time.Now().Add(20*24*time.Hour+time.Hour))
+		task.rule.CertAlertDays = 30
+
+		require.ErrorIs(t, task.RunOnce(context.Background()), ErrCertificateExpiring)
+		assert.Equal(t, StageCertificate, (<-outcomes).Stage)
+	})
+
+	t.Run("已过期", func(t *testing.T) {
+		task, outcomes := newCertTask(t, time.Now().Add(-time.Hour))
+
+		err := task.RunOnce(context.Background())
+		require.ErrorIs(t, err, ErrCertificateExpiring)
+		assert.Contains(t, err.Error(), "过期")
+		<-outcomes
+	})
+
+	t.Run("证书过期导致握手失败时按到期告警", func(t *testing.T) {
+		notAfter := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		handshakeErr := &url.Error{Op: "Get", URL: "https://example.com", Err: &tls.CertificateVerificationError{
+			Err: x509.CertificateInvalidError{Cert: &x509.Certificate{NotAfter: notAfter}, Reason: x509.Expired},
+		}}
+		task, outcomes := newCertTask(t, time.Time{})
+		task.fetcher = &stubFetcher{err: fmt.Errorf("发送请求失败: %w", handshakeErr)}
+
+		err := task.RunOnce(context.Background())
+		require.ErrorIs(t, err, ErrCertificateExpiring)
+		assert.Contains(t, err.Error(), "证书已于 2026-01-01T00:00:00Z 过期")
+		assert.Equal(t, StageCertificate, (<-outcomes).Stage)
+	})
+
+	t.Run("其他证书错误按请求失败", func(t *testing.T) {
+		task, outcomes := newCertTask(t, time.Time{})
+		task.fetcher = &stubFetcher{err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}
+
+		err := task.RunOnce(context.Background())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCertificateExpiring)
+		assert.Equal(t, StageFetch, (<-outcomes).Stage)
+	})
+}
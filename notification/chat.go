@@ -0,0 +1,427 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zx06/apiwatch/models"
+)
+
+const (
+	// DefaultChatTimeout 聊天平台请求的默认超时时间
+	DefaultChatTimeout = 10 * time.Second
+
+	// maxChatContent 消息中差异/正文部分的最大字符数，保证不超过各平台的消息长度限制
+	maxChatContent = 1500
+
+	// telegramAPIBase Telegram Bot API地址
+	telegramAPIBase = "https://api.telegram.org"
+)
+
+// ChatNotifier 聊天平台机器人通知器
+//
+// 支持Slack、Discord、Telegram、钉钉、飞书和企业微信，按各平台的消息格式发送包含
+// 规则名称、链接和差异的富文本消息。
+type ChatNotifier struct {
+	config models.ChatConfig
+	client *http.Client
+
+	apiBase string
+	now     func() time.Time
+}
+
+// NewChatNotifier 创建聊天平台通知器
+func NewChatNotifier(config models.ChatConfig) *ChatNotifier {
+	timeout := time.Duration(config.Timeout)
+	if timeout <= 0 {
+		timeout = DefaultChatTimeout
+	}
+
+	return &ChatNotifier{
+		config:  config,
+		client:  &http.Client{Timeout: timeout},
+		apiBase: telegramAPIBase,
+		now:     time.Now,
+	}
+}
+
+// Notify 发送只包含标题和正文的通知
+func (n *ChatNotifier) Notify(title, message string) error {
+	return n.NotifyMessage(&Message{
+		Title:     title,
+		Body:      message,
+		Timestamp: n.now(),
+	})
+}
+
+// NotifyMessage 发送包含完整信息的通知
+func (n *ChatNotifier) NotifyMessage(msg *Message) error {
+	endpoint, payload, err := n.buildRequest(newChatContent(msg))
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化%s消息失败: %w", n.config.Platform, err)
+	}
+
+	if err := n.post(endpoint, body); err != nil {
+		return fmt.Errorf("发送%s通知失败: %w", n.config.Platform, err)
+	}
+	return nil
+}
+
+// buildRequest 按平台生成请求地址和消息体
+func (n *ChatNotifier) buildRequest(c *chatContent) (string, interface{}, error) {
+	switch n.config.Platform {
+	case models.ChatSlack:
+		return n.config.WebhookURL, slackPayload(c), nil
+	case models.ChatDiscord:
+		return n.config.WebhookURL, discordPayload(c), nil
+	case models.ChatTelegram:
+		endpoint := n.apiBase + "/bot" + n.config.BotToken + "/sendMessage"
+		return endpoint, telegramPayload(c, n.config.ChatID), nil
+	case models.ChatDingTalk:
+		endpoint, err := n.dingTalkURL()
+		if err != nil {
+			return "", nil, err
+		}
+		return endpoint, dingTalkPayload(c), nil
+	case models.ChatFeishu:
+		return n.config.WebhookURL, n.feishuPayload(c), nil
+	case models.ChatWeCom:
+		return n.config.WebhookURL, weComPayload(c), nil
+	default:
+		return "", nil, fmt.Errorf("不支持的聊天平台: %s", n.config.Platform)
+	}
+}
+
+// post 发送请求并检查平台返回的错误码
+func (n *ChatNotifier) post(endpoint string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: 创建请求失败: %w", ErrPermanent, redactEndpoint(err))
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "URL-Monitor/1.0")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", redactEndpoint(err))
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	err = checkChatResponse(data)
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		err = fmt.Errorf("HTTP错误: %s", resp.Status)
+	}
+
+	// 服务端错误和限流可以重试，其他客户端错误（如令牌无效或已被撤销）重试也不会成功
+	if err != nil && resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	return err
+}
+
+// redactEndpoint 将错误信息中的请求地址替换为只包含协议和主机的地址
+//
+// Webhook地址和Telegram Bot API地址本身包含密钥，错误信息会保存到投递记录、通过API返回并写入日志。
+func redactEndpoint(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil && u.Host != "" {
+		urlErr.URL = u.Scheme + "://" + u.Host + "/..."
+	} else {
+		urlErr.URL = "..."
+	}
+	return err
+}
+
+// chatResponse 各平台响应中的错误字段
+type chatResponse struct {
+	ErrCode     *int   `json:"errcode"` // 钉钉、企业微信
+	ErrMsg      string `json:"errmsg"`
+	Code        *int   `json:"code"` // 飞书
+	Msg         string `json:"msg"`
+	OK          *bool  `json:"ok"` // Telegram
+	Description string `json:"description"`
+}
+
+// checkChatResponse 检查响应体中的业务错误（钉钉、飞书等在HTTP 200中返回错误码）
+func checkChatResponse(data []byte) error {
+	var resp chatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		// Slack返回纯文本，Discord返回空响应
+		return nil
+	}
+
+	switch {
+	case resp.ErrCode != nil && *resp.ErrCode != 0:
+		return fmt.Errorf("平台返回错误: %d %s", *resp.ErrCode, resp.ErrMsg)
+	case resp.Code != nil && *resp.Code != 0:
+		return fmt.Errorf("平台返回错误: %d %s", *resp.Code, resp.Msg)
+	case resp.OK != nil && !*resp.OK:
+		return fmt.Errorf("平台返回错误: %s", resp.Description)
+	}
+	return nil
+}
+
+// chatContent 与平台无关的消息内容
+type chatContent struct {
+	Title       string
+	URL         string
+	Description string
+
+	// Summary 差异摘要，例如 "+1 -1 行"
+	Summary string
+
+	// Code 以代码块显示的差异；为空时显示Text
+	Code string
+	Text string
+
+	Timestamp time.Time
+}
+
+// newChatContent 从通知信息生成消息内容
+func newChatContent(msg *Message) *chatContent {
+	c := &chatContent{
+		Title:       msg.Title,
+		URL:         msg.URL,
+		Description: msg.Description,
+		Timestamp:   msg.Timestamp,
+	}
+
+	// 用户模板生成的正文原样发送
+	if msg.Templated {
+		c.Description = ""
+		c.Text = truncateRunes(msg.Body, maxChatContent)
+		return c
+	}
+
+	if msg.Diff != nil && msg.Diff.Unified != "" {
+		c.Summary = msg.Diff.Summary()
+		c.Code = truncateRunes(strings.TrimSuffix(msg.Diff.Unified, "\n"), maxChatContent)
+	} else {
+		c.Text = truncateRunes(msg.Body, maxChatContent)
+	}
+	return c
+}
+
+// markdown 生成通用Markdown文本（钉钉、企业微信使用）
+func (c *chatContent) markdown() string {
+	var sb strings.Builder
+	sb.WriteString("### " + c.Title + "\n\n")
+	if c.URL != "" {
+		fmt.Fprintf(&sb, "[%s](%s)\n\n", c.URL, c.URL)
+	}
+	if c.Description != "" {
+		sb.WriteString(c.Description + "\n\n")
+	}
+	if c.Code != "" {
+		sb.WriteString(c.Summary + "\n\n```\n" + escapeFence(c.Code) + "\n```\n")
+	} else if c.Text != "" {
+		sb.WriteString(c.Text + "\n")
+	}
+	return sb.String()
+}
+
+// slackPayload 生成Slack incoming webhook消息（Block Kit）
+func slackPayload(c *chatContent) map[string]interface{} {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+	heading := "*" + escape(c.Title) + "*"
+	if c.URL != "" {
+		heading += "\n<" + c.URL + "|" + escape(c.URL) + ">"
+	}
+	if c.Description != "" {
+		heading += "\n" + escape(c.Description)
+	}
+
+	blocks := []map[string]interface{}{
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": heading}},
+	}
+	if c.Code != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": escape(c.Summary) + "\n```" + escape(escapeFence(c.Code)) + "```"},
+		})
+	} else if c.Text != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": escape(c.Text)},
+		})
+	}
+
+	return map[string]interface{}{
+		"text":   c.Title,
+		"blocks": blocks,
+	}
+}
+
+// discordPayload 生成Discord webhook消息（embed）
+func discordPayload(c *chatContent) map[string]interface{} {
+	var description strings.Builder
+	if c.Description != "" {
+		description.WriteString(c.Description + "\n")
+	}
+	if c.Code != "" {
+		description.WriteString(c.Summary + "\n```diff\n" + escapeFence(c.Code) + "\n```")
+	} else {
+		description.WriteString(c.Text)
+	}
+
+	embed := map[string]interface{}{
+		"title":       truncateRunes(c.Title, 256),
+		"description": description.String(),
+		"color":       0x2f81f7,
+	}
+	if c.URL != "" {
+		embed["url"] = c.URL
+	}
+	if !c.Timestamp.IsZero() {
+		embed["timestamp"] = c.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	return map[string]interface{}{
+		"embeds": []interface{}{embed},
+	}
+}
+
+// telegramPayload 生成Telegram sendMessage请求（HTML格式）
+func telegramPayload(c *chatContent, chatID string) map[string]interface{} {
+	var sb strings.Builder
+	if c.URL != "" {
+		fmt.Fprintf(&sb, "<b><a href=\"%s\">%s</a></b>\n", html.EscapeString(c.URL), html.EscapeString(c.Title))
+	} else {
+		sb.WriteString("<b>" + html.EscapeString(c.Title) + "</b>\n")
+	}
+	if c.Description != "" {
+		sb.WriteString(html.EscapeString(c.Description) + "\n")
+	}
+	if c.Code != "" {
+		sb.WriteString(html.EscapeString(c.Summary) + "\n<pre>" + html.EscapeString(c.Code) + "</pre>")
+	} else {
+		sb.WriteString(html.EscapeString(c.Text))
+	}
+
+	return map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     sb.String(),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+}
+
+// dingTalkPayload 生成钉钉机器人Markdown消息
+func dingTalkPayload(c *chatContent) map[string]interface{} {
+	return map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": c.Title,
+			"text":  c.markdown(),
+		},
+	}
+}
+
+// dingTalkURL 返回带签名参数的钉钉Webhook地址
+//
+// 签名为以密钥对 "<毫秒时间戳>\n<密钥>" 计算的HMAC-SHA256，Base64编码后作为sign参数。
+func (n *ChatNotifier) dingTalkURL() (string, error) {
+	if n.config.Secret == "" {
+		return n.config.WebhookURL, nil
+	}
+
+	u, err := url.Parse(n.config.WebhookURL)
+	if err != nil {
+		return "", fmt.Errorf("无效的钉钉Webhook地址: %w", redactEndpoint(err))
+	}
+
+	timestamp := strconv.FormatInt(n.now().UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(n.config.Secret))
+	mac.Write([]byte(timestamp + "\n" + n.config.Secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// feishuPayload 生成飞书机器人富文本消息，配置了密钥时附带签名
+//
+// 飞书签名以 "<秒级时间戳>\n<密钥>" 作为HMAC-SHA256的密钥、对空消息计算，Base64编码。
+func (n *ChatNotifier) feishuPayload(c *chatContent) map[string]interface{} {
+	content := make([][]map[string]string, 0, 3)
+	if c.URL != "" {
+		content = append(content, []map[string]string{{"tag": "a", "text": c.URL, "href": c.URL}})
+	}
+	if c.Description != "" {
+		content = append(content, []map[string]string{{"tag": "text", "text": c.Description}})
+	}
+	if c.Code != "" {
+		content = append(content, []map[string]string{{"tag": "text", "text": c.Summary + "\n" + c.Code}})
+	} else if c.Text != "" {
+		content = append(content, []map[string]string{{"tag": "text", "text": c.Text}})
+	}
+
+	payload := map[string]interface{}{
+		"msg_type": "post",
+		"content": map[string]interface{}{
+			"post": map[string]interface{}{
+				"zh_cn": map[string]interface{}{
+					"title":   c.Title,
+					"content": content,
+				},
+			},
+		},
+	}
+
+	if n.config.Secret != "" {
+		timestamp := strconv.FormatInt(n.now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+n.config.Secret))
+		payload["timestamp"] = timestamp
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	return payload
+}
+
+// weComPayload 生成企业微信机器人Markdown消息
+func weComPayload(c *chatContent) map[string]interface{} {
+	return map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": c.markdown(),
+		},
+	}
+}
+
+// escapeFence 在内容中的 ``` 中插入零宽空格，避免提前结束代码块
+func escapeFence(s string) string {
+	return strings.ReplaceAll(s, "```", "`\u200b``")
+}
+
+// truncateRunes 按字符数截断文本
+func truncateRunes(s string, maxRunes int) string {
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxRunes-3]) + "..."
+}
@@ -0,0 +1,289 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+)
+
+// chatRequest 模拟平台收到的请求
+type chatRequest struct {
+	path  string
+	query url.Values
+	body  map[string]interface{}
+}
+
+// newChatServer 启动模拟聊天平台，按 status 和 response 响应所有请求
+func newChatServer(t *testing.T, status int, response string) (*httptest.Server, *chatRequest) {
+	t.Helper()
+
+	received := &chatRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.path = r.URL.Path
+		received.query = r.URL.Query()
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &received.body))
+
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// newTestChatMessage 创建测试用的内容变化通知
+func newTestChatMessage() *Message {
+	return &Message{
+		Title:       "内容变化: 价格",
+		RuleID:      "rule-1",
+		RuleName:    "价格",
+		URL:         "https://example.com/price",
+		Description: "商品<价格>",
+		OldContent:  "100",
+		NewContent:  "120",
+		Diff:        diff.Compute("100", "120"),
+		Timestamp:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// path 按路径读取嵌套的JSON字段
+func path(t *testing.T, v interface{}, keys ...interface{}) interface{} {
+	t.Helper()
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			require.True(t, ok, "期望对象: %v", key)
+			v = m[k]
+		case int:
+			a, ok := v.([]interface{})
+			require.True(t, ok, "期望数组: %v", key)
+			require.Greater(t, len(a), k)
+			v = a[k]
+		}
+	}
+	return v
+}
+
+func TestChatNotifier_Slack(t *testing.T) {
+	server, received := newChatServer(t, http.StatusOK, "ok")
+	n := NewChatNotifier(models.ChatConfig{Platform: models.ChatSlack, WebhookURL: server.URL})
+	require.NoError(t, n.NotifyMessage(newTestChatMessage()))
+
+	assert.Equal(t, "内容变化: 价格", received.body["text"])
+	heading := path(t, received.body, "blocks", 0, "text", "text").(string)
+	assert.Contains(t, heading, "*内容变化: 价格*")
+	assert.Contains(t, heading, "<https://example.com/price|https://example.com/price>")
+	assert.Contains(t, heading, "商品&lt;价格&gt;")
+
+	code := path(t, received.body, "blocks", 1, "text", "text").(string)
+	assert.Contains(t, code, "+1 -1 行")
+	assert.Contains(t, code, "```--- old")
+	assert.Contains(t, code, "+120")
+}
+
+func TestChatNotifier_Discord(t *testing.T) {
+	server, received := newChatServer(t, http.StatusNoContent, "")
+	n := NewChatNotifier(models.ChatConfig{Platform: models.ChatDiscord, WebhookURL: server.URL})
+	require.NoError(t, n.NotifyMessage(newTestChatMessage()))
+
+	embed := path(t, received.body, "embeds", 0)
+	assert.Equal(t, "内容变化: 价格", path(t, embed, "title"))
+	assert.Equal(t, "https://example.com/price", path(t, embed, "url"))
+	assert.Equal(t, "2024-01-02T03:04:05Z", path(t, embed, "timestamp"))
+	assert.Contains(t, path(t, embed, "description"), "```diff\n--- old")
+}
+
+func TestChatNotifier_Telegram(t *testing.T) {
+	server, received := newChatServer(t, http.StatusOK, `{"ok":true,"result":{}}`)
+	n := NewChatNotifier(models.ChatConfig{Platform: models.ChatTelegram, BotToken: "123:abc", ChatID: "-100"})
+	n.apiBase = server.URL
+	require.NoError(t, n.NotifyMessage(newTestChatMessage()))
+
+	assert.Equal(t, "/bot123:abc/sendMessage", received.path)
+	assert.Equal(t, "-100", received.body["chat_id"])
+	assert.Equal(t, "HTML", received.body["parse_mode"])
+	text := received.body["text"].(string)
+	assert.Contains(t, text, `<b><a href="https://example.com/price">内容变化: 价格</a></b>`)
+	assert.Contains(t, text, "商品&lt;价格&gt;")
+	assert.Contains(t, text, "<pre>--- old")
+
+	t.Run("平台返回错误", func(t *testing.T) {
+		server, _ := newChatServer(t, http.StatusBadRequest, `{"ok":false,"description":"Bad Request: chat not found"}`)
+		n.apiBase = server.URL
+		err := n.Notify("标题", "正文")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "chat not found")
+	})
+
+	t.Run("错误信息不包含令牌", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		n.apiBase = server.URL
+		err := n.Notify("标题", "正文")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "123:abc")
+		assert.Contains(t, err.Error(), server.URL+"/...")
+	})
+}
+
+func TestChatNotifier_PermanentErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		response  string
+		permanent bool
+	}{
+		{name: "令牌无效", status: http.StatusUnauthorized, response: `{"ok":false,"description":"Unauthorized"}`, permanent: true},
+		{name: "机器人不存在", status: http.StatusNotFound, response: `{"ok":false,"description":"Not Found"}`, permanent: true},
+		{name: "没有错误信息的客户端错误", status: http.StatusForbidden, permanent: true},
+		{name: "限流", status: http.StatusTooManyRequests, response: `{"ok":false,"description":"Too Many Requests"}`},
+		{name: "服务端错误", status: http.StatusBadGateway},
+		{name: "业务错误码", status: http.StatusOK, response: `{"ok":false,"description":"Internal Error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newChatServer(t, tt.status, tt.response)
+			n := NewChatNotifier(models.ChatConfig{Platform: models.ChatTelegram, BotToken: "123:abc", ChatID: "-100"})
+			n.apiBase = server.URL
+
+			err := n.Notify("标题", "正文")
+			require.Error(t, err)
+			assert.Equal(t, tt.permanent, errors.Is(err, ErrPermanent))
+		})
+	}
+}
+
+func TestChatNotifier_RedactWebhookURL(t *testing.T) {
+	for _, webhookURL := range []string{
+		"http://127.0.0.1:1/services/T000/B000/XXXXSECRET",
+		"http://127.0.0.1:1/robot/send?access_token=XXXXSECRET",
+		"http://127.0.0.1:1/robot/send?key=XXXXSECRET\x7f",
+		"http://[::1:XXXXSECRET/robot/send",
+	} {
+		for _, platform := range []models.ChatPlatform{models.ChatSlack, models.ChatDingTalk, models.ChatWeCom} {
+			n := NewChatNotifier(models.ChatConfig{Platform: platform, WebhookURL: webhookURL, Secret: "sign"})
+			err := n.Notify("标题", "正文")
+			require.Error(t, err, webhookURL)
+			assert.NotContains(t, err.Error(), "XXXXSECRET", webhookURL)
+		}
+	}
+}
+
+func TestChatNotifier_DingTalk(t *testing.T) {
+	server, received := newChatServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	n := NewChatNotifier(models.ChatConfig{
+		Platform:   models.ChatDingTalk,
+		WebhookURL: server.URL + "/robot/send?access_token=token",
+		Secret:     "SECdemo",
+	})
+	now := time.UnixMilli(1700000000123)
+	n.now = func() time.Time { return now }
+	require.NoError(t, n.NotifyMessage(newTestChatMessage()))
+
+	t.Run("签名", func(t *testing.T) {
+		assert.Equal(t, "token", received.query.Get("access_token"))
+		assert.Equal(t, "1700000000123", received.query.Get("timestamp"))
+
+		mac := hmac.New(sha256.New, []byte("SECdemo"))
+		mac.Write([]byte("1700000000123\nSECdemo"))
+		assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), received.query.Get("sign"))
+	})
+
+	t.Run("Markdown消息", func(t *testing.T) {
+		assert.Equal(t, "markdown", received.body["msgtype"])
+		assert.Equal(t, "内容变化: 价格", path(t, received.body, "markdown", "title"))
+		text := path(t, received.body, "markdown", "text").(string)
+		assert.Contains(t, text, "### 内容变化: 价格")
+		assert.Contains(t, text, "[https://example.com/price](https://example.com/price)")
+		assert.Contains(t, text, "```\n--- old")
+	})
+
+	t.Run("平台返回错误码", func(t *testing.T) {
+		server, _ := newChatServer(t, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`)
+		n := NewChatNotifier(models.ChatConfig{Platform: models.ChatDingTalk, WebhookURL: server.URL})
+		err := n.Notify("标题", "正文")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sign not match")
+	})
+}
+
+func TestChatNotifier_Feishu(t *testing.T) {
+	server, received := newChatServer(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	n := NewChatNotifier(models.ChatConfig{Platform: models.ChatFeishu, WebhookURL: server.URL, Secret: "demo"})
+	n.now = func() time.Time { return time.Unix(1700000000, 0) }
+	require.NoError(t, n.NotifyMessage(newTestChatMessage()))
+
+	t.Run("签名", func(t *testing.T) {
+		assert.Equal(t, "1700000000", received.body["timestamp"])
+		mac := hmac.New(sha256.New, []byte("1700000000\ndemo"))
+		assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), received.body["sign"])
+	})
+
+	t.Run("富文本消息", func(t *testing.T) {
+		assert.Equal(t, "post", received.body["msg_type"])
+		post := path(t, received.body, "content", "post", "zh_cn")
+		assert.Equal(t, "内容变化: 价格", path(t, post, "title"))
+		assert.Equal(t, "https://example.com/price", path(t, post, "content", 0, 0, "href"))
+		assert.Contains(t, path(t, post, "content", 2, 0, "text"), "+120")
+	})
+
+	t.Run("平台返回错误码", func(t *testing.T) {
+		server, _ := newChatServer(t, http.StatusOK, `{"code":19021,"msg":"sign match fail"}`)
+		n := NewChatNotifier(models.ChatConfig{Platform: models.ChatFeishu, WebhookURL: server.URL})
+		err := n.Notify("标题", "正文")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "19021")
+	})
+}
+
+func TestChatNotifier_WeCom(t *testing.T) {
+	server, received := newChatServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	n := NewChatNotifier(models.ChatConfig{Platform: models.ChatWeCom, WebhookURL: server.URL})
+	require.NoError(t, n.Notify("标题", "正文"))
+
+	assert.Equal(t, "markdown", received.body["msgtype"])
+	assert.Equal(t, "### 标题\n\n正文\n", path(t, received.body, "markdown", "content"))
+}
+
+func TestChatContent(t *testing.T) {
+	t.Run("差异过长时截断", func(t *testing.T) {
+		msg := newTestChatMessage()
+		long := make([]byte, maxChatContent*2)
+		for i := range long {
+			long[i] = 'a'
+		}
+		msg.Diff = diff.Compute("", string(long))
+
+		c := newChatContent(msg)
+		assert.Equal(t, maxChatContent, len([]rune(c.Code)))
+	})
+
+	t.Run("模板生成的正文原样发送", func(t *testing.T) {
+		msg := newTestChatMessage()
+		msg.Body = "自定义正文"
+		msg.Templated = true
+
+		c := newChatContent(msg)
+		assert.Equal(t, "自定义正文", c.Text)
+		assert.Empty(t, c.Code)
+		assert.Empty(t, c.Description)
+	})
+
+	t.Run("代码块标记被转义", func(t *testing.T) {
+		assert.NotContains(t, escapeFence("a```b"), "```")
+	})
+}
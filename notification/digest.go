@@ -0,0 +1,151 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// digestBatch 一个渠道在当前时间窗口内累积的通知
+type digestBatch struct {
+	target Target
+	window time.Duration
+	items  []*Message
+	timer  *time.Timer
+}
+
+// digester 按渠道将时间窗口内的通知合并为一条摘要
+//
+// 窗口从渠道收到第一条通知时开始，窗口结束时通过 deliver 发送摘要。
+type digester struct {
+	mu      sync.Mutex
+	pending map[string]*digestBatch
+	closed  bool
+
+	deliver func(target Target, msg *Message)
+	now     func() time.Time
+}
+
+// newDigester 创建摘要合并器
+func newDigester(deliver func(target Target, msg *Message)) *digester {
+	return &digester{
+		pending: make(map[string]*digestBatch),
+		deliver: deliver,
+		now:     time.Now,
+	}
+}
+
+// add 将通知加入渠道的当前摘要，关闭后直接发送
+func (d *digester) add(target Target, window time.Duration, msg *Message) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		d.deliver(target, msg)
+		return
+	}
+
+	batch, ok := d.pending[target.Name]
+	if !ok {
+		name := target.Name
+		batch = &digestBatch{window: window}
+		batch.timer = time.AfterFunc(window, func() { d.flush(name) })
+		d.pending[name] = batch
+	}
+	batch.target = target
+	batch.items = append(batch.items, msg)
+	d.mu.Unlock()
+}
+
+// flush 发送渠道的摘要
+func (d *digester) flush(name string) {
+	d.mu.Lock()
+	batch, ok := d.pending[name]
+	delete(d.pending, name)
+	d.mu.Unlock()
+
+	if ok {
+		d.deliver(batch.target, newDigestMessage(batch.items, batch.window, d.now()))
+	}
+}
+
+// close 立即发送所有未到期的摘要，之后的通知不再合并
+func (d *digester) close() {
+	d.mu.Lock()
+	d.closed = true
+	batches := make([]*digestBatch, 0, len(d.pending))
+	for name, batch := range d.pending {
+		batch.timer.Stop()
+		batches = append(batches, batch)
+		delete(d.pending, name)
+	}
+	d.mu.Unlock()
+
+	now := d.now()
+	for _, batch := range batches {
+		d.deliver(batch.target, newDigestMessage(batch.items, batch.window, now))
+	}
+}
+
+// newDigestMessage 将多条通知合并为一条摘要
+func newDigestMessage(items []*Message, window time.Duration, now time.Time) *Message {
+	if len(items) == 1 {
+		return items[0]
+	}
+
+	rules := make(map[string]bool)
+	changes, errs, recoveries := 0, 0, 0
+	for _, item := range items {
+		rules[item.RuleID] = true
+		switch item.Event {
+		case EventMonitorError:
+			errs++
+		case EventMonitorRecovered:
+			recoveries++
+		default:
+			changes++
+		}
+	}
+
+	var title string
+	if errs == 0 && recoveries == 0 {
+		title = fmt.Sprintf("%d 个规则在最近 %s 内发生变化", len(rules), formatWindow(window))
+	} else {
+		title = fmt.Sprintf("%d 个规则在最近 %s 内有 %d 次变化、%d 次检查失败",
+			len(rules), formatWindow(window), changes, errs)
+		if recoveries > 0 {
+			title += fmt.Sprintf("、%d 次恢复", recoveries)
+		}
+	}
+
+	var sb strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "- %s %s", item.Timestamp.Format("15:04:05"), item.Title)
+		if line, _, _ := strings.Cut(strings.TrimSpace(item.Body), "\n"); line != "" {
+			sb.WriteString(": " + line)
+		}
+	}
+
+	return &Message{
+		Event:     EventDigest,
+		Title:     title,
+		Body:      sb.String(),
+		Items:     items,
+		Timestamp: now,
+	}
+}
+
+// formatWindow 格式化时间窗口，去掉多余的零值单位（10m0s → 10m）
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,153 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/models"
+)
+
+func TestNewDigestMessage(t *testing.T) {
+	at := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	items := []*Message{
+		{Event: EventContentChanged, RuleID: "r1", Title: "内容变化: A", Body: "+1 -1 行\n详情", Timestamp: at},
+		{Event: EventContentChanged, RuleID: "r2", Title: "内容变化: B", Timestamp: at.Add(time.Minute)},
+		{Event: EventContentChanged, RuleID: "r1", Title: "内容变化: A", Timestamp: at.Add(2 * time.Minute)},
+	}
+
+	t.Run("只有变化", func(t *testing.T) {
+		msg := newDigestMessage(items, 10*time.Minute, at)
+		assert.Equal(t, EventDigest, msg.Event)
+		assert.Equal(t, "2 个规则在最近 10m 内发生变化", msg.Title)
+		assert.Equal(t, "- 08:00:00 内容变化: A: +1 -1 行\n- 08:01:00 内容变化: B\n- 08:02:00 内容变化: A", msg.Body)
+		assert.Len(t, msg.Items, 3)
+	})
+
+	t.Run("包含检查失败", func(t *testing.T) {
+		withError := append(items[:1:1], &Message{Event: EventMonitorError, RuleID: "r3", Title: "检查失败: C"})
+		msg := newDigestMessage(withError, time.Hour, at)
+		assert.Equal(t, "2 个规则在最近 1h 内有 1 次变化、1 次检查失败", msg.Title)
+	})
+
+	t.Run("包含恢复", func(t *testing.T) {
+		withRecovery := append(items[:1:1],
+			&Message{Event: EventMonitorError, RuleID: "r3", Title: "检查失败: C"},
+			&Message{Event: EventMonitorRecovered, RuleID: "r3", Title: "已恢复: C"},
+		)
+		msg := newDigestMessage(withRecovery, time.Hour, at)
+		assert.Equal(t, "2 个规则在最近 1h 内有 1 次变化、1 次检查失败、1 次恢复", msg.Title)
+	})
+
+	t.Run("只有一条时原样发送", func(t *testing.T) {
+		assert.Same(t, items[0], newDigestMessage(items[:1], time.Minute, at))
+	})
+}
+
+// digestRecorder 记录摘要合并器发送的消息
+type digestRecorder struct {
+	mu       sync.Mutex
+	messages []*Message
+	sent     chan struct{}
+}
+
+func newDigestRecorder() *digestRecorder {
+	return &digestRecorder{sent: make(chan struct{}, 16)}
+}
+
+func (r *digestRecorder) deliver(target Target, msg *Message) {
+	r.mu.Lock()
+	r.messages = append(r.messages, msg)
+	r.mu.Unlock()
+	r.sent <- struct{}{}
+}
+
+func (r *digestRecorder) all() []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Message(nil), r.messages...)
+}
+
+func TestDigester(t *testing.T) {
+	t.Run("窗口结束时发送摘要", func(t *testing.T) {
+		recorder := newDigestRecorder()
+		d := newDigester(recorder.deliver)
+		target := Target{Name: "ops"}
+
+		d.add(target, 50*time.Millisecond, &Message{RuleID: "r1", Title: "1"})
+		d.add(target, 50*time.Millisecond, &Message{RuleID: "r2", Title: "2"})
+		assert.Empty(t, recorder.all(), "窗口结束前不发送")
+
+		select {
+		case <-recorder.sent:
+		case <-time.After(time.Second):
+			t.Fatal("窗口结束后应发送摘要")
+		}
+		messages := recorder.all()
+		require.Len(t, messages, 1)
+		assert.Len(t, messages[0].Items, 2)
+
+		// 新的窗口
+		d.add(target, 50*time.Millisecond, &Message{RuleID: "r1", Title: "3"})
+		<-recorder.sent
+		assert.Equal(t, "3", recorder.all()[1].Title)
+	})
+
+	t.Run("关闭时立即发送", func(t *testing.T) {
+		recorder := newDigestRecorder()
+		d := newDigester(recorder.deliver)
+
+		d.add(Target{Name: "a"}, time.Hour, &Message{Title: "1"})
+		d.add(Target{Name: "b"}, time.Hour, &Message{Title: "2"})
+		d.close()
+		assert.Len(t, recorder.all(), 2)
+
+		d.add(Target{Name: "a"}, time.Hour, &Message{Title: "3"})
+		assert.Len(t, recorder.all(), 3, "关闭后直接发送")
+	})
+}
+
+func TestRouter_RateLimitAndDigest(t *testing.T) {
+	t.Run("规则频率限制", func(t *testing.T) {
+		desktop := NewMockNotifier()
+		router := NewRouter(nil, nil, desktop)
+		rule := &models.MonitorRule{ID: "r1", RateLimit: &models.RateLimit{Max: 2, Window: models.Duration(time.Hour)}}
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, router.Send(rule, &Message{Title: "标题"}))
+		}
+		assert.Len(t, desktop.GetNotifications(), 2)
+	})
+
+	t.Run("渠道频率限制由所有规则共享", func(t *testing.T) {
+		popup := NewMockNotifier()
+		router := NewRouter(map[string]models.ChannelConfig{
+			"popup": {Type: models.ChannelDesktop, RateLimit: &models.RateLimit{Max: 1, Window: models.Duration(time.Hour)}},
+		}, nil, popup)
+
+		require.NoError(t, router.Send(&models.MonitorRule{ID: "r1", Channels: []string{"popup"}}, &Message{Title: "1"}))
+		require.NoError(t, router.Send(&models.MonitorRule{ID: "r2", Channels: []string{"popup"}}, &Message{Title: "2"}))
+		assert.Len(t, popup.GetNotifications(), 1)
+	})
+
+	t.Run("渠道摘要", func(t *testing.T) {
+		desktop := NewMockNotifier()
+		router := NewRouter(map[string]models.ChannelConfig{
+			"batched": {Type: models.ChannelDesktop, Digest: models.Duration(time.Hour)},
+		}, nil, desktop)
+
+		for _, id := range []string{"r1", "r2", "r3"} {
+			rule := &models.MonitorRule{ID: id, Channels: []string{"batched"}}
+			require.NoError(t, router.Send(rule, &Message{RuleID: id, Title: "内容变化: " + id}))
+		}
+		assert.Empty(t, desktop.GetNotifications())
+
+		router.Close(context.Background())
+		notifications := desktop.GetNotifications()
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "3 个规则在最近 1h 内发生变化", notifications[0].Title)
+	})
+}
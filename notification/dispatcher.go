@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+const (
+	// DefaultDispatchWorkers 默认的通知发送并发数
+	DefaultDispatchWorkers = 4
+
+	// DefaultDispatchQueueSize 默认的通知队列长度
+	DefaultDispatchQueueSize = 256
+)
+
+var (
+	// ErrQueueFull 通知队列已满
+	ErrQueueFull = errors.New("通知队列已满")
+
+	// ErrDispatcherClosed 分发器已关闭
+	ErrDispatcherClosed = errors.New("通知分发器已关闭")
+)
+
+// dispatchJob 一个待发送的通知
+type dispatchJob struct {
+	ruleID string
+	target Target
+	msg    *Message
+
+	// done 不为nil时在发送完成后调用，参数为发送结果
+	done func(err error)
+}
+
+// Dispatcher 异步通知分发器
+//
+// 通知放入有界队列后立即返回，由固定数量的worker发送，因此某个通知器阻塞不会影响监控检查。
+// 桌面通知使用单独的队列和worker，等待用户关闭的对话框不会占用远程渠道的worker。
+type Dispatcher struct {
+	queue   chan dispatchJob // 远程渠道的通知
+	desktop chan dispatchJob // 桌面通知
+	wg      sync.WaitGroup
+
+	// abandon 关闭超时后关闭，worker不再发送队列中剩余的通知
+	abandon     chan struct{}
+	abandonOnce sync.Once
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewDispatcher 创建并启动通知分发器，参数不大于0时使用默认值
+func NewDispatcher(workers, queueSize int) *Dispatcher {
+	if workers <= 0 {
+		workers = DefaultDispatchWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultDispatchQueueSize
+	}
+
+	d := &Dispatcher{
+		queue:   make(chan dispatchJob, queueSize),
+		desktop: make(chan dispatchJob, queueSize),
+		abandon: make(chan struct{}),
+	}
+	d.wg.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go d.work(d.queue)
+	}
+	go d.work(d.desktop)
+	return d
+}
+
+// Enqueue 将通知放入队列，不会阻塞；队列已满或分发器已关闭时返回错误
+//
+// ruleID 仅用于记录日志。
+func (d *Dispatcher) Enqueue(ruleID string, target Target, msg *Message) error {
+	return d.enqueue(dispatchJob{ruleID: ruleID, target: target, msg: msg})
+}
+
+// enqueue 将任务放入队列，不会阻塞
+func (d *Dispatcher) enqueue(job dispatchJob) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return ErrDispatcherClosed
+	}
+
+	queue := d.queue
+	if !isRemote(job.target) {
+		queue = d.desktop
+	}
+
+	select {
+	case queue <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close 停止接收新通知，并等待队列中已有的通知发送完成
+//
+// ctx 结束时不再等待并返回错误，队列中尚未开始发送的通知被放弃，正在发送的通知不会被中断；
+// 放弃的远程通知保留在发件箱中，下次启动后重试。
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+		close(d.desktop)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.abandonOnce.Do(func() { close(d.abandon) })
+		return fmt.Errorf("等待通知发送完成超时: %w", context.Cause(ctx))
+	}
+}
+
+// work 从队列中取出通知并发送，直到队列关闭
+func (d *Dispatcher) work(queue <-chan dispatchJob) {
+	defer d.wg.Done()
+
+	for job := range queue {
+		select {
+		case <-d.abandon:
+			slog.Warn("关闭超时，放弃发送通知",
+				"rule_id", job.ruleID,
+				"channel", job.target.Name,
+				"event", job.msg.Event,
+			)
+			continue
+		default:
+		}
+
+		err := Send(job.target.Notifier, job.msg)
+		if job.done != nil {
+			job.done(err)
+		}
+		if err != nil {
+			slog.Warn("发送通知失败",
+				"rule_id", job.ruleID,
+				"channel", job.target.Name,
+				"event", job.msg.Event,
+				"error", err,
+			)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package notification
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/models"
+)
+
+// blockingNotifier 在release关闭前阻塞的通知器，模拟等待用户关闭的对话框
+type blockingNotifier struct {
+	started chan struct{}
+	release chan struct{}
+	count   atomic.Int32
+}
+
+func newBlockingNotifier() *blockingNotifier {
+	return &blockingNotifier{
+		started: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+}
+
+// Notify 阻塞直到release关闭
+func (n *blockingNotifier) Notify(title, message string) error {
+	n.started <- struct{}{}
+	<-n.release
+	n.count.Add(1)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("阻塞的通知器不影响入队", func(t *testing.T) {
+		d := NewDispatcher(1, 2)
+		blocking := newBlockingNotifier()
+		target := Target{Name: "desktop", Notifier: blocking}
+
+		require.NoError(t, d.Enqueue("r1", target, &Message{Title: "1"}))
+		<-blocking.started // worker正在发送第一条
+
+		require.NoError(t, d.Enqueue("r1", target, &Message{Title: "2"}))
+		require.NoError(t, d.Enqueue("r1", target, &Message{Title: "3"}))
+		assert.ErrorIs(t, d.Enqueue("r1", target, &Message{Title: "4"}), ErrQueueFull)
+
+		close(blocking.release)
+		d.Close(context.Background())
+		assert.Equal(t, int32(3), blocking.count.Load(), "关闭时应发送完已排队的通知")
+	})
+
+	t.Run("桌面通知阻塞不影响远程渠道", func(t *testing.T) {
+		d := NewDispatcher(1, 4)
+		defer d.Close(context.Background())
+
+		blocking := newBlockingNotifier()
+		defer close(blocking.release)
+		require.NoError(t, d.Enqueue("r1", Target{Name: "desktop", Notifier: blocking}, &Message{Title: "对话框"}))
+		<-blocking.started
+
+		remote := newBlockingNotifier()
+		close(remote.release)
+		webhook := Target{Name: "ops", Notifier: remote, Config: models.ChannelConfig{Type: models.ChannelWebhook}}
+		require.NoError(t, d.Enqueue("r1", webhook, &Message{Title: "远程"}))
+		select {
+		case <-remote.started:
+		case <-time.After(time.Second):
+			t.Fatal("远程通知应由其他worker发送")
+		}
+	})
+
+	t.Run("关闭超时后放弃剩余通知", func(t *testing.T) {
+		d := NewDispatcher(1, 4)
+		blocking := newBlockingNotifier()
+		target := Target{Name: "desktop", Notifier: blocking}
+
+		require.NoError(t, d.Enqueue("r1", target, &Message{Title: "1"}))
+		require.NoError(t, d.Enqueue("r1", target, &Message{Title: "2"}))
+		<-blocking.started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := d.Close(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+
+		// 正在发送的通知完成后，剩余的通知不再发送
+		close(blocking.release)
+		assert.Eventually(t, func() bool {
+			return blocking.count.Load() == 1
+		}, time.Second, 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, int32(1), blocking.count.Load())
+	})
+
+	t.Run("关闭后拒绝新通知", func(t *testing.T) {
+		d := NewDispatcher(0, 0)
+		d.Close(context.Background())
+		d.Close(context.Background())
+
+		err := d.Enqueue("r1", Target{Notifier: NewMockNotifier()}, &Message{})
+		assert.ErrorIs(t, err, ErrDispatcherClosed)
+	})
+}
+
+func TestRouter_SendWithDispatcher(t *testing.T) {
+	blocking := newBlockingNotifier()
+	router := NewRouter(nil, nil, blocking)
+	router.SetDispatcher(NewDispatcher(1, 8))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- router.Send(&models.MonitorRule{ID: "r1"}, &Message{Title: "标题"})
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Send 不应等待通知发送完成")
+	}
+
+	<-blocking.started
+	close(blocking.release)
+	router.Close(context.Background())
+	assert.Equal(t, int32(1), blocking.count.Load())
+}
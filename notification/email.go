@@ -0,0 +1,307 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zx06/apiwatch/models"
+)
+
+// DefaultSMTPTimeout SMTP连接和发送的默认超时时间
+const DefaultSMTPTimeout = 30 * time.Second
+
+// EmailNotifier 通过SMTP发送邮件通知，邮件同时包含纯文本和HTML两种格式
+type EmailNotifier struct {
+	config models.SMTPConfig
+	to     []string
+
+	// tlsConfig 为nil时使用以服务器地址校验证书的默认配置
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+// NewEmailNotifier 创建邮件通知器
+func NewEmailNotifier(config models.SMTPConfig, to []string) *EmailNotifier {
+	return &EmailNotifier{
+		config: config,
+		to:     to,
+		now:    time.Now,
+	}
+}
+
+// Notify 发送只包含标题和正文的通知
+func (n *EmailNotifier) Notify(title, message string) error {
+	return n.NotifyMessage(&Message{
+		Title:     title,
+		Body:      message,
+		Timestamp: n.now(),
+	})
+}
+
+// NotifyMessage 发送包含完整信息的通知
+func (n *EmailNotifier) NotifyMessage(msg *Message) error {
+	if len(n.to) == 0 {
+		return errors.New("未配置邮件收件人")
+	}
+
+	from, err := mail.ParseAddress(n.config.From)
+	if err != nil {
+		return fmt.Errorf("无效的发件人地址: %w", err)
+	}
+
+	data, err := n.buildMessage(from, msg)
+	if err != nil {
+		return fmt.Errorf("生成邮件失败: %w", err)
+	}
+
+	if err := n.send(from.Address, data); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// send 连接SMTP服务器并发送邮件
+func (n *EmailNotifier) send(from string, data []byte) error {
+	timeout := time.Duration(n.config.Timeout)
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+
+	host := n.config.Host
+	addr := net.JoinHostPort(host, strconv.Itoa(n.config.EffectivePort()))
+	security := n.config.EffectiveSecurity()
+
+	tlsConfig := n.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if security == models.SMTPTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	// 整个会话共用一个截止时间，避免服务器无响应时一直阻塞
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if security == models.SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP服务器不支持STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS失败: %w", err)
+		}
+	}
+
+	if n.config.Username != "" {
+		auth := smtp.PlainAuth("", n.config.Username, n.config.Password, host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("无效的收件人地址 %q: %w", to, err)
+		}
+		if err := client.Rcpt(addr.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildMessage 生成multipart/alternative格式的邮件内容
+func (n *EmailNotifier) buildMessage(from *mail.Address, msg *Message) ([]byte, error) {
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = n.now()
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := []struct{ key, value string }{
+		{"From", from.String()},
+		{"To", strings.Join(n.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Title)},
+		{"Date", timestamp.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(from.Address)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	for _, h := range header {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	if err := writeTextPart(mw, "text/plain; charset=utf-8", plainEmailBody(msg)); err != nil {
+		return nil, err
+	}
+
+	html, err := htmlEmailBody(msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTextPart(mw, "text/html; charset=utf-8", html); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeTextPart 以quoted-printable编码写入一个文本部分
+func writeTextPart(mw *multipart.Writer, contentType, body string) error {
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// plainEmailBody 生成纯文本正文
+func plainEmailBody(msg *Message) string {
+	// 用户模板生成的正文原样发送
+	if msg.Templated {
+		return msg.Body
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg.Body)
+
+	if msg.URL != "" {
+		sb.WriteString("\n\nURL: " + msg.URL)
+	}
+	if msg.Diff != nil && msg.Diff.Unified != "" {
+		sb.WriteString("\n\n" + msg.Diff.Unified)
+	}
+	return sb.String()
+}
+
+// emailLine 带样式的差异行
+type emailLine struct {
+	Text  string
+	Style template.CSS
+}
+
+var htmlEmailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; font-size: 14px;">
+<h2 style="font-size: 16px;">{{.Title}}</h2>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .URL}}<p><a href="{{.URL}}">{{.URL}}</a></p>{{end}}
+{{if .Lines}}<pre style="font-family: monospace; font-size: 13px; background: #f6f8fa; padding: 8px;">{{range .Lines}}<span style="{{.Style}}">{{.Text}}</span>
+{{end}}</pre>{{else}}<pre style="white-space: pre-wrap;">{{.Body}}</pre>{{end}}
+</body>
+</html>
+`))
+
+// htmlEmailBody 生成HTML正文，差异行按新增/删除着色
+func htmlEmailBody(msg *Message) (string, error) {
+	data := struct {
+		Title       string
+		Description string
+		URL         string
+		Body        string
+		Lines       []emailLine
+	}{
+		Title:       msg.Title,
+		Description: msg.Description,
+		URL:         msg.URL,
+		Body:        msg.Body,
+	}
+
+	if msg.Templated {
+		data.Description = ""
+		data.URL = ""
+	} else if msg.Diff != nil && msg.Diff.Unified != "" {
+		for i, line := range strings.Split(strings.TrimSuffix(msg.Diff.Unified, "\n"), "\n") {
+			var style template.CSS
+			switch {
+			case i < 2: // --- old / +++ new
+				style = "color: #57606a;"
+			case strings.HasPrefix(line, "@@"):
+				style = "color: #8250df;"
+			case strings.HasPrefix(line, "+"):
+				style = "color: #116329; background: #dafbe1;"
+			case strings.HasPrefix(line, "-"):
+				style = "color: #82071e; background: #ffebe9;"
+			}
+			data.Lines = append(data.Lines, emailLine{Text: line, Style: style})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := htmlEmailTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// messageID 生成唯一的Message-ID
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
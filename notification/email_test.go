@@ -0,0 +1,317 @@
+package notification
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+)
+
+// receivedMail 模拟SMTP服务器收到的邮件
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+	auth string
+	tls  bool
+}
+
+// fakeSMTPServer 进程内的SMTP模拟服务器，支持STARTTLS、隐式TLS和AUTH PLAIN
+type fakeSMTPServer struct {
+	ln          net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+
+	mu    sync.Mutex
+	mails []receivedMail
+}
+
+// newFakeSMTPServer 启动SMTP模拟服务器，tlsConfig为nil时不支持TLS
+func newFakeSMTPServer(t *testing.T, tlsConfig *tls.Config, implicitTLS bool) *fakeSMTPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if implicitTLS {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	s := &fakeSMTPServer{ln: ln, tlsConfig: tlsConfig, implicitTLS: implicitTLS}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+// port 返回监听端口
+func (s *fakeSMTPServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// received 返回收到的邮件
+func (s *fakeSMTPServer) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.mails...)
+}
+
+// handle 处理一个SMTP会话
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	tp := textproto.NewConn(conn)
+	current := receivedMail{tls: s.implicitTLS}
+	tp.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"localhost"}
+			if s.tlsConfig != nil && !current.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			lines = append(lines, "AUTH PLAIN")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			current.tls = true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			current.auth = string(decoded)
+			tp.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			current.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			current.to = append(current.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, current)
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+// newTestTLSConfigs 返回测试用的服务端和客户端TLS配置（借用httptest的自签名证书）
+func newTestTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	defer ts.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	return ts.TLS.Clone(), &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+}
+
+// newTestEmailNotifier 创建连接到模拟服务器的邮件通知器
+func newTestEmailNotifier(server *fakeSMTPServer, security models.SMTPSecurity, clientTLS *tls.Config) *EmailNotifier {
+	n := NewEmailNotifier(models.SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "user",
+		Password: "pass",
+		From:     "API Watch <watch@example.com>",
+		Security: security,
+		Timeout:  models.Duration(5 * time.Second),
+	}, []string{"ops@example.com", "Dev <dev@example.com>"})
+	n.tlsConfig = clientTLS
+	return n
+}
+
+// parseMail 解析邮件，返回解码后的主题和各部分内容（按Content-Type）
+func parseMail(t *testing.T, data string) (string, map[string]string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		// multipart.Reader 会自动解码quoted-printable
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return subject, parts
+}
+
+func TestEmailNotifier_StartTLS(t *testing.T) {
+	serverTLS, clientTLS := newTestTLSConfigs(t)
+	server := newFakeSMTPServer(t, serverTLS, false)
+	n := newTestEmailNotifier(server, models.SMTPStartTLS, clientTLS)
+
+	msg := &Message{
+		Title:       "内容变化: 价格",
+		Body:        "+1 -1 行",
+		RuleID:      "rule-1",
+		RuleName:    "价格",
+		URL:         "https://example.com/price",
+		Description: "商品价格 <监控>",
+		OldContent:  "100",
+		NewContent:  "120",
+		Diff:        diff.Compute("100", "120"),
+		Timestamp:   time.Now(),
+	}
+	require.NoError(t, n.NotifyMessage(msg))
+
+	mails := server.received()
+	require.Len(t, mails, 1)
+	received := mails[0]
+
+	t.Run("会话已升级为TLS并完成认证", func(t *testing.T) {
+		assert.True(t, received.tls)
+		assert.Equal(t, "\x00user\x00pass", received.auth)
+	})
+
+	t.Run("发件人和收件人", func(t *testing.T) {
+		assert.Equal(t, "watch@example.com", received.from)
+		assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, received.to)
+	})
+
+	t.Run("邮件包含纯文本和HTML两部分", func(t *testing.T) {
+		subject, parts := parseMail(t, received.data)
+		assert.Equal(t, "内容变化: 价格", subject)
+
+		plain := parts["text/plain"]
+		assert.Contains(t, plain, "+1 -1 行")
+		assert.Contains(t, plain, "URL: https://example.com/price")
+		assert.Contains(t, plain, "-100\n+120")
+
+		html := parts["text/html"]
+		assert.Contains(t, html, "商品价格 &lt;监控&gt;", "HTML中的内容应被转义")
+		assert.Contains(t, html, `<a href="https://example.com/price">`)
+		assert.Contains(t, html, "&#43;120</span>")
+		assert.Contains(t, html, "background: #dafbe1;")
+	})
+}
+
+func TestEmailNotifier_ImplicitTLS(t *testing.T) {
+	serverTLS, clientTLS := newTestTLSConfigs(t)
+	server := newFakeSMTPServer(t, serverTLS, true)
+	n := newTestEmailNotifier(server, models.SMTPTLS, clientTLS)
+
+	require.NoError(t, n.Notify("标题", "正文"))
+
+	mails := server.received()
+	require.Len(t, mails, 1)
+	assert.True(t, mails[0].tls)
+
+	subject, parts := parseMail(t, mails[0].data)
+	assert.Equal(t, "标题", subject)
+	assert.Equal(t, "正文", parts["text/plain"])
+}
+
+func TestEmailNotifier_Errors(t *testing.T) {
+	t.Run("服务器不支持STARTTLS", func(t *testing.T) {
+		server := newFakeSMTPServer(t, nil, false)
+		n := newTestEmailNotifier(server, models.SMTPStartTLS, nil)
+
+		err := n.Notify("标题", "正文")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "STARTTLS")
+		assert.Empty(t, server.received())
+	})
+
+	t.Run("证书不受信任", func(t *testing.T) {
+		serverTLS, _ := newTestTLSConfigs(t)
+		server := newFakeSMTPServer(t, serverTLS, false)
+		n := newTestEmailNotifier(server, models.SMTPStartTLS, nil)
+
+		require.Error(t, n.Notify("标题", "正文"))
+		assert.Empty(t, server.received())
+	})
+
+	t.Run("未配置收件人", func(t *testing.T) {
+		n := NewEmailNotifier(models.SMTPConfig{Host: "127.0.0.1", From: "a@example.com"}, nil)
+		require.Error(t, n.Notify("标题", "正文"))
+	})
+
+	t.Run("连接失败", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+
+		n := NewEmailNotifier(models.SMTPConfig{
+			Host:     "127.0.0.1",
+			Port:     port,
+			From:     "a@example.com",
+			Security: models.SMTPNone,
+		}, []string{"b@example.com"})
+		err = n.Notify("标题", "正文")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), strconv.Itoa(port))
+	})
+}
@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/zx06/apiwatch/diff"
+)
+
+// 通知对应的事件类型
+const (
+	EventContentChanged   = "content_changed"   // 内容变化
+	EventMonitorError     = "monitor_error"     // 检查失败
+	EventMonitorRecovered = "monitor_recovered" // 连续失败后恢复
+	EventDigest           = "digest"            // 多条通知合并的摘要
+)
+
+// Message 通知的完整信息
+//
+// Title 和 Body 是已格式化好的文本，只支持纯文本的通知器直接使用它们；
+// 其余字段供需要自行排版的通知器使用。
+type Message struct {
+	// Event 事件类型，为空时视为内容变化
+	Event string `json:"event,omitempty"`
+
+	Title string `json:"title"`
+	Body  string `json:"body"`
+
+	RuleID      string `json:"rule_id,omitempty"`
+	RuleName    string `json:"rule_name,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+
+	// Diff 新旧内容的差异
+	Diff *diff.Result `json:"diff,omitempty"`
+
+	// StatusCode 和 DurationMs 为本次检查的HTTP状态码和耗时
+	StatusCode int   `json:"status_code,omitempty"`
+	DurationMs int64 `json:"duration_ms,omitempty"`
+
+	// Stage 和 Error 为检查失败的阶段和错误信息
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	// Failures 和 FailingSince 为连续失败次数和开始时间，用于检查失败和恢复通知；
+	// 恢复通知的 Error 为恢复前最后一次失败的错误信息
+	Failures     int       `json:"failures,omitempty"`
+	FailingSince time.Time `json:"failing_since,omitzero"`
+
+	// Items 摘要包含的通知，仅用于摘要消息
+	Items []*Message `json:"items,omitempty"`
+
+	// Templated 为true时 Body 由用户模板生成，支持排版的通知器应直接使用 Body 而不是自行组织内容
+	Templated bool `json:"templated,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MessageNotifier 支持完整通知信息的通知器
+type MessageNotifier interface {
+	Notifier
+
+	// NotifyMessage 发送包含完整信息的通知
+	NotifyMessage(msg *Message) error
+}
+
+// Send 发送通知，通知器实现了MessageNotifier时发送完整信息，否则只发送标题和正文
+func Send(n Notifier, msg *Message) error {
+	if mn, ok := n.(MessageNotifier); ok {
+		return mn.NotifyMessage(msg)
+	}
+	return n.Notify(msg.Title, msg.Body)
+}
@@ -113,3 +113,44 @@ func TestMockNotifier(t *testing.T) {
 		assert.Empty(t, mock.GetNotifications())
 	})
 }
+
+// mockMessageNotifier 支持完整通知信息的模拟通知器
+type mockMessageNotifier struct {
+	MockNotifier
+	messages []*Message
+}
+
+// NotifyMessage 记录完整通知信息
+func (m *mockMessageNotifier) NotifyMessage(msg *Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	msg := &Message{
+		Title:      "内容变化: 测试",
+		Body:       "+1 -1 行",
+		RuleID:     "rule-1",
+		OldContent: "old",
+		NewContent: "new",
+	}
+
+	t.Run("普通通知器只接收标题和正文", func(t *testing.T) {
+		mock := NewMockNotifier()
+		require.NoError(t, Send(mock, msg))
+
+		notifications := mock.GetNotifications()
+		require.Len(t, notifications, 1)
+		assert.Equal(t, msg.Title, notifications[0].Title)
+		assert.Equal(t, msg.Body, notifications[0].Message)
+	})
+
+	t.Run("MessageNotifier接收完整信息", func(t *testing.T) {
+		mock := &mockMessageNotifier{}
+		require.NoError(t, Send(mock, msg))
+
+		require.Len(t, mock.messages, 1)
+		assert.Same(t, msg, mock.messages[0])
+		assert.Empty(t, mock.GetNotifications())
+	})
+}
@@ -0,0 +1,357 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zx06/apiwatch/models"
+)
+
+// DeliveryStatus 通知投递状态
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // 等待发送或重试
+	DeliveryDelivered DeliveryStatus = "delivered" // 已送达
+	DeliveryFailed    DeliveryStatus = "failed"    // 重试次数用尽
+)
+
+// Valid 检查投递状态是否有效
+func (s DeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryFailed:
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultMaxAttempts 默认每条通知最多尝试发送的次数
+	DefaultMaxAttempts = 10
+
+	// DefaultDeliveryListLimit 未指定数量时返回的投递记录数
+	DefaultDeliveryListLimit = 50
+
+	// retryBaseDelay 和 retryMaxDelay 为重试的退避时间：第n次失败后等待 retryBaseDelay*2^(n-1)，不超过 retryMaxDelay
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+
+	// maxFinishedDeliveries 最多保留的已结束（送达或失败）投递记录数
+	maxFinishedDeliveries = 500
+)
+
+var (
+	// ErrDeliveryNotFound 投递记录不存在
+	ErrDeliveryNotFound = errors.New("通知投递记录不存在")
+
+	// ErrPermanent 重试也不会成功的发送错误，发件箱收到后直接标记为失败
+	ErrPermanent = errors.New("通知无法送达")
+)
+
+// Delivery 一条发往远程渠道的通知的投递状态
+type Delivery struct {
+	ID      string `json:"id"`
+	RuleID  string `json:"rule_id,omitempty"`
+	Channel string `json:"channel"`
+	Event   string `json:"event,omitempty"`
+	Title   string `json:"title"`
+
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	LastError string         `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// NextAttempt 下次重试的时间，仅 pending 状态有效
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// outboxEntry 发件箱中的一条记录，保存重建通知器所需的渠道配置和完整消息
+type outboxEntry struct {
+	Delivery
+
+	Config  models.ChannelConfig `json:"config"`
+	Message *Message             `json:"message"`
+
+	// MaxAttempts 该记录最多尝试发送的次数，为0时使用发件箱的设置
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// inFlight 正在发送中，不参与重试调度（不持久化，重启后未完成的发送会重新进行）
+	inFlight bool
+}
+
+// Outbox 持久化的通知发件箱
+//
+// 发往Webhook、聊天机器人和邮件的通知在发送前写入发件箱，发送失败后按指数退避重试，
+// 超过最大尝试次数后标记为失败。发件箱保存在单个JSON文件中，每次状态变化后整体重写，
+// 因此程序重启后未送达的通知会继续重试。
+type Outbox struct {
+	path        string
+	maxAttempts int
+
+	mu sync.Mutex
+	// entries 按创建时间正序
+	entries []*outboxEntry
+
+	now func() time.Time
+}
+
+// OpenOutbox 打开发件箱文件，文件不存在时创建空发件箱；maxAttempts 不大于0时使用默认值
+func OpenOutbox(path string, maxAttempts int) (*Outbox, error) {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建发件箱目录失败: %w", err)
+	}
+
+	o := &Outbox{
+		path:        path,
+		maxAttempts: maxAttempts,
+		now:         time.Now,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取发件箱失败: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &o.entries); err != nil {
+			return nil, fmt.Errorf("解析发件箱失败: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// add 新增一条待发送的通知并标记为发送中，返回记录ID
+func (o *Outbox) add(target Target, msg *Message) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	entry := &outboxEntry{
+		Delivery: Delivery{
+			ID:          uuid.New().String(),
+			RuleID:      msg.RuleID,
+			Channel:     target.Name,
+			Event:       msg.Event,
+			Title:       msg.Title,
+			Status:      DeliveryPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			NextAttempt: now,
+		},
+		Config:      target.Config,
+		Message:     msg,
+		MaxAttempts: maxAttempts(target.Config),
+		inFlight:    true,
+	}
+	o.entries = append(o.entries, entry)
+
+	if err := o.save(); err != nil {
+		o.entries = o.entries[:len(o.entries)-1]
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+// due 返回到达重试时间且不在发送中的记录，并将它们标记为发送中
+func (o *Outbox) due(now time.Time) []outboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var entries []outboxEntry
+	for _, entry := range o.entries {
+		if entry.Status == DeliveryPending && !entry.inFlight && !entry.NextAttempt.After(now) {
+			entry.inFlight = true
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// begin 将记录标记为发送中用于手动重新发送，并重置尝试次数
+//
+// 记录已在发送中时返回 false。
+func (o *Outbox) begin(id string) (outboxEntry, bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry := o.find(id)
+	if entry == nil {
+		return outboxEntry{}, false, fmt.Errorf("%w: %s", ErrDeliveryNotFound, id)
+	}
+	if entry.inFlight {
+		return outboxEntry{}, false, nil
+	}
+
+	entry.inFlight = true
+	entry.Status = DeliveryPending
+	entry.Attempts = 0
+	entry.LastError = ""
+	entry.UpdatedAt = o.now()
+	entry.NextAttempt = entry.UpdatedAt
+	if err := o.save(); err != nil {
+		entry.inFlight = false
+		return outboxEntry{}, false, err
+	}
+	return *entry, true, nil
+}
+
+// complete 记录一次发送的结果
+//
+// 成功时标记为已送达；失败时安排下次重试，尝试次数用尽后标记为失败。
+func (o *Outbox) complete(id string, sendErr error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry := o.find(id)
+	if entry == nil {
+		return fmt.Errorf("%w: %s", ErrDeliveryNotFound, id)
+	}
+
+	now := o.now()
+	entry.inFlight = false
+	entry.Attempts++
+	entry.UpdatedAt = now
+
+	switch {
+	case sendErr == nil:
+		entry.Status = DeliveryDelivered
+		entry.LastError = ""
+		entry.NextAttempt = time.Time{}
+	case errors.Is(sendErr, ErrPermanent), entry.Attempts >= o.attemptLimit(entry):
+		entry.Status = DeliveryFailed
+		entry.LastError = sendErr.Error()
+		entry.NextAttempt = time.Time{}
+	default:
+		entry.Status = DeliveryPending
+		entry.LastError = sendErr.Error()
+		entry.NextAttempt = now.Add(retryDelay(entry.Attempts))
+	}
+
+	o.prune()
+	return o.save()
+}
+
+// attemptLimit 返回记录最多尝试发送的次数
+func (o *Outbox) attemptLimit(entry *outboxEntry) int {
+	if entry.MaxAttempts > 0 {
+		return entry.MaxAttempts
+	}
+	return o.maxAttempts
+}
+
+// maxAttempts 返回渠道配置限定的发送次数，Webhook的 MaxRetries 为首次发送后的重试次数；未限定时返回0
+func maxAttempts(config models.ChannelConfig) int {
+	if config.Type == models.ChannelWebhook && config.Webhook != nil && config.Webhook.MaxRetries > 0 {
+		return config.Webhook.MaxRetries + 1
+	}
+	return 0
+}
+
+// release 取消记录的发送中标记，记录在下次重试时发送（用于通知未能进入发送队列）
+func (o *Outbox) release(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if entry := o.find(id); entry != nil {
+		entry.inFlight = false
+	}
+}
+
+// List 按创建时间倒序返回投递记录
+// status 为空时返回所有状态；limit <= 0 时使用 DefaultDeliveryListLimit
+func (o *Outbox) List(status DeliveryStatus, limit int) []*Delivery {
+	if limit <= 0 {
+		limit = DefaultDeliveryListLimit
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	deliveries := make([]*Delivery, 0, min(limit, len(o.entries)))
+	for i := len(o.entries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		entry := o.entries[i]
+		if status != "" && entry.Status != status {
+			continue
+		}
+		delivery := entry.Delivery
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries
+}
+
+// find 按ID查找记录，调用方需持有锁
+func (o *Outbox) find(id string) *outboxEntry {
+	for _, entry := range o.entries {
+		if entry.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+// prune 删除超出保留数量的最早的已结束记录，调用方需持有锁
+func (o *Outbox) prune() {
+	finished := 0
+	for _, entry := range o.entries {
+		if entry.Status != DeliveryPending {
+			finished++
+		}
+	}
+
+	excess := finished - maxFinishedDeliveries
+	if excess <= 0 {
+		return
+	}
+
+	kept := o.entries[:0]
+	for _, entry := range o.entries {
+		if excess > 0 && entry.Status != DeliveryPending {
+			excess--
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	o.entries = kept
+}
+
+// save 将发件箱写入文件（先写临时文件再重命名），调用方需持有锁
+//
+// 文件中包含渠道配置（可能有密钥），因此只允许当前用户读写。
+func (o *Outbox) save() error {
+	data, err := json.MarshalIndent(o.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化发件箱失败: %w", err)
+	}
+
+	tempPath := o.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("写入发件箱失败: %w", err)
+	}
+	if err := os.Rename(tempPath, o.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("写入发件箱失败: %w", err)
+	}
+	return nil
+}
+
+// retryDelay 返回第 attempts 次失败后的重试等待时间
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,212 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/models"
+)
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(1))
+	assert.Equal(t, time.Minute, retryDelay(2))
+	assert.Equal(t, 4*time.Minute, retryDelay(4))
+	assert.Equal(t, time.Hour, retryDelay(8))
+	assert.Equal(t, time.Hour, retryDelay(100))
+}
+
+func TestOutbox(t *testing.T) {
+	at := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	target := Target{
+		Name:   "ops",
+		Config: models.ChannelConfig{Type: models.ChannelWebhook, Webhook: &models.WebhookConfig{URL: "https://hooks.example.com"}},
+	}
+
+	openOutbox := func(t *testing.T, path string) *Outbox {
+		t.Helper()
+		outbox, err := OpenOutbox(path, 2)
+		require.NoError(t, err)
+		outbox.now = func() time.Time { return at }
+		return outbox
+	}
+
+	t.Run("失败后按退避时间重试", func(t *testing.T) {
+		outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.json"))
+
+		id, err := outbox.add(target, &Message{RuleID: "r1", Title: "标题"})
+		require.NoError(t, err)
+		assert.Empty(t, outbox.due(at), "发送中的记录不参与重试")
+
+		require.NoError(t, outbox.complete(id, errors.New("连接超时")))
+		deliveries := outbox.List("", 0)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, DeliveryPending, deliveries[0].Status)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, "连接超时", deliveries[0].LastError)
+		assert.Equal(t, at.Add(30*time.Second), deliveries[0].NextAttempt)
+
+		assert.Empty(t, outbox.due(at.Add(29*time.Second)))
+		due := outbox.due(at.Add(30 * time.Second))
+		require.Len(t, due, 1)
+		assert.Equal(t, "标题", due[0].Message.Title)
+
+		require.NoError(t, outbox.complete(id, errors.New("连接超时")))
+		assert.Equal(t, DeliveryFailed, outbox.List("", 0)[0].Status, "达到最大尝试次数后标记为失败")
+		assert.Empty(t, outbox.due(at.Add(24*time.Hour)))
+	})
+
+	t.Run("重新打开后保留记录", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "outbox.json")
+		outbox := openOutbox(t, path)
+
+		delivered, err := outbox.add(target, &Message{Title: "1"})
+		require.NoError(t, err)
+		require.NoError(t, outbox.complete(delivered, nil))
+		_, err = outbox.add(target, &Message{Title: "2"})
+		require.NoError(t, err)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		reopened := openOutbox(t, path)
+		assert.Len(t, reopened.List("", 0), 2)
+		assert.Len(t, reopened.List(DeliveryDelivered, 0), 1)
+
+		// 重启前正在发送的记录重新参与重试
+		due := reopened.due(at)
+		require.Len(t, due, 1)
+		assert.Equal(t, "2", due[0].Message.Title)
+		assert.Equal(t, "https://hooks.example.com", due[0].Config.Webhook.URL)
+	})
+
+	t.Run("手动重新发送重置尝试次数", func(t *testing.T) {
+		outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.json"))
+
+		id, err := outbox.add(target, &Message{Title: "标题"})
+		require.NoError(t, err)
+		_, ok, err := outbox.begin(id)
+		require.NoError(t, err)
+		assert.False(t, ok, "发送中的记录不重复发送")
+
+		require.NoError(t, outbox.complete(id, errors.New("失败")))
+		require.NoError(t, outbox.complete(id, errors.New("失败")))
+		entry, ok, err := outbox.begin(id)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, DeliveryPending, entry.Status)
+		assert.Equal(t, 0, entry.Attempts)
+
+		_, _, err = outbox.begin("missing")
+		assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	})
+
+	t.Run("无法送达的错误不重试", func(t *testing.T) {
+		outbox := openOutbox(t, filepath.Join(t.TempDir(), "outbox.json"))
+
+		id, err := outbox.add(target, &Message{Title: "标题"})
+		require.NoError(t, err)
+		require.NoError(t, outbox.complete(id, fmt.Errorf("%w: HTTP错误: 400 Bad Request", ErrPermanent)))
+		assert.Equal(t, DeliveryFailed, outbox.List("", 0)[0].Status)
+	})
+
+	t.Run("Webhook的重试次数限制尝试次数", func(t *testing.T) {
+		outbox, err := OpenOutbox(filepath.Join(t.TempDir(), "outbox.json"), 5)
+		require.NoError(t, err)
+		webhook := Target{
+			Name:   "r1/webhook#1",
+			Config: models.ChannelConfig{Type: models.ChannelWebhook, Webhook: &models.WebhookConfig{URL: "https://hooks.example.com", MaxRetries: 1}},
+		}
+
+		id, err := outbox.add(webhook, &Message{Title: "标题"})
+		require.NoError(t, err)
+		require.NoError(t, outbox.complete(id, errors.New("HTTP错误: 500")))
+		assert.Equal(t, DeliveryPending, outbox.List("", 0)[0].Status)
+		require.NoError(t, outbox.complete(id, errors.New("HTTP错误: 500")))
+		assert.Equal(t, DeliveryFailed, outbox.List("", 0)[0].Status, "首次发送后重试1次")
+	})
+}
+
+func TestRouter_Outbox(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		received.Add(1)
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	channels := map[string]models.ChannelConfig{
+		"ops": {Type: models.ChannelWebhook, Webhook: &models.WebhookConfig{URL: server.URL}},
+	}
+	rule := &models.MonitorRule{ID: "r1", Channels: []string{"ops", "desktop"}}
+
+	// 时钟偏移，用于让记录提前到达重试时间
+	var offset atomic.Int64
+	newRouter := func(t *testing.T) *Router {
+		t.Helper()
+		outbox, err := OpenOutbox(path, 0)
+		require.NoError(t, err)
+
+		router := NewRouter(channels, nil, NewMockNotifier())
+		router.now = func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }
+		router.retryInterval = 10 * time.Millisecond
+		router.SetOutbox(outbox)
+		router.StartRetry()
+		return router
+	}
+
+	router := newRouter(t)
+	err := router.Send(rule, &Message{RuleID: "r1", Title: "内容变化"})
+	require.Error(t, err)
+
+	deliveries := router.Deliveries("", 0)
+	require.Len(t, deliveries, 1, "桌面通知不写入发件箱")
+	assert.Equal(t, "ops", deliveries[0].Channel)
+	assert.Equal(t, DeliveryPending, deliveries[0].Status)
+	id := deliveries[0].ID
+
+	// 未到重试时间
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, router.Deliveries("", 0)[0].Attempts)
+	router.Close(context.Background())
+
+	t.Run("重启后继续重试", func(t *testing.T) {
+		failing.Store(false)
+		offset.Store(int64(time.Hour))
+
+		router := newRouter(t)
+		defer router.Close(context.Background())
+
+		assert.Eventually(t, func() bool {
+			return len(router.Deliveries(DeliveryDelivered, 0)) == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(1), received.Load())
+	})
+
+	t.Run("手动重新发送", func(t *testing.T) {
+		router := newRouter(t)
+		defer router.Close(context.Background())
+
+		require.NoError(t, router.Resend(id))
+		assert.Equal(t, int32(2), received.Load())
+		assert.Equal(t, 1, router.Deliveries("", 0)[0].Attempts)
+
+		assert.ErrorIs(t, router.Resend("missing"), ErrDeliveryNotFound)
+	})
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zx06/apiwatch/models"
+)
+
+// limiter 按键计数的滑动窗口频率限制器
+type limiter struct {
+	mu   sync.Mutex
+	sent map[string][]time.Time
+}
+
+// newLimiter 创建频率限制器
+func newLimiter() *limiter {
+	return &limiter{sent: make(map[string][]time.Time)}
+}
+
+// allow 检查键在 now 时刻是否还能发送一条通知，允许时记录本次发送
+func (l *limiter) allow(key string, limit *models.RateLimit, now time.Time) bool {
+	if limit == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 丢弃窗口之外的记录
+	cutoff := now.Add(-time.Duration(limit.Window))
+	times := l.sent[key]
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = times[i:]
+
+	if len(times) >= limit.Max {
+		l.sent[key] = times
+		return false
+	}
+
+	l.sent[key] = append(times, now)
+	return true
+}
@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zx06/apiwatch/models"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter()
+	limit := &models.RateLimit{Max: 2, Window: models.Duration(time.Minute)}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, l.allow("a", limit, start))
+	assert.True(t, l.allow("a", limit, start.Add(10*time.Second)))
+	assert.False(t, l.allow("a", limit, start.Add(20*time.Second)), "窗口内超出限制")
+	assert.True(t, l.allow("b", limit, start.Add(20*time.Second)), "不同的键分别计数")
+	assert.True(t, l.allow("a", limit, start.Add(61*time.Second)), "最早的记录移出窗口后恢复")
+	assert.True(t, l.allow("a", nil, start), "未设置限制")
+}
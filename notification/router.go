@@ -0,0 +1,527 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zx06/apiwatch/models"
+)
+
+// ErrUnknownChannel 规则引用了未定义的通知渠道
+var ErrUnknownChannel = errors.New("未定义的通知渠道")
+
+// Target 一个通知目标
+type Target struct {
+	// Name 渠道名称，规则内联的通知配置为 <规则ID>/webhook#1、<规则ID>/chat#1、<规则ID>/email 形式
+	Name     string
+	Notifier Notifier
+
+	// Template 渠道的消息模板，为nil时使用规则的模板
+	Template *models.MessageTemplate
+
+	// RateLimit 和 Digest 为渠道的频率限制和摘要时间窗口
+	RateLimit *models.RateLimit
+	Digest    time.Duration
+
+	// Config 创建通知器使用的渠道配置，用于发件箱重试时重新创建通知器
+	Config models.ChannelConfig
+}
+
+// outboxRetryInterval 检查发件箱中到期重试通知的间隔
+const outboxRetryInterval = 5 * time.Second
+
+// Router 通知路由，根据规则引用的命名渠道和规则内联的通知配置解析通知目标
+//
+// 内置的 desktop 渠道始终可用，对应桌面通知器；未配置渠道的规则在内容变化时默认使用它。
+type Router struct {
+	mu       sync.RWMutex
+	channels map[string]models.ChannelConfig
+	smtp     *models.SMTPConfig
+	desktop  Notifier
+
+	// dispatcher 为nil时同步发送通知
+	dispatcher *Dispatcher
+
+	// outbox 为nil时发送失败的通知不会重试
+	outbox        *Outbox
+	retryInterval time.Duration
+	stopRetry     chan struct{}
+	retryDone     chan struct{}
+
+	limiter *limiter
+	digests *digester
+	now     func() time.Time
+}
+
+// NewRouter 创建通知路由，desktop为nil时使用空通知器
+func NewRouter(channels map[string]models.ChannelConfig, smtp *models.SMTPConfig, desktop Notifier) *Router {
+	if desktop == nil {
+		desktop = NewNoOpNotifier()
+	}
+	r := &Router{
+		channels: channels,
+		smtp:     smtp,
+		desktop:  desktop,
+		limiter:  newLimiter(),
+		now:      time.Now,
+
+		retryInterval: outboxRetryInterval,
+	}
+	r.digests = newDigester(r.deliverDigest)
+	return r
+}
+
+// SetDesktop 替换桌面通知器（用于Wails启动后替换）
+func (r *Router) SetDesktop(notifier Notifier) {
+	if notifier == nil {
+		notifier = NewNoOpNotifier()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.desktop = notifier
+}
+
+// SetDispatcher 设置异步通知分发器，需要在发送任何通知之前调用
+func (r *Router) SetDispatcher(dispatcher *Dispatcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dispatcher = dispatcher
+}
+
+// SetOutbox 设置发件箱，需要在 SetDispatcher 之后、发送任何通知之前调用
+//
+// 发往远程渠道（Webhook、聊天机器人、邮件）的通知先写入发件箱，发送失败后由 StartRetry 启动的后台任务按退避时间重试。
+func (r *Router) SetOutbox(outbox *Outbox) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outbox = outbox
+}
+
+// StartRetry 启动发件箱的后台重试，未设置发件箱或已启动时不做任何事
+//
+// 同一个发件箱文件只应有一个进程重试，否则会重复发送并互相覆盖投递状态。
+func (r *Router) StartRetry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.outbox == nil || r.stopRetry != nil {
+		return
+	}
+	r.stopRetry = make(chan struct{})
+	r.retryDone = make(chan struct{})
+	go r.retryLoop(r.outbox, r.retryInterval, r.stopRetry, r.retryDone)
+}
+
+// Close 停止发件箱重试，立即发送未到期的摘要，等待已排队的通知发送完成并关闭分发器
+//
+// ctx 结束时不再等待并返回错误。关闭时仍未送达的通知保留在发件箱中，下次启动后继续重试。
+func (r *Router) Close(ctx context.Context) error {
+	r.mu.Lock()
+	stopRetry, retryDone := r.stopRetry, r.retryDone
+	r.stopRetry = nil
+	r.mu.Unlock()
+
+	if stopRetry != nil {
+		close(stopRetry)
+		select {
+		case <-retryDone:
+		case <-ctx.Done():
+			return fmt.Errorf("等待通知重试结束超时: %w", context.Cause(ctx))
+		}
+	}
+
+	r.digests.close()
+
+	r.mu.RLock()
+	dispatcher := r.dispatcher
+	r.mu.RUnlock()
+
+	if dispatcher != nil {
+		return dispatcher.Close(ctx)
+	}
+	return nil
+}
+
+// HasChannel 检查渠道是否已定义
+func (r *Router) HasChannel(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.channels[name]
+	return ok || name == models.DesktopChannel
+}
+
+// ValidateRule 检查规则引用的渠道是否都已定义，以及规则的消息模板是否有效
+func (r *Router) ValidateRule(rule *models.MonitorRule) error {
+	if err := ValidateTemplate(rule.Template, rule); err != nil {
+		return fmt.Errorf("消息模板无效: %w", err)
+	}
+
+	for _, names := range [][]string{rule.Channels, rule.ErrorChannels} {
+		for _, name := range names {
+			if !r.HasChannel(name) {
+				return fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve 返回规则在指定事件下的通知目标
+//
+// 内容变化通知发送到规则的 Channels（为空时为桌面通知）以及规则内联的Webhook、聊天机器人和邮件；
+// 检查失败和恢复通知只发送到规则的 ErrorChannels。无法创建的渠道会被跳过并记录警告。
+func (r *Router) Resolve(rule *models.MonitorRule, event string) []Target {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alert := isAlert(event)
+
+	var names []string
+	if alert {
+		names = rule.ErrorChannels
+	} else {
+		names = rule.Channels
+		if len(names) == 0 {
+			names = []string{models.DesktopChannel}
+		}
+	}
+
+	targets := make([]Target, 0, len(names))
+	for _, name := range names {
+		target, err := r.channel(name)
+		if err != nil {
+			slog.Warn("跳过通知渠道",
+				"rule_id", rule.ID,
+				"channel", name,
+				"error", err,
+			)
+			continue
+		}
+		targets = append(targets, target)
+	}
+
+	if !alert {
+		targets = append(targets, r.inlineTargets(rule)...)
+	}
+	return targets
+}
+
+// Send 将消息发送给规则在该事件下的所有通知目标
+//
+// 依次经过规则的频率限制、渠道的频率限制、模板和渠道的摘要合并，超出频率限制的通知被丢弃。
+// 检查失败和恢复通知每轮连续失败最多各发送一次，不受规则的频率限制，以免内容变化通知用完额度后告警丢失。
+// 设置了分发器时通知只放入队列，返回的错误仅包括入队失败，发送失败由分发器记录日志；
+// 设置了发件箱时，发往远程渠道的通知发送失败后会按退避时间重试。
+// 某个目标失败不影响其他目标，所有错误附带渠道名称合并后返回。
+func (r *Router) Send(rule *models.MonitorRule, msg *Message) error {
+	now := r.now()
+	if !isAlert(msg.Event) && !r.limiter.allow("rule:"+rule.ID, rule.RateLimit, now) {
+		slog.Info("通知超出规则的频率限制，已丢弃",
+			"rule_id", rule.ID,
+			"event", msg.Event,
+		)
+		return nil
+	}
+
+	var errs []error
+	for _, target := range r.Resolve(rule, msg.Event) {
+		if !r.limiter.allow("channel:"+target.Name, target.RateLimit, now) {
+			slog.Info("通知超出渠道的频率限制，已丢弃",
+				"rule_id", rule.ID,
+				"channel", target.Name,
+				"event", msg.Event,
+			)
+			continue
+		}
+
+		rendered := render(rule, target, msg)
+		if target.Digest > 0 {
+			r.digests.add(target, target.Digest, rendered)
+			continue
+		}
+
+		if err := r.deliver(target, rendered); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver 发送一条通知，设置了分发器时放入队列
+//
+// 设置了发件箱时，发往远程渠道的通知先写入发件箱，之后由发件箱跟踪发送结果；
+// 写入发件箱失败时退化为不重试的直接发送。
+func (r *Router) deliver(target Target, msg *Message) error {
+	r.mu.RLock()
+	dispatcher, outbox := r.dispatcher, r.outbox
+	r.mu.RUnlock()
+
+	if outbox != nil && isRemote(target) {
+		id, err := outbox.add(target, msg)
+		if err == nil {
+			return r.attempt(outbox, dispatcher, id, target, msg)
+		}
+		slog.Warn("写入通知发件箱失败，发送失败后将不会重试",
+			"rule_id", msg.RuleID,
+			"channel", target.Name,
+			"error", err,
+		)
+	}
+
+	if dispatcher != nil {
+		return dispatcher.Enqueue(msg.RuleID, target, msg)
+	}
+	return Send(target.Notifier, msg)
+}
+
+// attempt 发送发件箱中的一条通知并记录结果
+//
+// 通知无法放入队列时保留在发件箱中等待下次重试，不返回错误；同步发送时返回发送错误。
+func (r *Router) attempt(outbox *Outbox, dispatcher *Dispatcher, id string, target Target, msg *Message) error {
+	complete := func(err error) {
+		if saveErr := outbox.complete(id, err); saveErr != nil {
+			slog.Warn("更新通知发件箱失败", "id", id, "error", saveErr)
+		}
+	}
+
+	if dispatcher == nil {
+		err := Send(target.Notifier, msg)
+		complete(err)
+		return err
+	}
+
+	job := dispatchJob{ruleID: msg.RuleID, target: target, msg: msg, done: complete}
+	if err := dispatcher.enqueue(job); err != nil {
+		outbox.release(id)
+		slog.Info("通知暂时无法放入队列，稍后重试",
+			"rule_id", msg.RuleID,
+			"channel", target.Name,
+			"error", err,
+		)
+	}
+	return nil
+}
+
+// retryLoop 定期重新发送发件箱中到期的通知，直到stop关闭
+func (r *Router) retryLoop(outbox *Outbox, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.retryDue(outbox)
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryDue 重新发送发件箱中所有到期的通知
+func (r *Router) retryDue(outbox *Outbox) {
+	for _, entry := range outbox.due(r.now()) {
+		r.retry(outbox, entry)
+	}
+}
+
+// retry 根据发件箱记录保存的渠道配置重新创建通知器并发送
+func (r *Router) retry(outbox *Outbox, entry outboxEntry) {
+	r.mu.RLock()
+	notifier, err := r.newNotifier(entry.Config)
+	dispatcher := r.dispatcher
+	r.mu.RUnlock()
+
+	if err != nil {
+		if saveErr := outbox.complete(entry.ID, err); saveErr != nil {
+			slog.Warn("更新通知发件箱失败", "id", entry.ID, "error", saveErr)
+		}
+		return
+	}
+
+	target := Target{Name: entry.Channel, Notifier: notifier, Config: entry.Config}
+	_ = r.attempt(outbox, dispatcher, entry.ID, target, entry.Message)
+}
+
+// Deliveries 按创建时间倒序返回发件箱中的投递记录，未设置发件箱时返回空列表
+func (r *Router) Deliveries(status DeliveryStatus, limit int) []*Delivery {
+	r.mu.RLock()
+	outbox := r.outbox
+	r.mu.RUnlock()
+
+	if outbox == nil {
+		return []*Delivery{}
+	}
+	return outbox.List(status, limit)
+}
+
+// Resend 立即重新发送发件箱中的一条通知（不论当前状态），并重置其尝试次数
+//
+// 通知正在发送中时不重复发送。
+func (r *Router) Resend(id string) error {
+	r.mu.RLock()
+	outbox := r.outbox
+	r.mu.RUnlock()
+
+	if outbox == nil {
+		return fmt.Errorf("%w: %s", ErrDeliveryNotFound, id)
+	}
+
+	entry, ok, err := outbox.begin(id)
+	if err != nil || !ok {
+		return err
+	}
+	r.retry(outbox, entry)
+	return nil
+}
+
+// isAlert 检查事件是否为检查失败或恢复通知
+func isAlert(event string) bool {
+	return event == EventMonitorError || event == EventMonitorRecovered
+}
+
+// isRemote 检查目标是否为需要经过发件箱的远程渠道
+func isRemote(target Target) bool {
+	switch target.Config.Type {
+	case models.ChannelWebhook, models.ChannelChat, models.ChannelEmail:
+		return true
+	}
+	return false
+}
+
+// deliverDigest 发送到期的摘要，失败时记录日志
+func (r *Router) deliverDigest(target Target, msg *Message) {
+	if err := r.deliver(target, msg); err != nil {
+		slog.Warn("发送通知摘要失败",
+			"channel", target.Name,
+			"count", max(len(msg.Items), 1),
+			"error", err,
+		)
+	}
+}
+
+// render 按目标的模板生成消息，模板无效或执行失败时返回原消息
+func render(rule *models.MonitorRule, target Target, msg *Message) *Message {
+	config := target.Template
+	if config.IsEmpty() {
+		config = rule.Template
+	}
+	if config.IsEmpty() {
+		return msg
+	}
+
+	tmpl, err := ParseTemplate(config)
+	if err == nil {
+		var rendered *Message
+		if rendered, err = tmpl.Apply(rule, msg); err == nil {
+			return rendered
+		}
+	}
+
+	slog.Warn("消息模板执行失败，使用默认格式",
+		"rule_id", rule.ID,
+		"channel", target.Name,
+		"error", err,
+	)
+	return msg
+}
+
+// channel 创建命名渠道的通知目标，调用方需持有读锁
+func (r *Router) channel(name string) (Target, error) {
+	config, ok := r.channels[name]
+	if !ok {
+		if name == models.DesktopChannel {
+			return Target{
+				Name:     name,
+				Notifier: r.desktop,
+				Config:   models.ChannelConfig{Type: models.ChannelDesktop},
+			}, nil
+		}
+		return Target{}, fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+
+	notifier, err := r.newNotifier(config)
+	if err != nil {
+		return Target{}, err
+	}
+	return Target{
+		Name:      name,
+		Notifier:  notifier,
+		Template:  config.Template,
+		RateLimit: config.RateLimit,
+		Digest:    time.Duration(config.Digest),
+		Config:    config,
+	}, nil
+}
+
+// newNotifier 根据渠道配置创建通知器，调用方需持有读锁
+func (r *Router) newNotifier(config models.ChannelConfig) (Notifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch config.Type {
+	case models.ChannelDesktop:
+		return r.desktop, nil
+	case models.ChannelWebhook:
+		return NewWebhookNotifier(*config.Webhook), nil
+	case models.ChannelChat:
+		return NewChatNotifier(*config.Chat), nil
+	case models.ChannelEmail:
+		if r.smtp == nil {
+			return nil, errors.New("未配置SMTP服务器")
+		}
+		return NewEmailNotifier(*r.smtp, config.EmailTo), nil
+	default:
+		return nil, fmt.Errorf("不支持的渠道类型: %s", config.Type)
+	}
+}
+
+// inlineTargets 根据规则内联的通知配置（Webhook、聊天机器人、邮件）创建通知目标，调用方需持有读锁
+func (r *Router) inlineTargets(rule *models.MonitorRule) []Target {
+	targets := make([]Target, 0, len(rule.Webhooks)+len(rule.Chats)+1)
+	for i, webhook := range rule.Webhooks {
+		targets = append(targets, Target{
+			Name:     inlineName(rule, "webhook#"+strconv.Itoa(i+1)),
+			Notifier: NewWebhookNotifier(webhook),
+			Config:   models.ChannelConfig{Type: models.ChannelWebhook, Webhook: &webhook},
+		})
+	}
+	for i, chat := range rule.Chats {
+		targets = append(targets, Target{
+			Name:     inlineName(rule, "chat#"+strconv.Itoa(i+1)),
+			Notifier: NewChatNotifier(chat),
+			Config:   models.ChannelConfig{Type: models.ChannelChat, Chat: &chat},
+		})
+	}
+
+	if len(rule.EmailTo) > 0 {
+		if r.smtp != nil {
+			targets = append(targets, Target{
+				Name:     inlineName(rule, "email"),
+				Notifier: NewEmailNotifier(*r.smtp, rule.EmailTo),
+				Config:   models.ChannelConfig{Type: models.ChannelEmail, EmailTo: rule.EmailTo},
+			})
+		} else {
+			slog.Warn("规则配置了邮件收件人，但未配置SMTP服务器", "rule_id", rule.ID)
+		}
+	}
+
+	return targets
+}
+
+// inlineName 返回规则内联通知目标的名称（"<规则ID>/<名称>"），
+// 不同规则的内联目标在渠道频率限制、摘要合并和投递记录中互不影响
+func inlineName(rule *models.MonitorRule, name string) string {
+	return rule.ID + "/" + name
+}
@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/models"
+)
+
+// newWebhookRecorder 启动记录Webhook请求体的服务器
+func newWebhookRecorder(t *testing.T) (*httptest.Server, func() []WebhookPayload) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var payloads []WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []WebhookPayload {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]WebhookPayload(nil), payloads...)
+	}
+}
+
+// targetNames 返回通知目标的名称列表
+func targetNames(targets []Target) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+func TestRouter_Resolve(t *testing.T) {
+	channels := map[string]models.ChannelConfig{
+		"ops":   {Type: models.ChannelWebhook, Webhook: &models.WebhookConfig{URL: "https://hooks.example.com/ops"}},
+		"popup": {Type: models.ChannelDesktop},
+		"mail":  {Type: models.ChannelEmail, EmailTo: []string{"ops@example.com"}},
+	}
+	router := NewRouter(channels, nil, NewMockNotifier())
+
+	t.Run("未配置渠道时使用桌面通知", func(t *testing.T) {
+		rule := &models.MonitorRule{ID: "r1"}
+		assert.Equal(t, []string{"desktop"}, targetNames(router.Resolve(rule, EventContentChanged)))
+		assert.Empty(t, router.Resolve(rule, EventMonitorError))
+	})
+
+	t.Run("命名渠道和内联配置", func(t *testing.T) {
+		rule := &models.MonitorRule{
+			ID:       "r1",
+			Channels: []string{"ops", "popup"},
+			Webhooks: []models.WebhookConfig{{URL: "https://hooks.example.com/a"}},
+			Chats:    []models.ChatConfig{{Platform: models.ChatSlack, WebhookURL: "https://hooks.slack.com/x"}},
+		}
+		assert.Equal(t, []string{"ops", "popup", "r1/webhook#1", "r1/chat#1"}, targetNames(router.Resolve(rule, EventContentChanged)))
+
+		other := &models.MonitorRule{ID: "r2", Webhooks: rule.Webhooks}
+		assert.Equal(t, []string{"desktop", "r2/webhook#1"}, targetNames(router.Resolve(other, EventContentChanged)), "不同规则的内联目标名称不同")
+	})
+
+	t.Run("错误通知只发送到错误渠道", func(t *testing.T) {
+		rule := &models.MonitorRule{
+			ID:            "r1",
+			ErrorChannels: []string{"ops"},
+			Webhooks:      []models.WebhookConfig{{URL: "https://hooks.example.com/a"}},
+		}
+		assert.Equal(t, []string{"ops"}, targetNames(router.Resolve(rule, EventMonitorError)))
+		assert.Equal(t, []string{"ops"}, targetNames(router.Resolve(rule, EventMonitorRecovered)))
+	})
+
+	t.Run("跳过无法创建的渠道", func(t *testing.T) {
+		// mail 渠道需要SMTP配置，missing 未定义
+		rule := &models.MonitorRule{ID: "r1", Channels: []string{"mail", "missing", "desktop"}}
+		assert.Equal(t, []string{"desktop"}, targetNames(router.Resolve(rule, EventContentChanged)))
+	})
+}
+
+func TestRouter_ValidateRule(t *testing.T) {
+	router := NewRouter(map[string]models.ChannelConfig{
+		"ops": {Type: models.ChannelDesktop},
+	}, nil, nil)
+
+	assert.NoError(t, router.ValidateRule(&models.MonitorRule{
+		Channels:      []string{"ops", "desktop"},
+		ErrorChannels: []string{"ops"},
+	}))
+
+	err := router.ValidateRule(&models.MonitorRule{ErrorChannels: []string{"missing"}})
+	require.ErrorIs(t, err, ErrUnknownChannel)
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestRouter_Send(t *testing.T) {
+	server, payloads := newWebhookRecorder(t)
+	desktop := NewMockNotifier()
+	router := NewRouter(map[string]models.ChannelConfig{
+		"ops": {Type: models.ChannelWebhook, Webhook: &models.WebhookConfig{URL: server.URL}},
+	}, nil, desktop)
+
+	rule := &models.MonitorRule{
+		ID:            "r1",
+		Channels:      []string{"desktop", "ops"},
+		ErrorChannels: []string{"ops"},
+	}
+
+	t.Run("内容变化通知发送到所有渠道", func(t *testing.T) {
+		require.NoError(t, router.Send(rule, &Message{Event: EventContentChanged, Title: "内容变化: 规则", Body: "正文"}))
+
+		assert.Len(t, desktop.GetNotifications(), 1)
+		require.Len(t, payloads(), 1)
+		assert.Equal(t, EventContentChanged, payloads()[0].Event)
+	})
+
+	t.Run("检查失败通知", func(t *testing.T) {
+		desktop.Clear()
+		require.NoError(t, router.Send(rule, &Message{Event: EventMonitorError, Title: "检查失败: 规则", Error: "超时"}))
+
+		assert.Empty(t, desktop.GetNotifications())
+		require.Len(t, payloads(), 2)
+		assert.Equal(t, EventMonitorError, payloads()[1].Event)
+		assert.Equal(t, "超时", payloads()[1].Error)
+	})
+
+	t.Run("失败的渠道不影响其他渠道", func(t *testing.T) {
+		failing := NewMockNotifier()
+		failing.SetError(true)
+		router.SetDesktop(failing)
+
+		err := router.Send(rule, &Message{Title: "标题", Body: "正文"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "desktop: ")
+		assert.Len(t, payloads(), 3)
+	})
+}
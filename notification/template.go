@@ -0,0 +1,218 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+)
+
+// TemplateData 通知模板可用的数据
+type TemplateData struct {
+	// Event 事件类型：content_changed、monitor_error 或 monitor_recovered
+	Event string
+
+	// Rule 触发通知的规则
+	Rule *models.MonitorRule
+
+	// Title 和 Body 为默认格式的标题和正文
+	Title string
+	Body  string
+
+	OldContent string
+	NewContent string
+
+	// Diff 内容变化时的差异，检查失败时为nil
+	Diff *diff.Result
+
+	StatusCode int
+	DurationMs int64
+
+	// Stage 和 Error 为检查失败的阶段和错误信息，恢复通知的 Error 为恢复前最后一次失败的错误信息
+	Stage string
+	Error string
+
+	// Failures 连续失败次数，FailureDuration 从开始失败到本次检查的时长
+	Failures        int
+	FailingSince    time.Time
+	FailureDuration time.Duration
+
+	Timestamp time.Time
+}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	// truncate 截断为最多n个字符，超出部分以...表示
+	"truncate": func(n int, s string) string {
+		if runes := []rune(s); n <= 3 && len(runes) > n {
+			return string(runes[:max(n, 0)])
+		}
+		return truncateRunes(s, n)
+	},
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"replace":    strings.ReplaceAll,
+	"formatTime": func(layout string, t time.Time) string { return t.Format(layout) },
+}
+
+// Template 已解析的通知消息模板
+type Template struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// ParseTemplate 解析通知消息模板，未设置的标题或正文使用默认格式
+func ParseTemplate(config *models.MessageTemplate) (*Template, error) {
+	t := &Template{}
+	if config == nil {
+		return t, nil
+	}
+
+	var err error
+	if config.Title != "" {
+		if t.title, err = template.New("title").Funcs(templateFuncs).Parse(config.Title); err != nil {
+			return nil, fmt.Errorf("标题模板无效: %w", err)
+		}
+	}
+	if config.Body != "" {
+		if t.body, err = template.New("body").Funcs(templateFuncs).Parse(config.Body); err != nil {
+			return nil, fmt.Errorf("正文模板无效: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// Apply 使用模板生成消息的标题和正文，返回新的消息
+func (t *Template) Apply(rule *models.MonitorRule, msg *Message) (*Message, error) {
+	data := newTemplateData(rule, msg)
+	result := *msg
+
+	if t.title != nil {
+		title, err := execute(t.title, data)
+		if err != nil {
+			return nil, fmt.Errorf("生成标题失败: %w", err)
+		}
+		// 标题只取第一行
+		title, _, _ = strings.Cut(strings.TrimSpace(title), "\n")
+		result.Title = title
+	}
+
+	if t.body != nil {
+		body, err := execute(t.body, data)
+		if err != nil {
+			return nil, fmt.Errorf("生成正文失败: %w", err)
+		}
+		result.Body = body
+		result.Templated = true
+	}
+
+	return &result, nil
+}
+
+// ValidateTemplate 检查模板能否解析，并以示例数据分别按内容变化和检查失败渲染
+//
+// rule为nil时使用示例规则。
+func ValidateTemplate(config *models.MessageTemplate, rule *models.MonitorRule) error {
+	if config.IsEmpty() {
+		return nil
+	}
+
+	t, err := ParseTemplate(config)
+	if err != nil {
+		return err
+	}
+
+	if rule == nil {
+		rule = &models.MonitorRule{
+			ID:          "example",
+			Name:        "示例规则",
+			URL:         "https://example.com",
+			Method:      models.DefaultMethod,
+			Description: "示例",
+		}
+	}
+
+	now := time.Now()
+	samples := []*Message{
+		{
+			Event:      EventContentChanged,
+			OldContent: "old",
+			NewContent: "new",
+			Diff:       diff.Compute("old", "new"),
+			StatusCode: 200,
+			Timestamp:  now,
+		},
+		{
+			Event:        EventMonitorError,
+			Stage:        "fetch",
+			Error:        "connection refused",
+			Failures:     3,
+			FailingSince: now.Add(-10 * time.Minute),
+			Timestamp:    now,
+		},
+		{
+			Event:        EventMonitorRecovered,
+			Error:        "connection refused",
+			Failures:     3,
+			FailingSince: now.Add(-15 * time.Minute),
+			StatusCode:   200,
+			Timestamp:    now,
+		},
+	}
+	for _, sample := range samples {
+		if _, err := t.Apply(rule, sample); err != nil {
+			return fmt.Errorf("%s事件: %w", sample.Event, err)
+		}
+	}
+	return nil
+}
+
+// newTemplateData 根据规则和默认消息生成模板数据
+func newTemplateData(rule *models.MonitorRule, msg *Message) *TemplateData {
+	event := msg.Event
+	if event == "" {
+		event = EventContentChanged
+	}
+
+	return &TemplateData{
+		Event:      event,
+		Rule:       rule,
+		Title:      msg.Title,
+		Body:       msg.Body,
+		OldContent: msg.OldContent,
+		NewContent: msg.NewContent,
+		Diff:       msg.Diff,
+		StatusCode: msg.StatusCode,
+		DurationMs: msg.DurationMs,
+		Stage:      msg.Stage,
+		Error:      msg.Error,
+		Timestamp:  msg.Timestamp,
+
+		Failures:        msg.Failures,
+		FailingSince:    msg.FailingSince,
+		FailureDuration: FailureDuration(msg.FailingSince, msg.Timestamp),
+	}
+}
+
+// FailureDuration 返回从 since 开始失败到 at 的时长（精确到秒），since 为零值时为0
+//
+// 通知正文和模板使用同一个函数，保证同一次故障在不同渠道中显示相同的时长。
+func FailureDuration(since, at time.Time) time.Duration {
+	if since.IsZero() || at.Before(since) {
+		return 0
+	}
+	return at.Sub(since).Round(time.Second)
+}
+
+// execute 执行模板
+func execute(t *template.Template, data *TemplateData) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
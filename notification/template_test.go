@@ -0,0 +1,147 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+)
+
+func TestTemplate_Apply(t *testing.T) {
+	rule := &models.MonitorRule{ID: "r1", Name: "价格", URL: "https://example.com/price", Method: "GET"}
+	msg := &Message{
+		Event:      EventContentChanged,
+		Title:      "内容变化: 价格",
+		Body:       "默认正文",
+		OldContent: "100",
+		NewContent: "120",
+		Diff:       diff.Compute("100", "120"),
+		StatusCode: 200,
+		Timestamp:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("标题和正文", func(t *testing.T) {
+		tmpl, err := ParseTemplate(&models.MessageTemplate{
+			Title: "[{{.Rule.Name | upper}}] {{.OldContent}} → {{.NewContent}}\n第二行被忽略",
+			Body:  "{{.Rule.Method}} {{.Rule.URL}} {{.StatusCode}}\n{{.Diff.Summary}}\n{{formatTime \"2006-01-02\" .Timestamp}}",
+		})
+		require.NoError(t, err)
+
+		got, err := tmpl.Apply(rule, msg)
+		require.NoError(t, err)
+		assert.Equal(t, "[价格] 100 → 120", got.Title)
+		assert.Equal(t, "GET https://example.com/price 200\n+1 -1 行\n2024-01-02", got.Body)
+		assert.True(t, got.Templated)
+		assert.Same(t, msg.Diff, got.Diff, "其他字段保持不变")
+		assert.False(t, msg.Templated, "不修改原消息")
+	})
+
+	t.Run("只设置标题时正文使用默认格式", func(t *testing.T) {
+		tmpl, err := ParseTemplate(&models.MessageTemplate{Title: "{{.Event}}"})
+		require.NoError(t, err)
+
+		got, err := tmpl.Apply(rule, msg)
+		require.NoError(t, err)
+		assert.Equal(t, "content_changed", got.Title)
+		assert.Equal(t, "默认正文", got.Body)
+		assert.False(t, got.Templated)
+	})
+
+	t.Run("恢复通知的失败时长", func(t *testing.T) {
+		tmpl, err := ParseTemplate(&models.MessageTemplate{
+			Body: "{{.Event}}: 连续失败 {{.Failures}} 次，持续 {{.FailureDuration}}，最后错误 {{.Error}}",
+		})
+		require.NoError(t, err)
+
+		recovered := &Message{
+			Event:        EventMonitorRecovered,
+			Error:        "timeout",
+			Failures:     3,
+			FailingSince: msg.Timestamp.Add(-90*time.Second - 400*time.Millisecond),
+			Timestamp:    msg.Timestamp,
+		}
+		got, err := tmpl.Apply(rule, recovered)
+		require.NoError(t, err)
+		assert.Equal(t, "monitor_recovered: 连续失败 3 次，持续 1m30s，最后错误 timeout", got.Body)
+	})
+
+	t.Run("截断", func(t *testing.T) {
+		tmpl, err := ParseTemplate(&models.MessageTemplate{Body: `{{truncate 5 "abcdefgh"}}|{{truncate 2 "abc"}}`})
+		require.NoError(t, err)
+
+		got, err := tmpl.Apply(rule, msg)
+		require.NoError(t, err)
+		assert.Equal(t, "ab...|ab", got.Body)
+	})
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template *models.MessageTemplate
+		want     string
+	}{
+		{name: "未设置模板", template: nil},
+		{name: "有效的模板", template: &models.MessageTemplate{Body: "{{.Rule.Name}}{{with .Diff}}{{.Summary}}{{end}}{{.Error}}"}},
+		{name: "语法错误", template: &models.MessageTemplate{Title: "{{.Rule.Name"}, want: "标题模板无效"},
+		{name: "未知函数", template: &models.MessageTemplate{Body: "{{nope .Rule}}"}, want: "正文模板无效"},
+		{name: "未知字段", template: &models.MessageTemplate{Body: "{{.Rule.Missing}}"}, want: "content_changed事件"},
+		{name: "检查失败时差异为空", template: &models.MessageTemplate{Body: "{{.Diff.Unified}}"}, want: "monitor_error事件"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTemplate(tt.template, nil)
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRouter_SendWithTemplate(t *testing.T) {
+	server, payloads := newWebhookRecorder(t)
+	desktop := NewMockNotifier()
+	router := NewRouter(map[string]models.ChannelConfig{
+		"ops": {
+			Type:     models.ChannelWebhook,
+			Webhook:  &models.WebhookConfig{URL: server.URL},
+			Template: &models.MessageTemplate{Title: "ops: {{.Rule.Name}}"},
+		},
+	}, nil, desktop)
+
+	rule := &models.MonitorRule{
+		ID:       "r1",
+		Name:     "价格",
+		Channels: []string{"desktop", "ops"},
+		Template: &models.MessageTemplate{Title: "规则: {{.Rule.Name}}", Body: "{{.NewContent}}"},
+	}
+	require.NoError(t, router.Send(rule, &Message{Title: "内容变化: 价格", NewContent: "120"}))
+
+	t.Run("使用规则模板", func(t *testing.T) {
+		require.Len(t, desktop.GetNotifications(), 1)
+		assert.Equal(t, "规则: 价格", desktop.GetNotifications()[0].Title)
+		assert.Equal(t, "120", desktop.GetNotifications()[0].Message)
+	})
+
+	t.Run("渠道模板优先", func(t *testing.T) {
+		require.Len(t, payloads(), 1)
+		assert.Equal(t, "ops: 价格", payloads()[0].Title)
+		assert.Empty(t, payloads()[0].Body, "渠道模板未设置正文时使用默认正文")
+	})
+
+	t.Run("模板执行失败时使用默认格式", func(t *testing.T) {
+		desktop.Clear()
+		rule := &models.MonitorRule{ID: "r2", Template: &models.MessageTemplate{Body: "{{.Diff.Unified}}"}}
+		require.NoError(t, router.Send(rule, &Message{Title: "标题", Body: "正文"}))
+
+		require.Len(t, desktop.GetNotifications(), 1)
+		assert.Equal(t, "正文", desktop.GetNotifications()[0].Message)
+	})
+}
@@ -2,43 +2,62 @@ package notification
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/gen2brain/beeep"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"github.com/zx06/apiwatch/models"
 )
 
+// maxDesktopMessage 桌面通知正文的最大字符数
+const maxDesktopMessage = 200
+
 // WailsNotifier Wails通知器实现
+//
+// 消息对话框会阻塞到用户关闭为止，应通过 Dispatcher 异步调用。
 type WailsNotifier struct {
-	ctx context.Context
+	ctx  context.Context
+	mode models.DesktopMode
 }
 
-// NewWailsNotifier 创建Wails通知器
-func NewWailsNotifier(ctx context.Context) *WailsNotifier {
+// NewWailsNotifier 创建Wails通知器，mode为空时只发送系统通知
+func NewWailsNotifier(ctx context.Context, mode models.DesktopMode) *WailsNotifier {
+	if mode == "" {
+		mode = models.DesktopToast
+	}
 	return &WailsNotifier{
-		ctx: ctx,
+		ctx:  ctx,
+		mode: mode,
 	}
 }
 
-// Notify 实现Notifier接口，发送系统通知
+// Notify 实现Notifier接口，按配置发送系统通知和/或消息对话框
 func (n *WailsNotifier) Notify(title, message string) error {
 	if n.ctx == nil {
 		return nil // 如果context为nil，静默失败
 	}
 
 	// 限制消息长度
-	if len(message) > 200 {
-		message = message[:197] + "..."
-	}
+	message = truncateRunes(message, maxDesktopMessage)
 
-	// 使用Wails runtime发送消息对话框
-	_, err := runtime.MessageDialog(n.ctx, runtime.MessageDialogOptions{
-		Type:    runtime.InfoDialog,
-		Title:   title,
-		Message: message,
-	})
+	var errs []error
+	if n.mode == models.DesktopToast || n.mode == models.DesktopBoth {
+		if err := beeep.Notify(title, message, ""); err != nil {
+			errs = append(errs, fmt.Errorf("发送系统通知失败: %w", err))
+		}
+	}
 
-	// 用beeep发送通知
-	err = beeep.Notify(title, message, "")
+	if n.mode == models.DesktopDialog || n.mode == models.DesktopBoth {
+		_, err := runtime.MessageDialog(n.ctx, runtime.MessageDialogOptions{
+			Type:    runtime.InfoDialog,
+			Title:   title,
+			Message: message,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("显示消息对话框失败: %w", err))
+		}
+	}
 
-	return err
+	return errors.Join(errs...)
 }
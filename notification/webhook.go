@@ -0,0 +1,177 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+)
+
+const (
+	// DefaultWebhookTimeout Webhook请求的默认超时时间
+	DefaultWebhookTimeout = 10 * time.Second
+
+	// WebhookSignatureHeader 签名请求头，值为 sha256=<十六进制HMAC>
+	WebhookSignatureHeader = "X-APIWatch-Signature"
+
+	// WebhookTimestampHeader 签名时间戳请求头（Unix秒）
+	WebhookTimestampHeader = "X-APIWatch-Timestamp"
+)
+
+// WebhookPayload Webhook请求体
+type WebhookPayload struct {
+	Event       string       `json:"event"`
+	RuleID      string       `json:"rule_id,omitempty"`
+	RuleName    string       `json:"rule_name,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Title       string       `json:"title"`
+	Body        string       `json:"body"`
+	OldContent  string       `json:"old_content,omitempty"`
+	NewContent  string       `json:"new_content,omitempty"`
+	Diff        *diff.Result `json:"diff,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Timestamp   time.Time    `json:"timestamp"`
+
+	// Failures 和 FailingSince 为检查失败和恢复通知的连续失败次数和开始时间
+	Failures     int       `json:"failures,omitempty"`
+	FailingSince time.Time `json:"failing_since,omitzero"`
+
+	// Items 摘要包含的各条通知
+	Items []WebhookPayload `json:"items,omitempty"`
+}
+
+// WebhookNotifier 以JSON POST请求发送通知
+type WebhookNotifier struct {
+	config models.WebhookConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookNotifier 创建Webhook通知器
+func NewWebhookNotifier(config models.WebhookConfig) *WebhookNotifier {
+	timeout := time.Duration(config.Timeout)
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+
+	return &WebhookNotifier{
+		config: config,
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}
+}
+
+// Notify 发送只包含标题和正文的通知
+func (n *WebhookNotifier) Notify(title, message string) error {
+	return n.NotifyMessage(&Message{
+		Title:     title,
+		Body:      message,
+		Timestamp: n.now(),
+	})
+}
+
+// NotifyMessage 发送包含完整信息的通知
+//
+// 只发送一次，失败后的重试由通知发件箱负责；重试也不会成功的错误包装 ErrPermanent。
+func (n *WebhookNotifier) NotifyMessage(msg *Message) error {
+	body, err := json.Marshal(n.newPayload(msg))
+	if err != nil {
+		return fmt.Errorf("序列化Webhook请求失败: %w", err)
+	}
+
+	if err := n.send(body); err != nil {
+		return fmt.Errorf("发送Webhook通知失败: %w", err)
+	}
+	return nil
+}
+
+// newPayload 根据通知生成请求体
+func (n *WebhookNotifier) newPayload(msg *Message) WebhookPayload {
+	payload := WebhookPayload{
+		Event:       msg.Event,
+		RuleID:      msg.RuleID,
+		RuleName:    msg.RuleName,
+		URL:         msg.URL,
+		Description: msg.Description,
+		Title:       msg.Title,
+		Body:        msg.Body,
+		OldContent:  msg.OldContent,
+		NewContent:  msg.NewContent,
+		Diff:        msg.Diff,
+		Error:       msg.Error,
+		Timestamp:   msg.Timestamp,
+
+		Failures:     msg.Failures,
+		FailingSince: msg.FailingSince,
+	}
+	if payload.Event == "" {
+		payload.Event = EventContentChanged
+	}
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = n.now()
+	}
+
+	for _, item := range msg.Items {
+		payload.Items = append(payload.Items, n.newPayload(item))
+	}
+	return payload
+}
+
+// send 发送一次请求
+func (n *WebhookNotifier) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: 创建请求失败: %w", ErrPermanent, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "URL-Monitor/1.0")
+	for key, value := range n.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if n.config.Secret != "" {
+		timestamp := strconv.FormatInt(n.now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(n.config.Secret, timestamp, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// 服务端错误和限流可以重试，其他客户端错误重试也不会成功
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return fmt.Errorf("%w: HTTP错误: %s", ErrPermanent, resp.Status)
+		}
+		return fmt.Errorf("HTTP错误: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// SignWebhook 计算Webhook签名
+//
+// 签名内容为 "<时间戳>.<请求体>"，接收方应使用相同的密钥计算后与请求头中的签名比较，
+// 并拒绝时间戳过旧的请求以防止重放。
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
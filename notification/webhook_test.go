@@ -0,0 +1,171 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/diff"
+	"github.com/zx06/apiwatch/models"
+)
+
+func TestWebhookNotifier_NotifyMessage(t *testing.T) {
+	var (
+		received  WebhookPayload
+		header    http.Header
+		rawBody   []byte
+		timestamp = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		header = r.Header.Clone()
+		rawBody, _ = io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(rawBody, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(models.WebhookConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Team": "ops"},
+		Secret:  "s3cret",
+	})
+	n.now = func() time.Time { return timestamp }
+
+	msg := &Message{
+		Title:      "内容变化: 价格",
+		Body:       "+1 -1 行",
+		RuleID:     "rule-1",
+		RuleName:   "价格",
+		URL:        "https://example.com/price",
+		OldContent: "100",
+		NewContent: "120",
+		Diff:       diff.Compute("100", "120"),
+		Timestamp:  timestamp,
+	}
+	require.NoError(t, n.NotifyMessage(msg))
+
+	t.Run("请求体包含完整信息", func(t *testing.T) {
+		assert.Equal(t, EventContentChanged, received.Event)
+		assert.Equal(t, "rule-1", received.RuleID)
+		assert.Equal(t, "价格", received.RuleName)
+		assert.Equal(t, "https://example.com/price", received.URL)
+		assert.Equal(t, "100", received.OldContent)
+		assert.Equal(t, "120", received.NewContent)
+		require.NotNil(t, received.Diff)
+		assert.Equal(t, 1, received.Diff.Added)
+		assert.True(t, timestamp.Equal(received.Timestamp))
+	})
+
+	t.Run("请求头", func(t *testing.T) {
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "ops", header.Get("X-Team"))
+	})
+
+	t.Run("签名可以验证", func(t *testing.T) {
+		ts := header.Get(WebhookTimestampHeader)
+		assert.Equal(t, "1704164645", ts)
+		assert.Equal(t, "sha256="+SignWebhook("s3cret", ts, rawBody), header.Get(WebhookSignatureHeader))
+		assert.NotEqual(t, "sha256="+SignWebhook("other", ts, rawBody), header.Get(WebhookSignatureHeader))
+	})
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received WebhookPayload
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(models.WebhookConfig{URL: server.URL})
+	require.NoError(t, n.Notify("标题", "正文"))
+
+	assert.Equal(t, "标题", received.Title)
+	assert.Equal(t, "正文", received.Body)
+	assert.False(t, received.Timestamp.IsZero())
+	assert.Empty(t, header.Get(WebhookSignatureHeader), "未设置密钥时不签名")
+}
+
+func TestWebhookNotifier_Digest(t *testing.T) {
+	server, payloads := newWebhookRecorder(t)
+	n := NewWebhookNotifier(models.WebhookConfig{URL: server.URL})
+
+	items := []*Message{
+		{Event: EventContentChanged, RuleID: "r1", Title: "内容变化: A", NewContent: "1"},
+		{Event: EventMonitorError, RuleID: "r2", Title: "检查失败: B", Error: "超时"},
+	}
+	require.NoError(t, n.NotifyMessage(newDigestMessage(items, time.Hour, time.Now())))
+
+	require.Len(t, payloads(), 1)
+	payload := payloads()[0]
+	assert.Equal(t, EventDigest, payload.Event)
+	require.Len(t, payload.Items, 2)
+	assert.Equal(t, "r1", payload.Items[0].RuleID)
+	assert.Equal(t, "1", payload.Items[0].NewContent)
+	assert.Equal(t, EventMonitorError, payload.Items[1].Event)
+	assert.Equal(t, "超时", payload.Items[1].Error)
+}
+
+func TestWebhookNotifier_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{name: "发送成功", status: 200, wantErr: false},
+		{name: "服务端错误可以重试", status: 502, wantErr: true, wantPermanent: false},
+		{name: "限流可以重试", status: 429, wantErr: true, wantPermanent: false},
+		{name: "客户端错误无法送达", status: 400, wantErr: true, wantPermanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			// 重试由发件箱负责，通知器只发送一次
+			n := NewWebhookNotifier(models.WebhookConfig{URL: server.URL, MaxRetries: 3})
+			err := n.Notify("标题", "正文")
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantPermanent, errors.Is(err, ErrPermanent))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, int32(1), attempts.Load())
+		})
+	}
+}
+
+func TestWebhookNotifier_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	n := NewWebhookNotifier(models.WebhookConfig{
+		URL:     server.URL,
+		Timeout: models.Duration(50 * time.Millisecond),
+	})
+
+	start := time.Now()
+	err := n.Notify("标题", "正文")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,255 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// 嵌入时区数据，保证在没有系统时区数据库的平台（如Windows）上也能解析时区
+	_ "time/tzdata"
+)
+
+// bits 字段允许取值的位集合
+type bits uint64
+
+// has 检查取值是否在集合中
+func (b bits) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+// field cron表达式中一个字段的定义
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+
+	// wrapEnd 为true时，小于起点的范围终点 min 按 max 处理（星期中 MON-SUN 的 SUN 为7）
+	wrapEnd bool
+}
+
+var (
+	monthNames = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	dayNames = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+
+	// fields 依次为分钟、小时、日、月、星期，星期中0和7都表示星期日
+	fields = [5]field{
+		{name: "分钟", min: 0, max: 59},
+		{name: "小时", min: 0, max: 23},
+		{name: "日", min: 1, max: 31},
+		{name: "月", min: 1, max: 12, names: monthNames},
+		{name: "星期", min: 0, max: 7, names: dayNames, wrapEnd: true},
+	}
+
+	// macros 预定义的表达式
+	macros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// searchYears 查找下次执行时间的最大年数，超过后认为表达式没有可执行的时间（如2月30日）
+const searchYears = 5
+
+// Cron 解析后的cron表达式
+//
+// 支持标准的5个字段（分钟 小时 日 月 星期），每个字段可以是 *、数值、范围 a-b、步长 */n 或 a-b/n
+// 以及逗号分隔的列表，月和星期支持英文缩写（JAN、MON）。日和星期都不是 * 时，满足其一即可。
+// 还支持 @hourly、@daily、@weekly、@monthly、@yearly 等预定义表达式。
+type Cron struct {
+	expr string
+
+	minute, hour, dom, month, dow bits
+
+	// domStar 和 dowStar 表示日和星期字段以 * 开头（不限制）
+	domStar, dowStar bool
+}
+
+// Parse 解析cron表达式
+func Parse(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron表达式需要%d个字段（分钟 小时 日 月 星期）: %q", len(fields), expr)
+	}
+
+	var values [5]bits
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = b
+	}
+
+	// 星期中的7与0相同，都表示星期日
+	dow := values[4]
+	if dow.has(7) {
+		dow = (dow | 1) &^ (1 << 7)
+	}
+
+	return &Cron{
+		expr:    expr,
+		minute:  values[0],
+		hour:    values[1],
+		dom:     values[2],
+		month:   values[3],
+		dow:     dow,
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// String 返回原始表达式
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next 返回 t 之后（不含 t）第一个满足表达式的时间，按 t 所在的时区计算
+//
+// 在 searchYears 年内找不到时返回零值。夏令时切换时跳过不存在的本地时间。
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	// 从下一整分钟开始
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		// 小时和分钟使用绝对时间前进，避免夏令时重复的时段中回到更早的时间
+		if !c.hour.has(t.Hour()) {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 检查日期是否满足日和星期字段
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom.has(t.Day())
+	dowMatch := c.dow.has(int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField 解析一个字段
+func parseField(s string, f field) (bits, error) {
+	var result bits
+	for _, part := range strings.Split(s, ",") {
+		b, err := parseRange(part, f)
+		if err != nil {
+			return 0, fmt.Errorf("%s字段无效: %w", f.name, err)
+		}
+		result |= b
+	}
+	return result, nil
+}
+
+// parseRange 解析字段中逗号分隔的一项：*、n、a-b，可带步长 /n
+func parseRange(s string, f field) (bits, error) {
+	rangePart, stepPart, hasStep := strings.Cut(s, "/")
+
+	var start, end int
+	switch {
+	case rangePart == "*":
+		start, end = f.min, f.max
+	case strings.Contains(rangePart, "-"):
+		lo, hi, _ := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = parseValue(lo, f); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(hi, f); err != nil {
+			return 0, err
+		}
+		if f.wrapEnd && end == f.min && start > end {
+			end = f.max
+		}
+		if start > end {
+			return 0, fmt.Errorf("范围起点大于终点: %q", s)
+		}
+	default:
+		var err error
+		if start, err = parseValue(rangePart, f); err != nil {
+			return 0, err
+		}
+		end = start
+		// a/n 表示从a开始到最大值
+		if hasStep {
+			end = f.max
+		}
+	}
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepPart)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("无效的步长: %q", s)
+		}
+		step = n
+	}
+
+	var b bits
+	for v := start; v <= end; v += step {
+		b |= 1 << uint(v)
+	}
+	return b, nil
+}
+
+// parseValue 解析单个数值或名称，并检查取值范围
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的值: %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%d 超出范围 %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// LoadLocation 加载时区，名称为空时使用本地时区
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %q: %w", name, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"*/5 9-18 * * MON-FRI",
+		"0,30 8-20/2 1,15 jan-jun 0,7",
+		"15 3 * * sun",
+		"0 9 * * MON-SUN",
+		"0 9 * * 5-0",
+		"@daily",
+		"@Hourly",
+	}
+	for _, expr := range valid {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.NoError(t, err)
+		})
+	}
+
+	invalid := map[string]string{
+		"字段数量错误": "* * * *",
+		"超出范围":   "60 * * * *",
+		"无效的名称":  "* * * FOO *",
+		"无效的步长":  "*/0 * * * *",
+		"范围颠倒":   "* 18-9 * * *",
+		"星期范围颠倒": "* * * * SAT-FRI",
+		"空表达式":   "",
+	}
+	for name, expr := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	shanghai, err := LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "每5分钟",
+			expr: "*/5 * * * *",
+			from: time.Date(2024, 1, 1, 10, 2, 30, 0, time.UTC),
+			want: time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC),
+		},
+		{
+			name: "不包含起始时间",
+			expr: "*/5 * * * *",
+			from: time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 1, 10, 10, 0, 0, time.UTC),
+		},
+		{
+			name: "工作时间结束后跳到下一个工作日",
+			expr: "*/5 9-18 * * MON-FRI",
+			from: time.Date(2024, 1, 5, 18, 56, 0, 0, shanghai), // 星期五
+			want: time.Date(2024, 1, 8, 9, 0, 0, 0, shanghai),
+		},
+		{
+			name: "日和星期满足其一",
+			expr: "0 0 13 * FRI",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "星期范围以星期日结束",
+			expr: "0 12 * * FRI-SUN",
+			from: time.Date(2024, 1, 6, 13, 0, 0, 0, time.UTC), // 星期六
+			want: time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "星期7表示星期日",
+			expr: "0 12 * * 7",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "闰年2月29日",
+			expr: "0 0 29 2 *",
+			from: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "预定义表达式",
+			expr: "@monthly",
+			from: time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Next(tt.from))
+		})
+	}
+
+	t.Run("没有可执行的时间", func(t *testing.T) {
+		c, err := Parse("0 0 30 2 *")
+		require.NoError(t, err)
+		assert.True(t, c.Next(time.Now()).IsZero())
+	})
+
+	t.Run("夏令时开始时跳过不存在的时间", func(t *testing.T) {
+		newYork, err := LoadLocation("America/New_York")
+		require.NoError(t, err)
+
+		// 2024-03-10 02:00 开始夏令时，当天没有 02:30
+		c, err := Parse("30 2 * * *")
+		require.NoError(t, err)
+		next := c.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, newYork))
+		assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, newYork), next)
+	})
+}
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("")
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+
+	_, err = LoadLocation("Mars/Olympus")
+	assert.Error(t, err)
+}
@@ -0,0 +1,310 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PassphraseEnv 保存密钥存储密码的环境变量，未设置时使用密钥文件
+const PassphraseEnv = "APIWATCH_SECRETS_PASSPHRASE"
+
+const (
+	// keySize AES-256密钥长度
+	keySize = 32
+
+	// pbkdf2Iterations 从密码派生密钥的迭代次数
+	pbkdf2Iterations = 600000
+
+	// 加密密钥的来源
+	kdfPBKDF2  = "pbkdf2-sha256"
+	kdfKeyFile = "keyfile"
+)
+
+var (
+	// ErrSecretNotFound 密钥不存在
+	ErrSecretNotFound = errors.New("密钥不存在")
+
+	// ErrInvalidName 密钥名称无效
+	ErrInvalidName = errors.New("无效的密钥名称")
+
+	// ErrDecrypt 无法解密密钥存储
+	ErrDecrypt = errors.New("无法解密密钥存储（密码或密钥文件不正确）")
+
+	// namePattern 密钥名称只能包含字母、数字、下划线、点和连字符
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+)
+
+// Info 密钥的信息，不包含密钥的值
+type Info struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// secret 一个密钥
+type secret struct {
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// storeFile 密钥存储文件的内容，所有密钥整体加密保存在 Data 中
+type storeFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt,omitempty"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// Store 加密的本地密钥存储
+//
+// 所有密钥使用AES-256-GCM加密后保存在单个文件中。加密密钥由密码通过PBKDF2派生，
+// 未提供密码时使用随机生成的密钥文件（代替系统钥匙串，仅限当前用户读取）。
+type Store struct {
+	path string
+	kdf  string
+	salt []byte
+	key  []byte
+
+	mu      sync.RWMutex
+	secrets map[string]secret
+
+	now func() time.Time
+}
+
+// Open 打开密钥存储文件，文件不存在时创建空存储
+//
+// passphrase 不为空时从密码派生加密密钥，否则使用 keyFile 中的密钥，密钥文件不存在时生成新的密钥。
+func Open(path, keyFile, passphrase string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥存储目录失败: %w", err)
+	}
+
+	s := &Store{
+		path:    path,
+		secrets: make(map[string]secret),
+		now:     time.Now,
+	}
+
+	var file storeFile
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("读取密钥存储失败: %w", err)
+	default:
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("解析密钥存储失败: %w", err)
+		}
+	}
+
+	if passphrase != "" {
+		s.kdf = kdfPBKDF2
+		s.salt = file.Salt
+		if file.KDF != kdfPBKDF2 || len(s.salt) == 0 {
+			s.salt = make([]byte, 16)
+			rand.Read(s.salt)
+		}
+		if s.key, err = pbkdf2.Key(sha256.New, passphrase, s.salt, pbkdf2Iterations, keySize); err != nil {
+			return nil, fmt.Errorf("派生加密密钥失败: %w", err)
+		}
+	} else {
+		s.kdf = kdfKeyFile
+		if s.key, err = loadKeyFile(keyFile, data == nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if data == nil {
+		return s, nil
+	}
+	if file.KDF != s.kdf {
+		if file.KDF == kdfPBKDF2 {
+			return nil, fmt.Errorf("%w: 密钥存储使用密码加密，请设置环境变量 %s", ErrDecrypt, PassphraseEnv)
+		}
+		return nil, fmt.Errorf("%w: 密钥存储使用密钥文件加密", ErrDecrypt)
+	}
+
+	plain, err := s.decrypt(file.Nonce, file.Data)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(plain, &s.secrets); err != nil {
+		return nil, fmt.Errorf("解析密钥存储失败: %w", err)
+	}
+	return s, nil
+}
+
+// loadKeyFile 读取密钥文件，文件不存在且 create 为true时生成新的密钥
+func loadKeyFile(path string, create bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("无效的密钥文件: %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	if !create {
+		return nil, fmt.Errorf("%w: 密钥文件不存在: %s", ErrDecrypt, path)
+	}
+
+	key := make([]byte, keySize)
+	rand.Read(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥文件目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("写入密钥文件失败: %w", err)
+	}
+	return key, nil
+}
+
+// ValidateName 检查密钥名称是否有效
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q（只能包含字母、数字、下划线、点和连字符）", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// Lookup 返回密钥的值，密钥不存在时返回 ErrSecretNotFound
+func (s *Store) Lookup(name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sec, ok := s.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return sec.Value, nil
+}
+
+// List 按名称顺序返回所有密钥的信息
+func (s *Store) List() []Info {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]Info, 0, len(s.secrets))
+	for name, sec := range s.secrets {
+		infos = append(infos, Info{Name: name, UpdatedAt: sec.UpdatedAt})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Set 新增或更新密钥
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, existed := s.secrets[name]
+	s.secrets[name] = secret{Value: value, UpdatedAt: s.now()}
+	if err := s.save(); err != nil {
+		if existed {
+			s.secrets[name] = old
+		} else {
+			delete(s.secrets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete 删除密钥，密钥不存在时返回 ErrSecretNotFound
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.secrets[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	delete(s.secrets, name)
+	if err := s.save(); err != nil {
+		s.secrets[name] = old
+		return err
+	}
+	return nil
+}
+
+// save 加密并写入密钥存储文件，调用方需持有写锁
+func (s *Store) save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("序列化密钥存储失败: %w", err)
+	}
+
+	gcm, err := s.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+
+	data, err := json.MarshalIndent(storeFile{
+		Version: 1,
+		KDF:     s.kdf,
+		Salt:    s.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化密钥存储失败: %w", err)
+	}
+
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return fmt.Errorf("写入密钥存储失败: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("写入密钥存储失败: %w", err)
+	}
+	return nil
+}
+
+// decrypt 解密密钥存储的内容
+func (s *Store) decrypt(nonce, data []byte) ([]byte, error) {
+	gcm, err := s.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("解析密钥存储失败: 无效的nonce")
+	}
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// aead 创建AES-GCM加密器
+func (s *Store) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("使用密钥文件加密并在重新打开后保留", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "secrets.json")
+		keyFile := filepath.Join(dir, "secrets.key")
+
+		store, err := Open(path, keyFile, "")
+		require.NoError(t, err)
+		require.NoError(t, store.Set("api-token", "s3cr3t-value"))
+		require.NoError(t, store.Set("db.password", "p@ss"))
+
+		for _, p := range []string{path, keyFile} {
+			info, err := os.Stat(p)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "s3cr3t-value", "密钥的值不以明文保存")
+
+		reopened, err := Open(path, keyFile, "")
+		require.NoError(t, err)
+		value, err := reopened.Lookup("api-token")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cr3t-value", value)
+
+		infos := reopened.List()
+		require.Len(t, infos, 2)
+		assert.Equal(t, "api-token", infos[0].Name)
+		assert.Equal(t, "db.password", infos[1].Name)
+		assert.False(t, infos[0].UpdatedAt.IsZero())
+
+		require.NoError(t, os.Remove(keyFile))
+		_, err = Open(path, keyFile, "")
+		assert.ErrorIs(t, err, ErrDecrypt, "密钥文件丢失时不生成新的密钥")
+	})
+
+	t.Run("使用密码加密", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "secrets.json")
+		keyFile := filepath.Join(dir, "secrets.key")
+
+		store, err := Open(path, keyFile, "correct horse")
+		require.NoError(t, err)
+		require.NoError(t, store.Set("token", "value"))
+		assert.NoFileExists(t, keyFile)
+
+		_, err = Open(path, keyFile, "wrong")
+		assert.ErrorIs(t, err, ErrDecrypt)
+		_, err = Open(path, keyFile, "")
+		assert.ErrorIs(t, err, ErrDecrypt)
+
+		reopened, err := Open(path, keyFile, "correct horse")
+		require.NoError(t, err)
+		value, err := reopened.Lookup("token")
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("删除和无效的名称", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := Open(filepath.Join(dir, "secrets.json"), filepath.Join(dir, "secrets.key"), "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Set("token", "value"))
+		require.NoError(t, store.Delete("token"))
+		_, err = store.Lookup("token")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+		assert.ErrorIs(t, store.Delete("token"), ErrSecretNotFound)
+
+		for _, name := range []string{"", "has space", "a/b", strings.Repeat("x", 129)} {
+			assert.ErrorIs(t, store.Set(name, "v"), ErrInvalidName, name)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/zx06/apiwatch/notification"
+)
+
+// handleGetDeliveries 获取通知的投递记录
+//
+// 查询参数：status 只返回该状态（pending、delivered、failed）的记录；limit 返回数量
+func (s *Server) handleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := notification.DeliveryStatus(query.Get("status"))
+	if status != "" && !status.Valid() {
+		writeError(w, http.StatusBadRequest, errors.New("无效的status参数"))
+		return
+	}
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("无效的limit参数"))
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := s.api.GetDeliveries(status, limit)
+	if err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// handleResendDelivery 立即重新发送一条通知
+func (s *Server) handleResendDelivery(w http.ResponseWriter, r *http.Request) {
+	if err := s.api.ResendDelivery(r.PathValue("id")); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zx06/apiwatch/core"
+)
+
+const (
+	// eventBufferSize 每个订阅者的事件缓冲大小
+	eventBufferSize = 64
+
+	// sseHeartbeatInterval SSE心跳间隔
+	sseHeartbeatInterval = 15 * time.Second
+
+	// wsPingInterval WebSocket心跳间隔
+	wsPingInterval = 30 * time.Second
+
+	// wsWriteTimeout WebSocket单条消息写入超时
+	wsWriteTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// eventStream 事件流订阅者，实现core.EventListener接口
+type eventStream struct {
+	ruleIDs map[string]bool
+	types   map[core.EventType]bool
+	events  chan core.Event
+}
+
+// newEventStream 根据查询参数创建事件流
+//
+// 支持的过滤参数（可重复，也可用逗号分隔）：
+//   - rule_id: 只接收指定规则的事件
+//   - type: 只接收指定类型的事件
+func newEventStream(r *http.Request) *eventStream {
+	stream := &eventStream{
+		events: make(chan core.Event, eventBufferSize),
+	}
+
+	query := r.URL.Query()
+	if ids := splitQuery(query["rule_id"]); len(ids) > 0 {
+		stream.ruleIDs = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			stream.ruleIDs[id] = true
+		}
+	}
+	if types := splitQuery(query["type"]); len(types) > 0 {
+		stream.types = make(map[core.EventType]bool, len(types))
+		for _, t := range types {
+			stream.types[core.EventType(t)] = true
+		}
+	}
+
+	return stream
+}
+
+// OnEvent 实现EventListener接口
+func (s *eventStream) OnEvent(event core.Event) {
+	if !s.match(event) {
+		return
+	}
+
+	// 订阅者处理过慢时丢弃事件，避免阻塞事件总线
+	select {
+	case s.events <- event:
+	default:
+		slog.Warn("事件订阅者处理过慢，丢弃事件",
+			"event_type", event.Type,
+			"rule_id", event.RuleID,
+		)
+	}
+}
+
+// match 检查事件是否满足过滤条件
+func (s *eventStream) match(event core.Event) bool {
+	if s.ruleIDs != nil && !s.ruleIDs[event.RuleID] {
+		return false
+	}
+	if s.types != nil && !s.types[event.Type] {
+		return false
+	}
+	return true
+}
+
+// handleEventsSSE 以Server-Sent Events推送事件
+func (s *Server) handleEventsSSE(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		slog.Warn("SSE不支持刷新", "error", err)
+		return
+	}
+
+	stream := newEventStream(r)
+	s.api.Subscribe(stream)
+	defer s.api.Unsubscribe(stream)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event := <-stream.events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.Warn("序列化事件失败", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// handleEventsWebSocket 以WebSocket推送事件
+func (s *Server) handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已经写入了错误响应
+		slog.Warn("WebSocket握手失败", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	stream := newEventStream(r)
+	s.api.Subscribe(stream)
+	defer s.api.Unsubscribe(stream)
+
+	// 读取循环：处理控制帧并检测连接关闭，客户端发来的数据消息被忽略
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			deadline := time.Now().Add(wsWriteTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case event := <-stream.events:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// splitQuery 展开可重复且以逗号分隔的查询参数
+func splitQuery(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/core"
+)
+
+func TestEventStream_Match(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		event core.Event
+		want  bool
+	}{
+		{
+			name:  "无过滤条件",
+			query: "",
+			event: core.Event{Type: core.EventRuleAdded, RuleID: "a"},
+			want:  true,
+		},
+		{
+			name:  "按规则过滤-命中",
+			query: "rule_id=a,b",
+			event: core.Event{Type: core.EventRuleAdded, RuleID: "b"},
+			want:  true,
+		},
+		{
+			name:  "按规则过滤-未命中",
+			query: "rule_id=a&rule_id=b",
+			event: core.Event{Type: core.EventRuleAdded, RuleID: "c"},
+			want:  false,
+		},
+		{
+			name:  "按类型过滤-命中",
+			query: "type=content_changed",
+			event: core.Event{Type: core.EventContentChanged, RuleID: "a"},
+			want:  true,
+		},
+		{
+			name:  "按类型过滤-未命中",
+			query: "type=content_changed,monitor_error",
+			event: core.Event{Type: core.EventRuleStatusChanged, RuleID: "a"},
+			want:  false,
+		},
+		{
+			name:  "组合过滤",
+			query: "type=monitor_error&rule_id=a",
+			event: core.Event{Type: core.EventMonitorError, RuleID: "b"},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/events?"+tt.query, nil)
+			stream := newEventStream(req)
+			assert.Equal(t, tt.want, stream.match(tt.event))
+		})
+	}
+}
+
+// waitForSubscribers 等待订阅者数量达到预期
+func waitForSubscribers(t *testing.T, api *stubAPI, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return api.subscriberCount() == n
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_EventsSSE(t *testing.T) {
+	api := newStubAPI()
+	ts := httptest.NewServer(NewServer(api, ""))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events?type=content_changed")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	waitForSubscribers(t, api, 1)
+
+	// 不匹配的事件应被过滤
+	api.publish(core.Event{Type: core.EventRuleAdded, RuleID: "r1"})
+	api.publish(core.Event{
+		Type:      core.EventContentChanged,
+		RuleID:    "r1",
+		Timestamp: time.Now(),
+		Data:      "new content",
+	})
+
+	reader := bufio.NewReader(resp.Body)
+	var eventName, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			eventName = name
+		}
+		if d, ok := strings.CutPrefix(line, "data: "); ok {
+			data = d
+		}
+	}
+
+	assert.Equal(t, "content_changed", eventName)
+
+	var event core.Event
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, core.EventContentChanged, event.Type)
+	assert.Equal(t, "r1", event.RuleID)
+	assert.Equal(t, "new content", event.Data)
+}
+
+func TestServer_EventsWebSocket(t *testing.T) {
+	api := newStubAPI()
+	ts := httptest.NewServer(NewServer(api, "secret"))
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/events/ws?rule_id=r2&access_token=secret"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+
+	waitForSubscribers(t, api, 1)
+
+	api.publish(core.Event{Type: core.EventMonitorError, RuleID: "r1"})
+	api.publish(core.Event{Type: core.EventMonitorError, RuleID: "r2", Data: "boom"})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var event core.Event
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, core.EventMonitorError, event.Type)
+	assert.Equal(t, "r2", event.RuleID)
+	assert.Equal(t, "boom", event.Data)
+
+	// 关闭连接后应取消订阅
+	conn.Close()
+	waitForSubscribers(t, api, 0)
+}
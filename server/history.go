@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// handleGetHistory 获取规则的内容历史
+//
+// 查询参数：limit 返回数量；before RFC3339时间，只返回早于该时间的记录（用于分页）
+func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("无效的limit参数"))
+			return
+		}
+		limit = n
+	}
+
+	var before time.Time
+	if v := query.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("无效的before参数，需要RFC3339格式"))
+			return
+		}
+		before = t
+	}
+
+	entries, err := s.api.GetHistory(r.PathValue("id"), limit, before)
+	if err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/zx06/apiwatch/models"
+)
+
+// handleGetRules 获取所有规则
+func (s *Server) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.api.GetRules()
+	if err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// handleGetRule 获取单个规则
+func (s *Server) handleGetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := s.api.GetRule(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// handleAddRule 添加规则
+func (s *Server) handleAddRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.MonitorRule
+	if err := decodeJSON(w, r, &rule); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.api.AddRule(&rule); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, &rule)
+}
+
+// handleUpdateRule 更新规则
+func (s *Server) handleUpdateRule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var rule models.MonitorRule
+	if err := decodeJSON(w, r, &rule); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 路径中的ID优先，请求体中的ID必须一致
+	if rule.ID != "" && rule.ID != id {
+		writeError(w, http.StatusBadRequest, errors.New("请求体中的规则ID与路径不一致"))
+		return
+	}
+	rule.ID = id
+
+	if err := s.api.UpdateRule(&rule); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &rule)
+}
+
+// handleDeleteRule 删除规则
+func (s *Server) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	if err := s.api.DeleteRule(r.PathValue("id")); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleStartMonitoring 启动监控
+func (s *Server) handleStartMonitoring(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.api.StartMonitoring(id); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	s.writeRule(w, id)
+}
+
+// handleStopMonitoring 停止监控
+func (s *Server) handleStopMonitoring(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.api.StopMonitoring(id); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	s.writeRule(w, id)
+}
+
+// handleCheckNow 立即检查
+func (s *Server) handleCheckNow(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.api.CheckNow(id); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	s.writeRule(w, id)
+}
+
+// handleStopAllMonitoring 停止所有监控
+func (s *Server) handleStopAllMonitoring(w http.ResponseWriter, r *http.Request) {
+	if err := s.api.StopAllMonitoring(); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRule 写入规则的最新状态
+func (s *Server) writeRule(w http.ResponseWriter, id string) {
+	rule, err := s.api.GetRule(id)
+	if err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
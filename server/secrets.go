@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+)
+
+// setSecretRequest 设置密钥的请求体
+type setSecretRequest struct {
+	Value string `json:"value"`
+}
+
+// handleListSecrets 获取所有密钥的名称和更新时间，不返回密钥的值
+func (s *Server) handleListSecrets(w http.ResponseWriter, r *http.Request) {
+	infos, err := s.api.ListSecrets()
+	if err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handleSetSecret 新增或更新密钥
+func (s *Server) handleSetSecret(w http.ResponseWriter, r *http.Request) {
+	var req setSecretRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.api.SetSecret(r.PathValue("name"), req.Value); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteSecret 删除密钥
+func (s *Server) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	if err := s.api.DeleteSecret(r.PathValue("name")); err != nil {
+		writeError(w, statusFromError(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/monitor"
+)
+
+// maxRequestBody 请求体大小上限（1MB）
+const maxRequestBody = 1 << 20
+
+// Server REST API服务，将CoreAPI暴露为HTTP接口
+type Server struct {
+	api   core.CoreAPI
+	token string
+	mux   *http.ServeMux
+}
+
+// NewServer 创建REST API服务
+//
+// token 不为空时，所有请求都必须携带 "Authorization: Bearer <token>" 请求头；
+// 浏览器中的EventSource和WebSocket无法设置请求头，因此也接受 access_token 查询参数。
+func NewServer(api core.CoreAPI, token string) *Server {
+	s := &Server{
+		api:   api,
+		token: token,
+		mux:   http.NewServeMux(),
+	}
+	s.routes()
+	return s
+}
+
+// routes 注册路由
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/health", s.handleHealth)
+
+	// 规则管理
+	s.mux.HandleFunc("GET /api/rules", s.handleGetRules)
+	s.mux.HandleFunc("POST /api/rules", s.handleAddRule)
+	s.mux.HandleFunc("GET /api/rules/{id}", s.handleGetRule)
+	s.mux.HandleFunc("PUT /api/rules/{id}", s.handleUpdateRule)
+	s.mux.HandleFunc("DELETE /api/rules/{id}", s.handleDeleteRule)
+
+	// 监控控制
+	s.mux.HandleFunc("POST /api/rules/{id}/start", s.handleStartMonitoring)
+	s.mux.HandleFunc("POST /api/rules/{id}/stop", s.handleStopMonitoring)
+	s.mux.HandleFunc("POST /api/rules/{id}/check", s.handleCheckNow)
+	s.mux.HandleFunc("POST /api/monitoring/stop-all", s.handleStopAllMonitoring)
+
+	// 历史记录
+	s.mux.HandleFunc("GET /api/rules/{id}/history", s.handleGetHistory)
+
+	// 通知投递
+	s.mux.HandleFunc("GET /api/deliveries", s.handleGetDeliveries)
+	s.mux.HandleFunc("POST /api/deliveries/{id}/resend", s.handleResendDelivery)
+
+	// 密钥管理
+	s.mux.HandleFunc("GET /api/secrets", s.handleListSecrets)
+	s.mux.HandleFunc("PUT /api/secrets/{name}", s.handleSetSecret)
+	s.mux.HandleFunc("DELETE /api/secrets/{name}", s.handleDeleteSecret)
+
+	// 事件流
+	s.mux.HandleFunc("GET /api/events", s.handleEventsSSE)
+	s.mux.HandleFunc("GET /api/events/ws", s.handleEventsWebSocket)
+}
+
+// ServeHTTP 实现http.Handler接口
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 拒绝其他网站的页面发起的跨域请求，未设置令牌时也不能被网页利用（CSRF）
+	if !sameOrigin(r) {
+		writeError(w, http.StatusForbidden, errors.New("不允许跨域请求"))
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("未授权的请求"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// sameOrigin 请求没有 Origin 头（非浏览器客户端）或 Origin 与请求的主机一致时返回true
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// authorized 校验访问令牌
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// handleHealth 健康检查
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// errorResponse 错误响应体
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("写入响应失败", "error", err)
+	}
+}
+
+// writeError 写入错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// statusFromError 根据错误类型选择HTTP状态码
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, core.ErrRuleNotFound),
+		errors.Is(err, core.ErrDeliveryNotFound),
+		errors.Is(err, core.ErrSecretNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrInvalidRule),
+		errors.Is(err, core.ErrInvalidSecret):
+		return http.StatusBadRequest
+	case errors.Is(err, monitor.ErrTaskNotFound),
+		errors.Is(err, monitor.ErrTaskNotRunning),
+		errors.Is(err, monitor.ErrTaskAlreadyRunning),
+		errors.Is(err, monitor.ErrCheckCanceled):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrSecretsUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// decodeJSON 解析请求体
+//
+// 只接受 application/json 类型，浏览器无需预检即可跨域发送的请求只能使用表单和纯文本类型。
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return errors.New("请求体必须为JSON（Content-Type: application/json）")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.New("无效的请求体: " + err.Error())
+	}
+	return nil
+}
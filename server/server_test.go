@@ -0,0 +1,623 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zx06/apiwatch/core"
+	"github.com/zx06/apiwatch/history"
+	"github.com/zx06/apiwatch/models"
+	"github.com/zx06/apiwatch/monitor"
+	"github.com/zx06/apiwatch/notification"
+	"github.com/zx06/apiwatch/secrets"
+)
+
+// stubAPI 用于测试的CoreAPI实现
+type stubAPI struct {
+	mu         sync.Mutex
+	rules      map[string]*models.MonitorRule
+	running    map[string]bool
+	history    map[string][]*history.Entry
+	deliveries []*notification.Delivery
+	resent     []string
+	secrets    map[string]string
+	listeners  []core.EventListener
+}
+
+func newStubAPI() *stubAPI {
+	return &stubAPI{
+		rules:   make(map[string]*models.MonitorRule),
+		running: make(map[string]bool),
+		history: make(map[string][]*history.Entry),
+		secrets: make(map[string]string),
+	}
+}
+
+func (s *stubAPI) GetRules() ([]*models.MonitorRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules := make([]*models.MonitorRule, 0, len(s.rules))
+	for _, r := range s.rules {
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (s *stubAPI) GetRule(id string) (*models.MonitorRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule, ok := s.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", core.ErrRuleNotFound, id)
+	}
+	return rule, nil
+}
+
+func (s *stubAPI) AddRule(rule *models.MonitorRule) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", core.ErrInvalidRule, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rule.ID == "" {
+		rule.ID = fmt.Sprintf("rule-%d", len(s.rules)+1)
+	}
+	s.rules[rule.ID] = rule
+	return nil
+}
+
+func (s *stubAPI) UpdateRule(rule *models.MonitorRule) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", core.ErrInvalidRule, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[rule.ID]; !ok {
+		return fmt.Errorf("%w: %s", core.ErrRuleNotFound, rule.ID)
+	}
+	s.rules[rule.ID] = rule
+	return nil
+}
+
+func (s *stubAPI) DeleteRule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[id]; !ok {
+		return fmt.Errorf("%w: %s", core.ErrRuleNotFound, id)
+	}
+	delete(s.rules, id)
+	return nil
+}
+
+func (s *stubAPI) StartMonitoring(ruleID string) error {
+	rule, err := s.GetRule(ruleID)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[ruleID] {
+		return fmt.Errorf("启动监控失败: %w: %s", monitor.ErrTaskAlreadyRunning, ruleID)
+	}
+	s.running[ruleID] = true
+	rule.Enabled = true
+	return nil
+}
+
+func (s *stubAPI) StopMonitoring(ruleID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running[ruleID] {
+		return fmt.Errorf("停止监控失败: %w: %s", monitor.ErrTaskNotFound, ruleID)
+	}
+	delete(s.running, ruleID)
+	s.rules[ruleID].Enabled = false
+	s.rules[ruleID].Status = models.StatusPaused
+	return nil
+}
+
+func (s *stubAPI) StopAllMonitoring() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = make(map[string]bool)
+	return nil
+}
+
+func (s *stubAPI) CheckNow(ruleID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running[ruleID] {
+		return fmt.Errorf("%w: %s，请先启动监控", monitor.ErrTaskNotRunning, ruleID)
+	}
+	s.rules[ruleID].LastChecked = time.Now().Format(time.RFC3339)
+	return nil
+}
+
+func (s *stubAPI) GetHistory(ruleID string, limit int, before time.Time) ([]*history.Entry, error) {
+	if _, err := s.GetRule(ruleID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*history.Entry, 0)
+	for _, e := range s.history[ruleID] {
+		if !before.IsZero() && !e.CheckedAt.Before(before) {
+			continue
+		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func (s *stubAPI) GetDeliveries(status notification.DeliveryStatus, limit int) ([]*notification.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*notification.Delivery, 0)
+	for _, d := range s.deliveries {
+		if status != "" && d.Status != status {
+			continue
+		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+func (s *stubAPI) ResendDelivery(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deliveries {
+		if d.ID == id {
+			s.resent = append(s.resent, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", core.ErrDeliveryNotFound, id)
+}
+
+func (s *stubAPI) ListSecrets() ([]secrets.Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]secrets.Info, 0, len(s.secrets))
+	for name := range s.secrets {
+		infos = append(infos, secrets.Info{Name: name})
+	}
+	return infos, nil
+}
+
+func (s *stubAPI) SetSecret(name, value string) error {
+	if err := secrets.ValidateName(name); err != nil {
+		return fmt.Errorf("%w: %q", core.ErrInvalidSecret, name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[name] = value
+	return nil
+}
+
+func (s *stubAPI) DeleteSecret(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", core.ErrSecretNotFound, name)
+	}
+	delete(s.secrets, name)
+	return nil
+}
+
+func (s *stubAPI) Subscribe(listener core.EventListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *stubAPI) Unsubscribe(listener core.EventListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, l := range s.listeners {
+		if l == listener {
+			s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *stubAPI) Initialize() error { return nil }
+func (s *stubAPI) Shutdown() error   { return nil }
+
+const validRuleJSON = `{
+	"name": "测试规则",
+	"url": "https://example.com",
+	"method": "GET",
+	"interval": "5m",
+	"extractor_type": "css",
+	"extractor_expr": "title"
+}`
+
+func doRequest(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp errorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Error
+}
+
+func TestServer_RuleCRUD(t *testing.T) {
+	api := newStubAPI()
+	srv := NewServer(api, "")
+
+	// 添加规则
+	rec := doRequest(t, srv, http.MethodPost, "/api/rules", validRuleJSON)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created models.MonitorRule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.NotEmpty(t, created.ID)
+	assert.Equal(t, "测试规则", created.Name)
+	assert.Equal(t, models.Duration(5*time.Minute), created.Interval)
+
+	t.Run("获取规则列表", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/rules", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var rules []*models.MonitorRule
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rules))
+		assert.Len(t, rules, 1)
+	})
+
+	t.Run("获取单个规则", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/rules/"+created.ID, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("更新规则", func(t *testing.T) {
+		body := strings.Replace(validRuleJSON, "测试规则", "新名称", 1)
+		rec := doRequest(t, srv, http.MethodPut, "/api/rules/"+created.ID, body)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rule, err := api.GetRule(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "新名称", rule.Name)
+	})
+
+	t.Run("更新时ID不一致", func(t *testing.T) {
+		body := strings.Replace(validRuleJSON, "{", `{"id": "other",`, 1)
+		rec := doRequest(t, srv, http.MethodPut, "/api/rules/"+created.ID, body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("删除规则", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodDelete, "/api/rules/"+created.ID, "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = doRequest(t, srv, http.MethodGet, "/api/rules/"+created.ID, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestServer_ErrorStatus(t *testing.T) {
+	api := newStubAPI()
+	srv := NewServer(api, "")
+
+	t.Run("规则不存在返回404", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodDelete, "/api/rules/missing", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, decodeError(t, rec), "规则不存在")
+	})
+
+	t.Run("无效的请求体返回400", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules", "{not json")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, decodeError(t, rec), "无效的请求体")
+	})
+
+	t.Run("规则验证失败返回400", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules", `{"url": "https://example.com"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, decodeError(t, rec), "规则名称不能为空")
+	})
+
+	t.Run("不支持的方法返回405", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPatch, "/api/rules", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_MonitoringControl(t *testing.T) {
+	api := newStubAPI()
+	srv := NewServer(api, "")
+
+	rec := doRequest(t, srv, http.MethodPost, "/api/rules", validRuleJSON)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created models.MonitorRule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	t.Run("未启动时立即检查返回409", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules/"+created.ID+"/check", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("启动监控", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules/"+created.ID+"/start", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var rule models.MonitorRule
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rule))
+		assert.True(t, rule.Enabled)
+	})
+
+	t.Run("重复启动返回409", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules/"+created.ID+"/start", "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("立即检查", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules/"+created.ID+"/check", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var rule models.MonitorRule
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rule))
+		assert.NotEmpty(t, rule.LastChecked)
+	})
+
+	t.Run("停止监控", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/rules/"+created.ID+"/stop", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var rule models.MonitorRule
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rule))
+		assert.False(t, rule.Enabled)
+		assert.Equal(t, models.StatusPaused, rule.Status)
+	})
+
+	t.Run("停止所有监控", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/monitoring/stop-all", "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
+
+func TestServer_Token(t *testing.T) {
+	srv := NewServer(newStubAPI(), "secret")
+
+	t.Run("缺少令牌返回401", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/rules", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("错误的令牌返回401", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/rules", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("正确的令牌", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/rules", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestServer_CrossOrigin(t *testing.T) {
+	api := newStubAPI()
+	srv := NewServer(api, "")
+
+	send := func(origin, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:8787/api/rules", strings.NewReader(validRuleJSON))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("拒绝其他网站的请求", func(t *testing.T) {
+		for _, origin := range []string{"https://evil.example.com", "http://127.0.0.1:9999", "null"} {
+			rec := send(origin, "application/json")
+			assert.Equal(t, http.StatusForbidden, rec.Code, origin)
+		}
+	})
+
+	t.Run("拒绝非JSON请求体", func(t *testing.T) {
+		for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+			rec := send("", contentType)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, contentType)
+			assert.Contains(t, decodeError(t, rec), "application/json")
+		}
+	})
+
+	t.Run("同源和非浏览器客户端", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, send("http://127.0.0.1:8787", "application/json; charset=utf-8").Code)
+		assert.Equal(t, http.StatusCreated, send("", "application/json").Code)
+	})
+
+	rules, err := api.GetRules()
+	require.NoError(t, err)
+	assert.Len(t, rules, 2)
+}
+
+// publish 向所有订阅者发布事件
+func (s *stubAPI) publish(event core.Event) {
+	s.mu.Lock()
+	listeners := make([]core.EventListener, len(s.listeners))
+	copy(listeners, s.listeners)
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l.OnEvent(event)
+	}
+}
+
+// subscriberCount 返回当前订阅者数量
+func (s *stubAPI) subscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.listeners)
+}
+
+func TestServer_History(t *testing.T) {
+	api := newStubAPI()
+	srv := NewServer(api, "")
+
+	rec := doRequest(t, srv, http.MethodPost, "/api/rules", validRuleJSON)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created models.MonitorRule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	api.history[created.ID] = []*history.Entry{
+		{RuleID: created.ID, Content: "c", StatusCode: 200, CheckedAt: base.Add(2 * time.Hour)},
+		{RuleID: created.ID, Content: "b", StatusCode: 200, CheckedAt: base.Add(time.Hour)},
+		{RuleID: created.ID, Content: "a", StatusCode: 200, CheckedAt: base},
+	}
+
+	t.Run("获取历史记录", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/rules/"+created.ID+"/history", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entries []*history.Entry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		assert.Len(t, entries, 3)
+	})
+
+	t.Run("分页参数", func(t *testing.T) {
+		path := "/api/rules/" + created.ID + "/history?limit=1&before=" + base.Add(2*time.Hour).Format(time.RFC3339)
+		rec := doRequest(t, srv, http.MethodGet, path, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entries []*history.Entry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		require.Len(t, entries, 1)
+		assert.Equal(t, "b", entries[0].Content)
+	})
+
+	t.Run("无效的参数", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/rules/"+created.ID+"/history?limit=x", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(t, srv, http.MethodGet, "/api/rules/"+created.ID+"/history?before=yesterday", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("规则不存在", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/rules/missing/history", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestServer_Deliveries(t *testing.T) {
+	api := newStubAPI()
+	api.deliveries = []*notification.Delivery{
+		{ID: "d2", Channel: "ops", Status: notification.DeliveryFailed, Attempts: 10, LastError: "超时"},
+		{ID: "d1", Channel: "r1/webhook#1", Status: notification.DeliveryDelivered, Attempts: 1},
+	}
+	srv := NewServer(api, "")
+
+	t.Run("获取投递记录", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/deliveries", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var deliveries []*notification.Delivery
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+		assert.Len(t, deliveries, 2)
+	})
+
+	t.Run("按状态过滤", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/deliveries?status=failed&limit=5", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var deliveries []*notification.Delivery
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "d2", deliveries[0].ID)
+		assert.Equal(t, "超时", deliveries[0].LastError)
+	})
+
+	t.Run("无效的参数", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/deliveries?status=lost", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(t, srv, http.MethodGet, "/api/deliveries?limit=-1", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("重新发送", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/deliveries/d2/resend", "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, []string{"d2"}, api.resent)
+	})
+
+	t.Run("投递记录不存在", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPost, "/api/deliveries/missing/resend", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestServer_Secrets(t *testing.T) {
+	api := newStubAPI()
+	srv := NewServer(api, "")
+
+	t.Run("设置密钥", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPut, "/api/secrets/api-token", `{"value":"s3cr3t"}`)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "s3cr3t", api.secrets["api-token"])
+	})
+
+	t.Run("列表不包含密钥的值", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodGet, "/api/secrets", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+
+		var infos []secrets.Info
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &infos))
+		require.Len(t, infos, 1)
+		assert.Equal(t, "api-token", infos[0].Name)
+	})
+
+	t.Run("无效的名称和请求体", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodPut, "/api/secrets/bad%20name", `{"value":"v"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(t, srv, http.MethodPut, "/api/secrets/token", `not json`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("删除密钥", func(t *testing.T) {
+		rec := doRequest(t, srv, http.MethodDelete, "/api/secrets/api-token", "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = doRequest(t, srv, http.MethodDelete, "/api/secrets/api-token", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}